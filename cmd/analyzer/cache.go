@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"schema-analyzer/internal/ai/cache"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	aiCacheDir string
+	aiCacheTTL time.Duration
+	noAICache  bool
+	pruneAll   bool
+)
+
+// addAICacheFlags 注册 AI 缓存参数
+func addAICacheFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&aiCacheDir, "ai-cache-dir", "", "AI 响应缓存目录（默认 "+cache.DefaultDir()+"）")
+	cmd.Flags().DurationVar(&aiCacheTTL, "ai-cache-ttl", cache.DefaultTTL, "AI 缓存有效期，0 表示永不过期")
+}
+
+// openAICache 打开 AI 缓存，--no-ai-cache 或打开失败时返回 nil（不缓存）
+func openAICache() *cache.Store {
+	if noAICache {
+		return nil
+	}
+	store, err := cache.Open(aiCacheDir, aiCacheTTL)
+	if err != nil {
+		fmt.Printf("⚠️  打开 AI 缓存失败: %v，本次不使用缓存\n", err)
+		return nil
+	}
+	return store
+}
+
+// newCacheCmd 创建 cache 命令：查看和清理 AI 响应缓存
+func newCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "查看和清理 AI 响应缓存",
+		Long: `AI 响应按服务商、模型、prompt 版本和输入内容缓存，重复扫描同一个库时不再调用模型。
+更换模型、修改 prompt 模板或领域后旧条目不会命中，可用 prune 清理。`,
+	}
+
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "统计缓存条目",
+		Args:  cobra.NoArgs,
+		Run:   runCacheStats,
+	}
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "删除过期的缓存条目",
+		Args:  cobra.NoArgs,
+		Run:   runCachePrune,
+	}
+	pruneCmd.Flags().BoolVar(&pruneAll, "all", false, "清空全部缓存")
+	addAICacheFlags(statsCmd)
+	addAICacheFlags(pruneCmd)
+
+	cmd.AddCommand(statsCmd, pruneCmd)
+	return cmd
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) {
+	store, err := cache.Open(aiCacheDir, aiCacheTTL)
+	if err != nil {
+		log.Fatalf("打开 AI 缓存失败: %v", err)
+	}
+	stats, err := store.Stats()
+	if err != nil {
+		log.Fatalf("统计 AI 缓存失败: %v", err)
+	}
+
+	fmt.Printf("📦 AI 缓存: %s\n", store.Dir())
+	if stats.Entries == 0 {
+		fmt.Println("   (空)")
+		return
+	}
+	fmt.Printf("   条目: %d（过期 %d），大小: %s\n", stats.Entries, stats.Expired, formatBytes(stats.Bytes))
+	fmt.Printf("   时间: %s ~ %s\n", stats.Oldest.Format("2006-01-02 15:04"), stats.Newest.Format("2006-01-02 15:04"))
+
+	kinds := make([]string, 0, len(stats.Kinds))
+	for kind := range stats.Kinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Printf("   - %s: %d\n", kind, stats.Kinds[kind])
+	}
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) {
+	store, err := cache.Open(aiCacheDir, aiCacheTTL)
+	if err != nil {
+		log.Fatalf("打开 AI 缓存失败: %v", err)
+	}
+	removed, freed, err := store.Prune(pruneAll)
+	if err != nil {
+		log.Fatalf("清理 AI 缓存失败: %v", err)
+	}
+	fmt.Printf("✓ 删除 %d 个缓存条目，释放 %s\n", removed, formatBytes(freed))
+}
+
+// formatBytes 把字节数格式化为 KB / MB
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
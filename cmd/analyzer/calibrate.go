@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"schema-analyzer/internal/analyzer"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	labelsFile     string
+	modelOutput    string
+	negativesRatio int
+)
+
+// newCalibrateCmd 创建 calibrate 命令：从标注关系拟合评分权重
+func newCalibrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "calibrate",
+		Short: "基于已知关系标定关系评分权重（逻辑回归）",
+		Long: `从一组标注过的真/假关系拟合证据权重，并把模型写入文件。
+未指定 --labels 时，使用数据库中声明的外键作为正样本，
+并为每个外键列挑选命名最接近的其他主键作为负样本。`,
+		Run: runCalibrate,
+	}
+
+	cmd.Flags().StringVar(&dbType, "type", "sqlserver", "数据库类型 (sqlserver/mysql)")
+	cmd.Flags().StringVar(&connStr, "conn", "", "连接字符串")
+	addSchemaFlags(cmd)
+	addFilterFlags(cmd)
+	addSafetyFlags(cmd)
+	cmd.Flags().StringVar(&namingProfile, "naming-profile", "u8", "命名规范 (u8/generic)")
+	cmd.Flags().StringVar(&labelsFile, "labels", "", "标注文件（JSON 数组，字段 from_table/from_column/to_table/to_column/related）")
+	cmd.Flags().IntVar(&negativesRatio, "negatives", 3, "每个外键生成的负样本数（仅在未指定 --labels 时使用）")
+	cmd.Flags().StringVar(&modelOutput, "output", "./scoring-model.json", "评分模型输出文件")
+	cmd.MarkFlagRequired("conn")
+
+	return cmd
+}
+
+func runCalibrate(cmd *cobra.Command, args []string) {
+	fmt.Println("🎯 开始标定关系评分模型...")
+
+	dbAdapter := openAdapter(cmd)
+	defer dbAdapter.Close()
+
+	meta, err := dbAdapter.IntrospectSchema()
+	if err != nil {
+		log.Fatalf("获取元数据失败: %v", err)
+	}
+	fmt.Printf("✓ 发现 %d 个表\n", len(meta.Tables))
+
+	inferer := newRelationshipInferer(dbAdapter)
+
+	// 1. 准备标注集
+	var pairs []analyzer.LabeledPair
+	if labelsFile != "" {
+		pairs, err = analyzer.LoadLabeledPairs(labelsFile)
+		if err != nil {
+			log.Fatalf("加载标注文件失败: %v", err)
+		}
+	} else {
+		fks, err := dbAdapter.GetForeignKeys()
+		if err != nil {
+			log.Fatalf("获取外键失败: %v", err)
+		}
+		if len(fks) == 0 {
+			log.Fatal("数据库中没有声明外键，请使用 --labels 指定标注文件")
+		}
+		pairs = inferer.BuildLabeledPairs(meta, fks, negativesRatio)
+	}
+	fmt.Printf("✓ 标注样本 %d 个\n", len(pairs))
+
+	// 2. 计算证据得分
+	fmt.Println("\n📐 计算证据得分...")
+	samples := inferer.CollectSamples(meta, pairs)
+
+	// 3. 拟合
+	fmt.Println("\n📈 拟合权重...")
+	model, report, err := analyzer.Calibrate(samples, analyzer.DefaultScoringModel())
+	if err != nil {
+		log.Fatalf("标定失败: %v", err)
+	}
+
+	for _, evidenceType := range analyzer.EvidenceTypes {
+		fmt.Printf("  - %s: %.3f\n", evidenceType, model.Weights[evidenceType])
+	}
+	fmt.Printf("  - bias: %.3f\n", model.Bias)
+	fmt.Printf("✓ 样本 %d（正样本 %d），阈值 %.2f\n", report.Samples, report.Positives, report.Threshold)
+	fmt.Printf("  准确率 %.1f%%，精确率 %.1f%%，召回率 %.1f%%，F1 %.3f\n",
+		report.Accuracy*100, report.Precision*100, report.Recall*100, report.F1)
+
+	if err := model.Save(modelOutput); err != nil {
+		log.Fatalf("保存评分模型失败: %v", err)
+	}
+	fmt.Printf("\n✅ 评分模型已写入 %s\n", modelOutput)
+	fmt.Printf("   使用：schema-analyzer scan --scoring-model %s ...\n", modelOutput)
+}
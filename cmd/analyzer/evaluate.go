@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"schema-analyzer/internal/analyzer"
+	"schema-analyzer/internal/evaluation"
+
+	"github.com/spf13/cobra"
+)
+
+var evalThresholds string
+
+// newEvaluateCmd 创建 evaluate 命令：评估关系推断质量
+func newEvaluateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "evaluate",
+		Short: "评估关系推断的精确率、召回率和 F1",
+		Long: `以数据库中声明的外键（或 --labels 标注文件）作为标准答案，
+隐藏外键后运行关系推断，输出各置信度阈值下的精确率、召回率、F1 以及误报/漏报列表。`,
+		Run: runEvaluate,
+	}
+
+	cmd.Flags().StringVar(&dbType, "type", "sqlserver", "数据库类型 (sqlserver/mysql)")
+	cmd.Flags().StringVar(&connStr, "conn", "", "连接字符串")
+	addSchemaFlags(cmd)
+	addFilterFlags(cmd)
+	addSafetyFlags(cmd)
+	cmd.Flags().StringVar(&namingProfile, "naming-profile", "u8", "命名规范 (u8/generic)")
+	cmd.Flags().StringVar(&scoringModel, "scoring-model", "", "关系评分模型文件（JSON）")
+	cmd.Flags().BoolVar(&keepAllCands, "keep-all-candidates", false, "不裁剪候选关系")
+	cmd.Flags().StringVar(&labelsFile, "labels", "", "标注文件（JSON），不指定则使用声明的外键")
+	cmd.Flags().StringVar(&evalThresholds, "thresholds", "0.1,0.2,0.3,0.4,0.5,0.6,0.7,0.8,0.9", "评估的置信度阈值（逗号分隔）")
+	cmd.Flags().StringVar(&outputDir, "output", "./output", "报告输出目录")
+	cmd.MarkFlagRequired("conn")
+
+	return cmd
+}
+
+func runEvaluate(cmd *cobra.Command, args []string) {
+	fmt.Println("📏 开始评估关系推断质量...")
+
+	thresholds, err := parseThresholds(evalThresholds)
+	if err != nil {
+		log.Fatalf("解析阈值失败: %v", err)
+	}
+
+	dbAdapter := openAdapter(cmd)
+	defer dbAdapter.Close()
+
+	// 1. 标准答案
+	var truth evaluation.GroundTruth
+	if labelsFile != "" {
+		pairs, err := analyzer.LoadLabeledPairs(labelsFile)
+		if err != nil {
+			log.Fatalf("加载标注文件失败: %v", err)
+		}
+		truth = evaluation.FromLabels(pairs)
+	} else {
+		fks, err := dbAdapter.GetForeignKeys()
+		if err != nil {
+			log.Fatalf("获取外键失败: %v", err)
+		}
+		truth = evaluation.FromForeignKeys(fks)
+	}
+	if truth.Positives() == 0 {
+		log.Fatal("标准答案中没有真实关系，请确认数据库声明了外键或使用 --labels")
+	}
+	fmt.Printf("✓ 标准答案: %d 个关系\n", truth.Positives())
+
+	// 2. 隐藏外键运行推断并评估
+	profile, ok := analyzer.GetNamingProfile(namingProfile)
+	if !ok {
+		log.Fatalf("不支持的命名规范: %s", namingProfile)
+	}
+	opts := evaluation.Options{
+		NamingProfile: profile,
+		Thresholds:    thresholds,
+
+		KeepAllCandidates: keepAllCands,
+	}
+	if scoringModel != "" {
+		model, err := analyzer.LoadScoringModel(scoringModel)
+		if err != nil {
+			log.Fatalf("加载评分模型失败: %v", err)
+		}
+		opts.Scorer = model
+	}
+
+	fmt.Println("\n🔗 运行关系推断...")
+	report, err := evaluation.Run(dbAdapter, truth, opts)
+	if err != nil {
+		log.Fatalf("评估失败: %v", err)
+	}
+
+	// 3. 输出
+	fmt.Println("\n阈值   精确率   召回率   F1")
+	for _, res := range report.Results {
+		fmt.Printf("%.2f   %5.1f%%   %5.1f%%   %.3f\n", res.Threshold, res.Precision*100, res.Recall*100, res.F1)
+	}
+	if best := report.Best(); best != nil {
+		fmt.Printf("\n✓ 最佳阈值 %.2f (F1 %.3f)\n", best.Threshold, best.F1)
+	}
+
+	os.MkdirAll(outputDir, 0755)
+	jsonData, _ := report.ToJSON()
+	os.WriteFile(filepath.Join(outputDir, "evaluation.json"), jsonData, 0644)
+	fmt.Printf("✓ %s\n", filepath.Join(outputDir, "evaluation.json"))
+	os.WriteFile(filepath.Join(outputDir, "evaluation.md"), []byte(report.Markdown()), 0644)
+	fmt.Printf("✓ %s\n", filepath.Join(outputDir, "evaluation.md"))
+	writeSafetyReport(dbAdapter, safetyOptions(cmd))
+}
+
+// parseThresholds 解析逗号分隔的阈值列表
+func parseThresholds(s string) ([]float64, error) {
+	var thresholds []float64
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		t, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, err
+		}
+		thresholds = append(thresholds, t)
+	}
+	if len(thresholds) == 0 {
+		return nil, fmt.Errorf("至少需要一个阈值")
+	}
+	return thresholds, nil
+}
@@ -4,11 +4,18 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"schema-analyzer/internal/adapter"
 	"schema-analyzer/internal/ai"
+	"schema-analyzer/internal/ai/prompt"
 	"schema-analyzer/internal/analyzer"
+	"schema-analyzer/internal/checkpoint"
 	"schema-analyzer/internal/graph"
+	"schema-analyzer/internal/incremental"
+	"schema-analyzer/internal/profile"
 	"schema-analyzer/internal/renderer"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -17,10 +24,38 @@ var (
 	dbType     string
 	connStr    string
 	schema     string
+	schemas    []string
+	databases  []string
+
+	includeTables []string
+	excludeTables []string
+	tablesFile    string
+	neighbors     bool
 	outputDir  string
 	sampleSize int
 	enableAI   bool
 	aiAPIKey   string
+	aiProvider string
+	aiBaseURL  string
+	aiModel    string
+	aiContext  int
+	aiDomain   string
+	aiPrompts  string
+	aiTimeout     time.Duration
+	aiRetries     int
+	aiRateLimit   float64
+	aiConcurrency int
+	aiSamples     int
+
+	skipRelations bool
+	enableProfile bool
+	keepAllCands  bool
+	scoringModel  string
+	namingProfile string
+
+	baselineFile      string
+	baselineTolerance float64
+	resumeScan        bool
 )
 
 func main() {
@@ -38,35 +73,140 @@ func main() {
 
 	scanCmd.Flags().StringVar(&dbType, "type", "sqlserver", "数据库类型 (sqlserver/mysql)")
 	scanCmd.Flags().StringVar(&connStr, "conn", "", "连接字符串")
-	scanCmd.Flags().StringVar(&schema, "schema", "", "数据库 schema (MySQL 必需)")
+	addSchemaFlags(scanCmd)
+	addFilterFlags(scanCmd)
+	addSafetyFlags(scanCmd)
 	scanCmd.Flags().StringVar(&outputDir, "output", "./output", "输出目录")
 	scanCmd.Flags().IntVar(&sampleSize, "sample", 1000, "采样大小")
-	scanCmd.Flags().BoolVar(&enableAI, "enable-ai", false, "启用 AI 增强（需要 API Key）")
-	scanCmd.Flags().StringVar(&aiAPIKey, "ai-key", "", "AI API Key（或使用环境变量 DASHSCOPE_API_KEY）")
+	scanCmd.Flags().BoolVar(&enableAI, "enable-ai", false, "启用 AI 增强（云端服务需要 API Key，也可用本地模型）")
+	scanCmd.Flags().StringVar(&aiAPIKey, "ai-key", "", "AI API Key（或使用环境变量 DASHSCOPE_API_KEY / OPENAI_API_KEY）")
+	scanCmd.Flags().StringVar(&aiProvider, "ai-provider", ai.ProviderDashScope, "AI 服务商 ("+strings.Join(ai.Providers(), "/")+")")
+	scanCmd.Flags().StringVar(&aiBaseURL, "ai-base-url", "", "接口地址：OpenAI 兼容接口（如 https://api.deepseek.com/v1，或环境变量 OPENAI_BASE_URL）或本地模型服务（默认 Ollama http://localhost:11434，llama.cpp http://localhost:8080）")
+	scanCmd.Flags().StringVar(&aiModel, "ai-model", "", "AI 模型（默认 dashscope 为 qwen-plus，openai 为 gpt-4o-mini，ollama 为 qwen2.5:7b）")
+	scanCmd.Flags().IntVar(&aiContext, "ai-context", 0, "本地模型的上下文长度（token，默认取模型上限与 8192 的较小值）")
+	scanCmd.Flags().StringVar(&aiDomain, "ai-domain", "", "AI prompt 的业务领域 ("+strings.Join(prompt.Domains(), "/")+" 或领域 JSON 文件)，默认按 --naming-profile 选择 u8 或 generic")
+	scanCmd.Flags().StringVar(&aiPrompts, "ai-prompts", "", "prompt 模板目录，其中的 <模板名>.tmpl 覆盖内置模板")
+	scanCmd.Flags().DurationVar(&aiTimeout, "ai-timeout", ai.DefaultTimeout, "单次 AI 请求的超时")
+	scanCmd.Flags().IntVar(&aiRetries, "ai-retries", ai.DefaultMaxRetries, "AI 请求遇到限流（429）、服务端错误（5xx）或网络错误时的重试次数，0 不重试")
+	scanCmd.Flags().Float64Var(&aiRateLimit, "ai-rate-limit", 0, "每秒最多发出的 AI 请求数（0 不限制）")
+	scanCmd.Flags().IntVar(&aiConcurrency, "ai-concurrency", 0, "同时进行的 AI 请求数（默认云端服务 4，本地模型 1）")
+	scanCmd.Flags().IntVar(&aiSamples, "ai-samples", 0, "每个字段随 AI 请求发送的脱敏样例数（默认 0，只发送统计和取值模式）")
+	addAIBudgetFlags(scanCmd)
+	addAIPrivacyFlags(scanCmd)
+	addAICacheFlags(scanCmd)
+	scanCmd.Flags().BoolVar(&noAICache, "no-ai-cache", false, "不使用 AI 响应缓存")
+	scanCmd.Flags().BoolVar(&enableProfile, "profile", false, "生成列数据画像（profile.md / profile.json）")
+	scanCmd.Flags().BoolVar(&skipRelations, "skip-relations", false, "跳过表间关系推断")
+	scanCmd.Flags().BoolVar(&keepAllCands, "keep-all-candidates", false, "保留同一字段的全部候选关系（默认只保留最佳目标）")
+	scanCmd.Flags().StringVar(&scoringModel, "scoring-model", "", "关系评分模型文件（JSON，可由 calibrate 命令生成）")
+	scanCmd.Flags().StringVar(&namingProfile, "naming-profile", "u8", "命名规范 (u8/generic)")
+	scanCmd.Flags().StringVar(&reviewFile, "review-file", "", "审核记录文件（默认 <output>/review.json）")
+	scanCmd.Flags().StringVar(&baselineFile, "baseline", "", "上次扫描的 schema.json，只重新分析有变化的表")
+	scanCmd.Flags().BoolVar(&resumeScan, "resume", false, "从输出目录中的检查点恢复上次中断的扫描")
+	scanCmd.Flags().Float64Var(&baselineTolerance, "baseline-tolerance", incremental.DefaultRowCountTolerance, "行数相对变化超过该比例视为表有变化")
 	scanCmd.MarkFlagRequired("conn")
 
 	rootCmd.AddCommand(scanCmd)
+	rootCmd.AddCommand(newCalibrateCmd())
+	rootCmd.AddCommand(newEvaluateCmd())
+	rootCmd.AddCommand(newReviewCmd())
+	rootCmd.AddCommand(newCacheCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func runScan(cmd *cobra.Command, args []string) {
-	fmt.Println("🔍 开始扫描数据库...")
+// addSchemaFlags 注册 schema / 数据库选择参数
+func addSchemaFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&schema, "schema", "", "数据库 schema (MySQL 必需，多个时用 --schemas)")
+	cmd.Flags().StringSliceVar(&schemas, "schemas", nil, "扫描的 schema 列表（逗号分隔），SQL Server 默认扫描全部 schema")
+	cmd.Flags().StringSliceVar(&databases, "databases", nil, "扫描的数据库列表（逗号分隔，仅 MySQL，等同于 --schemas）")
+}
 
-	// 创建适配器
+// addFilterFlags 注册表过滤参数
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&includeTables, "include", nil, "只扫描匹配的表（通配符如 Inventory*、dbo.ST_*，re: 开头为正则，逗号分隔）")
+	cmd.Flags().StringSliceVar(&excludeTables, "exclude", nil, "排除匹配的表（语法同 --include）")
+	cmd.Flags().StringVar(&tablesFile, "tables-file", "", "表清单文件（每行一个表名或模式，并入 --include）")
+	cmd.Flags().BoolVar(&neighbors, "neighbors", false, "邻域模式：额外扫描被选中表通过声明外键直接引用的表")
+}
+
+// buildTableFilter 根据命令行参数创建表过滤器，没有任何过滤参数时返回 nil
+func buildTableFilter() *adapter.TableFilter {
+	include := includeTables
+	if tablesFile != "" {
+		patterns, err := adapter.LoadTableList(tablesFile)
+		if err != nil {
+			log.Fatalf("读取表清单失败: %v", err)
+		}
+		if len(patterns) == 0 {
+			log.Fatalf("表清单 %s 为空", tablesFile)
+		}
+		include = append(include, patterns...)
+	}
+	if len(include) == 0 && len(excludeTables) == 0 {
+		if neighbors {
+			log.Fatal("--neighbors 需要配合 --include 或 --tables-file 使用")
+		}
+		return nil
+	}
+
+	filter, err := adapter.NewTableFilter(include, excludeTables)
+	if err != nil {
+		log.Fatalf("表过滤参数无效: %v", err)
+	}
+	filter.Neighbors = neighbors
+	return filter
+}
+
+// selectedSchemas 合并 --schema / --schemas / --databases，去重并保持顺序
+func selectedSchemas() []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, list := range [][]string{{schema}, schemas, databases} {
+		for _, s := range list {
+			s = strings.TrimSpace(s)
+			if s == "" || seen[s] {
+				continue
+			}
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// openAdapter 根据命令行参数创建数据库适配器
+func openAdapter(cmd *cobra.Command) adapter.DBAdapter {
 	var dbAdapter adapter.DBAdapter
 	var err error
+	selected := selectedSchemas()
+	filter := buildTableFilter()
+	safety := safetyOptions(cmd)
 
 	switch dbType {
 	case "sqlserver":
-		dbAdapter, err = adapter.NewSQLServerAdapter(connStr)
+		if len(databases) > 0 {
+			log.Fatal("SQL Server 不支持跨数据库扫描，请在连接字符串中指定数据库，并用 --schemas 选择 schema")
+		}
+		var ss *adapter.SQLServerAdapter
+		ss, err = adapter.NewSQLServerAdapterWithOptions(connStr, safety)
+		if err == nil {
+			ss.SetSchemas(selected)
+			ss.SetTableFilter(filter)
+			dbAdapter = ss
+		}
 	case "mysql":
-		if schema == "" {
-			log.Fatal("MySQL 需要指定 --schema 参数")
+		if len(selected) == 0 {
+			log.Fatal("MySQL 需要指定 --schema、--schemas 或 --databases 参数")
+		}
+		var ms *adapter.MySQLAdapter
+		ms, err = adapter.NewMySQLAdapterWithOptions(connStr, safety, selected...)
+		if err == nil {
+			ms.SetTableFilter(filter)
+			dbAdapter = ms
 		}
-		dbAdapter, err = adapter.NewMySQLAdapter(connStr, schema)
 	default:
 		log.Fatalf("不支持的数据库类型: %s", dbType)
 	}
@@ -74,10 +214,46 @@ func runScan(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("连接数据库失败: %v", err)
 	}
+	if desc := describeSafety(safety); desc != "" {
+		fmt.Printf("🛡️  安全模式: %s\n", desc)
+	}
+	return dbAdapter
+}
+
+// newRelationshipInferer 根据命令行参数创建关系推断器
+func newRelationshipInferer(dbAdapter adapter.DBAdapter) *analyzer.RelationshipInferer {
+	inferer := analyzer.NewRelationshipInferer(dbAdapter)
+
+	profile, ok := analyzer.GetNamingProfile(namingProfile)
+	if !ok {
+		log.Fatalf("不支持的命名规范: %s", namingProfile)
+	}
+	inferer.SetNamingProfile(profile)
+
+	if scoringModel != "" {
+		model, err := analyzer.LoadScoringModel(scoringModel)
+		if err != nil {
+			log.Fatalf("加载评分模型失败: %v", err)
+		}
+		inferer.SetScorer(model)
+		fmt.Printf("✓ 使用评分模型: %s (%s)\n", scoringModel, model.Kind)
+	}
+
+	return inferer
+}
+
+func runScan(cmd *cobra.Command, args []string) {
+	fmt.Println("🔍 开始扫描数据库...")
+
+	// 创建适配器
+	dbAdapter := openAdapter(cmd)
 	defer dbAdapter.Close()
 
 	fmt.Println("✓ 数据库连接成功")
 
+	// 检查点：记录各阶段已完成的工作，中断后可用 --resume 恢复
+	cp := openCheckpoint()
+
 	// 1. 获取元数据
 	fmt.Println("\n📊 获取数据库元数据...")
 	meta, err := dbAdapter.IntrospectSchema()
@@ -85,18 +261,31 @@ func runScan(cmd *cobra.Command, args []string) {
 		log.Fatalf("获取元数据失败: %v", err)
 	}
 	fmt.Printf("✓ 发现 %d 个表\n", len(meta.Tables))
+	if found := meta.Schemas(); len(found) > 1 {
+		fmt.Printf("✓ 涉及 %d 个 schema: %s\n", len(found), strings.Join(found, ", "))
+	}
+
+	// 增量扫描：与基线对比，未变化的表沿用基线结果，只重新分析变化的表
+	var baseline *graph.SchemaGraph
+	var diff *incremental.Diff
+	if baselineFile != "" {
+		baseline, diff = compareBaseline(meta)
+	}
+	workMeta := diff.ChangedMeta(meta)
 
 	// 2. 构建 Schema Graph
 	fmt.Println("\n🔨 构建 Schema Graph...")
 	g := graph.NewSchemaGraph()
+	evidence := analyzer.NewEvidenceCollector()
+	evidence.SetSampleValues(aiSamples)
 
 	// 创建规则引擎解释器
 
 	// 添加表和列节点
-	for _, table := range meta.Tables {
+	for _, table := range workMeta.Tables {
 		// 表节点
 		tableNode := &graph.Node{
-			ID:   table.Name,
+			ID:   table.QualifiedName(),
 			Type: graph.NodeTypeTable,
 			Name: table.Name,
 			Properties: map[string]interface{}{
@@ -108,7 +297,8 @@ func runScan(cmd *cobra.Command, args []string) {
 		// 列节点
 		for _, col := range table.Columns {
 			// 采样统计
-			stats, _ := dbAdapter.SampleColumnStats(table.Name, col.Name, sampleSize)
+			stats := sampleColumnStats(dbAdapter, cp, table.QualifiedName(), col.Name)
+			evidence.AddStats(table.QualifiedName(), col.Name, stats)
 			
 			nullRatio := 0.0
 			distinctRate := 0.0
@@ -118,11 +308,11 @@ func runScan(cmd *cobra.Command, args []string) {
 			}
 
 			colNode := &graph.Node{
-				ID:   fmt.Sprintf("%s.%s", table.Name, col.Name),
+				ID:   fmt.Sprintf("%s.%s", table.QualifiedName(), col.Name),
 				Type: graph.NodeTypeColumn,
 				Name: col.Name,
 				Properties: map[string]interface{}{
-					"table":               table.Name,
+					"table":               table.QualifiedName(),
 					"data_type":           col.DataType,
 					"length":              col.Length,
 					"nullable":            col.Nullable,
@@ -135,27 +325,130 @@ func runScan(cmd *cobra.Command, args []string) {
 					"ai_confidence":       0.0,
 				},
 			}
+			if stats != nil && stats.SampleMethod != "" {
+				colNode.Properties["sample_method"] = stats.SampleMethod
+				if stats.EstimatedRows > 0 {
+					colNode.Properties["estimated_distinct"] = stats.EstimatedDistinct
+				}
+			}
 			g.AddNode(colNode)
 		}
 	}
 
-	fmt.Println("✓ Graph 构建完成")
-
-	// 3. AI 增强分析（可选）
-	if enableAI {
-		runAIEnhancedAnalysis(dbAdapter, meta, g)
+	if diff != nil {
+		carried := incremental.CarryOver(baseline, g, diff)
+		fmt.Printf("✓ 沿用基线: %d 个表，%d 个字段，%d 个关系\n", carried.Tables, carried.Columns, carried.Edges)
 	}
+	// 记录表指纹，本次输出可作为下次增量扫描的基线
+	for _, table := range meta.Tables {
+		incremental.AnnotateTable(g.GetNode(table.QualifiedName()), table)
+	}
+
+	cp.Flush()
+	fmt.Println("✓ Graph 构建完成")
 
-	// 4. 检测枚举表
+	// 3. 检测枚举表
 	fmt.Println("\n📋 检测枚举/码表...")
 	enumDetector := analyzer.NewEnumDetector(dbAdapter)
-	enumTables, err := enumDetector.DetectEnumTables(meta)
+	enumDetector.SetSampleSize(sampleSize)
+	enumDetector.SetEvidence(evidence)
+	if profile, ok := analyzer.GetNamingProfile(namingProfile); ok {
+		enumDetector.SetNamingProfile(profile)
+	}
+	enumTables, err := enumDetector.DetectEnumTables(workMeta)
 	if err != nil {
 		log.Printf("检测枚举表时出错: %v", err)
 	} else {
 		fmt.Printf("✓ 发现 %d 个可能的枚举表\n", len(enumTables))
 		for _, et := range enumTables {
-			fmt.Printf("  - %s (行数: %d, 置信度: %.2f)\n", et.Name, et.RowCount, et.Confidence)
+			fmt.Printf("  - %s (行数: %d, 取值: %d, 置信度: %.2f)\n", et.Name, et.RowCount, len(et.Values), et.Confidence)
+		}
+	}
+	enumColumns, err := enumDetector.DetectEnumColumns(workMeta)
+	if err != nil {
+		log.Printf("检测枚举列时出错: %v", err)
+	} else {
+		fmt.Printf("✓ 发现 %d 个枚举列\n", len(enumColumns))
+		for _, ec := range enumColumns {
+			fmt.Printf("  - %s.%s (取值: %d, 置信度: %.2f)\n", ec.Table, ec.Column, len(ec.Values), ec.Confidence)
+		}
+	}
+	enumRefs := enumDetector.LinkEnumReferences(meta, enumTables)
+	if diff != nil {
+		// 沿用基线的码表不重新检测，但变化的表对它们的引用边没有沿用，需要重新关联
+		carriedEnums := analyzer.CarriedEnumTables(g, diff.ChangedTables())
+		enumRefs = append(enumRefs, enumDetector.LinkChangedReferences(meta, workMeta, carriedEnums)...)
+		enumTables = append(enumTables, carriedEnums...)
+	}
+	analyzer.AnnotateEnums(g, enumTables, enumColumns)
+	analyzer.AddEnumReferences(g, enumRefs)
+	if len(enumRefs) > 0 {
+		fmt.Printf("✓ 发现 %d 个码表引用字段\n", len(enumRefs))
+	}
+
+	// 4. 推断表间关系
+	if !skipRelations {
+		fmt.Println("\n🔗 推断表间关系...")
+		inferer := newRelationshipInferer(dbAdapter)
+		inferer.SetFocusTables(diff.ChangedTables())
+		inferer.SetCheckpoint(cp)
+		edges, err := inferer.InferRelationships(meta)
+		if err != nil {
+			log.Printf("推断关系时出错: %v", err)
+		}
+		for _, edge := range edges {
+			g.AddEdge(edge)
+		}
+		fmt.Printf("✓ 发现 %d 个推断关系\n", len(edges))
+	}
+
+	// 5. AI 增强分析（可选），以列统计和上面得到的关系作为证据
+	if enableAI {
+		edges := make([]*graph.Edge, 0, len(g.Edges))
+		for _, edge := range g.Edges {
+			edges = append(edges, edge)
+		}
+		evidence.AddEdges(edges)
+		runAIEnhancedAnalysis(dbAdapter, workMeta, g, cp, evidence)
+	}
+
+	// 应用人工审核结论
+	reviewStore := loadReviewStore()
+	reviewResult := reviewStore.Apply(g)
+	if len(reviewStore.Decisions) > 0 || len(reviewResult.NewCandidates) > 0 {
+		fmt.Printf("✓ 应用审核记录: 确认 %d，否决 %d，人工添加 %d\n",
+			reviewResult.Accepted, reviewResult.Rejected, reviewResult.Manual)
+		fmt.Printf("  新候选关系 %d 个（待审核共 %d 个）\n", len(reviewResult.NewCandidates), reviewResult.Pending)
+		for _, edge := range reviewResult.NewCandidates {
+			fmt.Printf("  + %.2f  %s\n", edge.Confidence, edge.ID)
+		}
+	}
+
+	// 候选关系裁剪：每个字段只保留最佳目标，其余记为备选
+	if !skipRelations && !keepAllCands {
+		pruneResult := analyzer.NewEdgePruner().PruneGraph(g, meta)
+		if len(pruneResult.Pruned) > 0 {
+			fmt.Printf("✓ 裁剪候选关系: 保留 %d，转为备选 %d，存疑 %d\n",
+				len(pruneResult.Kept), len(pruneResult.Pruned), pruneResult.Ambiguous)
+		}
+	}
+
+	// 列数据画像（可选）
+	var profileReport *profile.Report
+	if enableProfile {
+		fmt.Println("\n📊 生成列数据画像...")
+		profiler := profile.NewProfiler(dbAdapter)
+		profiler.SetSampleSize(sampleSize)
+		profiler.SetCheckpoint(cp)
+		profileReport, err = profiler.Profile(workMeta)
+		if err != nil {
+			log.Printf("生成数据画像时出错: %v", err)
+		} else {
+			if diff != nil {
+				mergeBaselineProfile(profileReport, diff)
+			}
+			profile.AnnotateGraph(g, profileReport)
+			fmt.Printf("✓ 完成 %d 个表的数据画像\n", len(profileReport.Tables))
 		}
 	}
 
@@ -163,6 +456,14 @@ func runScan(cmd *cobra.Command, args []string) {
 	fmt.Println("\n📝 生成输出文件...")
 	os.MkdirAll(outputDir, 0755)
 
+	if profileReport != nil {
+		profileJSON, _ := profileReport.ToJSON()
+		os.WriteFile(fmt.Sprintf("%s/profile.json", outputDir), profileJSON, 0644)
+		fmt.Printf("✓ %s/profile.json\n", outputDir)
+		os.WriteFile(fmt.Sprintf("%s/profile.md", outputDir), []byte(profileReport.Markdown()), 0644)
+		fmt.Printf("✓ %s/profile.md\n", outputDir)
+	}
+
 	// JSON
 	jsonData, _ := g.ToJSON()
 	os.WriteFile(fmt.Sprintf("%s/schema.json", outputDir), jsonData, 0644)
@@ -187,31 +488,200 @@ func runScan(cmd *cobra.Command, args []string) {
 	os.WriteFile(fmt.Sprintf("%s/er.mmd", outputDir), []byte(mermaidContent), 0644)
 	fmt.Printf("✓ %s/er.mmd\n", outputDir)
 
+	// 审核记录（记录已出现过的候选关系，下次只报告新候选）
+	saveReviewStore(reviewStore)
+	fmt.Printf("✓ %s\n", reviewStore.Path())
+
+	writeSafetyReport(dbAdapter, safetyOptions(cmd))
+
+	// 扫描完整结束，检查点不再需要
+	if err := cp.Remove(); err != nil {
+		log.Printf("删除检查点失败: %v", err)
+	}
+
 	fmt.Println("\n✅ 分析完成！")
 }
 
 
+// openCheckpoint 打开输出目录中的检查点，签名由影响扫描结果的参数计算
+func openCheckpoint() *checkpoint.Store {
+	signature := checkpoint.Signature(
+		dbType, connStr, strings.Join(selectedSchemas(), ","), fmt.Sprint(sampleSize),
+		strings.Join(includeTables, ","), strings.Join(excludeTables, ","), tablesFile, fmt.Sprint(neighbors),
+		namingProfile, scoringModel, baselineFile, fmt.Sprint(baselineTolerance),
+		fmt.Sprint(safeMode), fmt.Sprint(maxTableRows), largeTables,
+		aiProvider, aiBaseURL, aiModel, fmt.Sprint(aiContext), aiDomain, aiPrompts, fmt.Sprint(aiSamples),
+		aiPrivacyFile, strings.Join(aiNeverSendTables, ","), strings.Join(aiNeverSendColumns, ","),
+		strings.Join(aiPseudonymTables, ","), strings.Join(aiPseudonymColumns, ","), aiSampleValueMode,
+	)
+	cp, err := checkpoint.Open(filepath.Join(outputDir, "checkpoint.json"), signature, resumeScan)
+	if err != nil {
+		log.Fatalf("打开检查点失败: %v", err)
+	}
+
+	if resumeScan {
+		stats := cp.Count(checkpoint.StageColumnStats)
+		profiled := cp.Count(checkpoint.StageProfile)
+		inferred := cp.Count(checkpoint.StageInference)
+		aiDone := cp.Count(checkpoint.StageAITables) + cp.Count(checkpoint.StageAIBatches)
+		if stats+profiled+inferred+aiDone == 0 {
+			fmt.Println("⚠️  没有可恢复的检查点，从头开始扫描")
+		} else {
+			fmt.Printf("✓ 从检查点恢复（%s）: 列统计 %d，画像 %d，关系推断 %d 个表，AI 结果 %d\n",
+				cp.UpdatedAt.Format("2006-01-02 15:04:05"), stats, profiled, inferred, aiDone)
+		}
+	}
+	return cp
+}
+
+// sampleColumnStats 采样列统计，优先使用检查点中已完成的结果
+func sampleColumnStats(dbAdapter adapter.DBAdapter, cp *checkpoint.Store, table, column string) *adapter.ColumnStats {
+	key := table + "." + column
+	var stats *adapter.ColumnStats
+	if cp.Load(checkpoint.StageColumnStats, key, &stats) {
+		return stats
+	}
+	stats, err := dbAdapter.SampleColumnStats(table, column, sampleSize)
+	if err != nil {
+		return nil
+	}
+	if err := cp.Save(checkpoint.StageColumnStats, key, stats); err != nil {
+		log.Printf("保存检查点失败: %v", err)
+	}
+	return stats
+}
+
+// compareBaseline 读取基线 schema.json 并与当前元数据对比
+func compareBaseline(meta *adapter.SchemaMetadata) (*graph.SchemaGraph, *incremental.Diff) {
+	data, err := os.ReadFile(baselineFile)
+	if err != nil {
+		log.Fatalf("读取基线失败: %v", err)
+	}
+	baseline, err := graph.FromJSON(data)
+	if err != nil {
+		log.Fatalf("解析基线失败: %v", err)
+	}
+
+	diff := incremental.Compare(baseline, meta, baselineTolerance)
+	fmt.Printf("✓ 对比基线 %s: 需重新分析 %d 个表，未变化 %d 个，已删除 %d 个\n",
+		baselineFile, len(diff.Changed), len(meta.Tables)-len(diff.Changed), len(diff.Removed))
+	for _, change := range diff.Changed {
+		fmt.Printf("  ~ %s: %s\n", change.Table, change.Reason)
+	}
+	for _, table := range diff.Removed {
+		fmt.Printf("  - %s\n", table)
+	}
+	return baseline, diff
+}
+
+// mergeBaselineProfile 并入基线目录中未变化表的数据画像
+func mergeBaselineProfile(report *profile.Report, diff *incremental.Diff) {
+	path := filepath.Join(filepath.Dir(baselineFile), "profile.json")
+	previous, err := profile.LoadReport(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("读取基线画像失败: %v", err)
+		}
+		return
+	}
+	if merged := report.Merge(previous, diff.Unchanged); merged > 0 {
+		fmt.Printf("✓ 沿用基线画像: %d 个表\n", merged)
+	}
+}
+
+// aiConfig 根据命令行参数和环境变量生成 AI 服务商配置
+func aiConfig() ai.Config {
+	cfg := ai.Config{Provider: aiProvider, BaseURL: aiBaseURL, Model: aiModel, APIKey: aiAPIKey, ContextLength: aiContext}
+	cfg.Timeout, cfg.MaxRetries, cfg.RateLimit = aiTimeout, aiRetries, aiRateLimit
+	if aiRetries == 0 {
+		cfg.MaxRetries = -1 // Config 中 0 表示默认值
+	}
+	cfg.Prompts = loadPrompts()
+	cfg.Cache = openAICache()
+	cfg.Usage = newAIUsage()
+	cfg.Privacy = newAIPrivacy()
+	cfg.Audit = openAIAudit()
+	if env := ai.APIKeyEnv(aiProvider); cfg.APIKey == "" && env != "" {
+		cfg.APIKey = os.Getenv(env)
+	}
+	if cfg.BaseURL == "" && aiProvider == ai.ProviderOpenAI {
+		cfg.BaseURL = os.Getenv("OPENAI_BASE_URL")
+	}
+	return cfg
+}
+
+// loadPrompts 加载 prompt 模板和业务领域。未指定领域时 U8 命名规范使用 u8 领域，其他使用 generic
+func loadPrompts() *prompt.Set {
+	name := aiDomain
+	if name == "" {
+		name = "generic"
+		if strings.EqualFold(namingProfile, "u8") {
+			name = "u8"
+		}
+	}
+	domain, err := prompt.LoadDomain(name)
+	if err != nil {
+		log.Fatalf("加载 AI 领域失败: %v", err)
+	}
+	prompts, err := prompt.Load(aiPrompts, domain)
+	if err != nil {
+		log.Fatalf("加载 prompt 模板失败: %v", err)
+	}
+	return prompts
+}
+
+// resolveTableName 把 AI 返回的表名解析为表节点 ID（带 schema 前缀）。
+// 不带前缀的表名只有在唯一时才能解析，解析不了时返回空
+func resolveTableName(meta *adapter.SchemaMetadata, name string) string {
+	var matches []string
+	for _, table := range meta.Tables {
+		if table.QualifiedName() == name {
+			return name
+		}
+		if table.Name == name {
+			matches = append(matches, table.QualifiedName())
+		}
+	}
+	if len(matches) != 1 {
+		return ""
+	}
+	return matches[0]
+}
+
 // runAIEnhancedAnalysis 运行 AI 增强分析
-func runAIEnhancedAnalysis(dbAdapter adapter.DBAdapter, meta *adapter.SchemaMetadata, g *graph.SchemaGraph) {
+func runAIEnhancedAnalysis(dbAdapter adapter.DBAdapter, meta *adapter.SchemaMetadata, g *graph.SchemaGraph, cp *checkpoint.Store, evidence *analyzer.EvidenceCollector) {
 	fmt.Println("\n🤖 启用 AI 增强分析...")
 	
-	// 获取 API Key
-	apiKey := aiAPIKey
-	if apiKey == "" {
-		apiKey = os.Getenv("DASHSCOPE_API_KEY")
+	// 创建 AI 客户端
+	cfg := aiConfig()
+	aiClient, err := ai.NewClient(cfg)
+	if err != nil {
+		cfg.Audit.Close()
 	}
-	
-	if apiKey == "" {
+	if err == ai.ErrMissingAPIKey {
 		fmt.Println("⚠️  未提供 API Key，跳过 AI 分析")
-		fmt.Println("   提示：使用 --ai-key 或设置环境变量 DASHSCOPE_API_KEY")
+		fmt.Printf("   提示：使用 --ai-key 或设置环境变量 %s\n", ai.APIKeyEnv(aiProvider))
 		return
 	}
-	
-	// 创建 AI 客户端
-	aiClient := ai.NewAlibabaClient(apiKey)
+	if err != nil {
+		fmt.Printf("⚠️  %v，跳过 AI 分析\n", err)
+		return
+	}
+	if aiMaxCost > 0 && !cfg.Usage.PriceKnown {
+		fmt.Println("⚠️  模型价格未知，--ai-max-cost 不生效（可用 --ai-price-input / --ai-price-output 指定价格）")
+	}
+	defer writeAIUsage(cfg.Usage)
+	defer closeAIPrivacy(cfg.Privacy, cfg.Audit)
 	
 	// 创建混合分析器
 	hybridAnalyzer := analyzer.NewHybridAnalyzer(dbAdapter, aiClient)
+	hybridAnalyzer.SetCheckpoint(cp)
+	hybridAnalyzer.SetEvidence(evidence)
+	concurrency := aiConcurrency
+	if concurrency <= 0 {
+		concurrency = ai.DefaultConcurrency(aiProvider)
+	}
+	hybridAnalyzer.SetConcurrency(concurrency)
 	
 	// 执行 AI 增强分析
 	enhanced, err := hybridAnalyzer.AnalyzeWithAI(meta)
@@ -219,6 +689,9 @@ func runAIEnhancedAnalysis(dbAdapter adapter.DBAdapter, meta *adapter.SchemaMeta
 		fmt.Printf("⚠️  AI 分析失败: %v\n", err)
 		return
 	}
+	if hits, misses := cfg.Cache.Usage(); hits+misses > 0 {
+		fmt.Printf("💾 AI 缓存: 命中 %d 次，未命中 %d 次（%s）\n", hits, misses, cfg.Cache.Dir())
+	}
 	
 	// 将 AI 解释添加到 Graph 节点
 	for tableName, table := range enhanced.Tables {
@@ -248,8 +721,13 @@ func runAIEnhancedAnalysis(dbAdapter adapter.DBAdapter, meta *adapter.SchemaMeta
 		}
 	}
 	
-	// 添加 AI 分析的表关系到 Graph
+	// 添加 AI 分析的表关系到 Graph，表名对应不上表节点的关系丢弃
 	for _, rel := range enhanced.TableRelationships {
+		from, to := resolveTableName(meta, rel.FromTable), resolveTableName(meta, rel.ToTable)
+		if from == "" || to == "" {
+			continue
+		}
+		rel.FromTable, rel.ToTable = from, to
 		edge := &graph.Edge{
 			ID:         fmt.Sprintf("%s->%s", rel.FromTable, rel.ToTable),
 			Type:       graph.EdgeTypeInferredFK,
@@ -299,4 +777,15 @@ func runAIEnhancedAnalysis(dbAdapter adapter.DBAdapter, meta *adapter.SchemaMeta
 	fmt.Printf("  - AI 直接识别: %d 个标准字段\n", standardCount)
 	fmt.Printf("  - AI 推断: %d 个自定义字段\n", inferredCount)
 	fmt.Printf("  - 关系推断: %d 个字段\n", relationCount)
+	for _, stage := range []string{"table_meaning", "table_relationships", "batch_explain"} {
+		if n := enhanced.Skipped[stage]; n > 0 {
+			fmt.Printf("  - 达到用量上限跳过: %s %d 次\n", stage, n)
+		}
+	}
+	if len(enhanced.Failures) > 0 {
+		fmt.Printf("  - ⚠️  失败: %d 次 AI 调用（已重试，相关表和字段保留算法结果）\n", len(enhanced.Failures))
+		for _, f := range enhanced.Failures {
+			fmt.Printf("    · [%s] %s: %s\n", f.Stage, f.Target, f.Error)
+		}
+	}
 }
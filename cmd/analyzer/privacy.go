@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"schema-analyzer/internal/ai"
+	"schema-analyzer/internal/ai/redact"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	aiPrivacyFile      string
+	aiNeverSendTables  []string
+	aiNeverSendColumns []string
+	aiPseudonymTables  []string
+	aiPseudonymColumns []string
+	aiSampleValueMode  string
+	aiAuditLogPath     string
+)
+
+// addAIPrivacyFlags 注册 AI 隐私策略和审计日志参数
+func addAIPrivacyFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&aiPrivacyFile, "ai-privacy", "", "AI 隐私策略文件（JSON），与 --ai-never-send-* 等参数合并")
+	cmd.Flags().StringSliceVar(&aiNeverSendTables, "ai-never-send-tables", nil, "不发送给 AI 的表（通配符或 re: 正则，逗号分隔）")
+	cmd.Flags().StringSliceVar(&aiNeverSendColumns, "ai-never-send-columns", nil, "不发送给 AI 的列（匹配 列 或 表.列，逗号分隔）")
+	cmd.Flags().StringSliceVar(&aiPseudonymTables, "ai-pseudonymize-tables", nil, "发送给 AI 时替换为代号的表名")
+	cmd.Flags().StringSliceVar(&aiPseudonymColumns, "ai-pseudonymize-columns", nil, "发送给 AI 时替换为代号的列名")
+	cmd.Flags().StringVar(&aiSampleValueMode, "ai-sample-values", "", "样例值的处理方式：mask（默认，保留首尾字符）/ hash / drop")
+	cmd.Flags().StringVar(&aiAuditLogPath, "ai-audit-log", "", "AI 请求审计日志（默认 <output>/ai_audit.jsonl，off 不记录）")
+}
+
+// newAIPrivacy 合并隐私策略文件和命令行参数，代号对照表默认保存在输出目录
+func newAIPrivacy() *redact.Policy {
+	policy := &redact.Policy{}
+	if aiPrivacyFile != "" {
+		var err error
+		if policy, err = redact.LoadPolicy(aiPrivacyFile); err != nil {
+			log.Fatalf("加载 AI 隐私策略失败: %v", err)
+		}
+	}
+	policy.NeverSendTables = append(policy.NeverSendTables, aiNeverSendTables...)
+	policy.NeverSendColumns = append(policy.NeverSendColumns, aiNeverSendColumns...)
+	policy.PseudonymizeTables = append(policy.PseudonymizeTables, aiPseudonymTables...)
+	policy.PseudonymizeColumns = append(policy.PseudonymizeColumns, aiPseudonymColumns...)
+	if aiSampleValueMode != "" {
+		policy.Values = aiSampleValueMode
+	}
+	if policy.MappingFile == "" {
+		policy.MappingFile = filepath.Join(outputDir, "ai_pseudonyms.json")
+	}
+	if err := policy.Compile(); err != nil {
+		log.Fatalf("AI 隐私策略无效: %v", err)
+	}
+	return policy
+}
+
+// openAIAudit 打开 AI 审计日志，off 时返回 nil（不记录）
+func openAIAudit() *ai.AuditLog {
+	path := aiAuditLogPath
+	if path == "off" {
+		return nil
+	}
+	if path == "" {
+		path = filepath.Join(outputDir, "ai_audit.jsonl")
+	}
+	audit, err := ai.OpenAuditLog(path)
+	if err != nil {
+		// 无法记录时不发送任何请求
+		log.Fatalf("打开 AI 审计日志失败: %v", err)
+	}
+	return audit
+}
+
+// closeAIPrivacy 保存代号对照表，关闭审计日志并输出摘要
+func closeAIPrivacy(policy *redact.Policy, audit *ai.AuditLog) {
+	if err := policy.SaveMapping(); err != nil {
+		fmt.Printf("⚠️  保存代号对照表失败: %v\n", err)
+	} else if len(policy.PseudonymizeTables)+len(policy.PseudonymizeColumns) > 0 {
+		fmt.Printf("✓ 代号对照表: %s（包含密钥，请勿外发）\n", policy.MappingFile)
+	}
+	if audit != nil {
+		fmt.Printf("🔒 AI 审计: 发出 %d 次请求，内容记录在 %s\n", audit.Count(), audit.Path())
+		audit.Close()
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"schema-analyzer/internal/graph"
+	"schema-analyzer/internal/review"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	reviewFile string
+	reviewNote string
+)
+
+// newReviewCmd 创建 review 命令：人工审核推断关系
+func newReviewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "review",
+		Short: "人工审核推断关系（确认/否决/补充）",
+		Long: `审核记录保存在输出目录的 review.json 中，后续扫描会自动应用：
+否决的关系不再输出，确认的关系标记为人工证据，人工补充的关系会被加入，
+扫描时只报告新出现的候选关系。`,
+	}
+	cmd.PersistentFlags().StringVar(&outputDir, "output", "./output", "输出目录（review.json 所在目录）")
+	cmd.PersistentFlags().StringVar(&reviewFile, "file", "", "审核记录文件（默认 <output>/review.json）")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "列出审核记录和待审核的候选关系",
+		Args:  cobra.NoArgs,
+		Run:   runReviewList,
+	}
+
+	acceptCmd := &cobra.Command{
+		Use:   "accept <edge-id>...",
+		Short: "确认推断关系",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runReviewDecide(args, func(s *review.Store, id string) error { return s.Accept(id, reviewNote) }, "已确认")
+		},
+	}
+	acceptCmd.Flags().StringVar(&reviewNote, "note", "", "备注")
+
+	rejectCmd := &cobra.Command{
+		Use:   "reject <edge-id>...",
+		Short: "否决推断关系",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runReviewDecide(args, func(s *review.Store, id string) error { return s.Reject(id, reviewNote) }, "已否决")
+		},
+	}
+	rejectCmd.Flags().StringVar(&reviewNote, "note", "", "备注")
+
+	addCmd := &cobra.Command{
+		Use:   "add <from-table.column> <to-table.column>",
+		Short: "人工补充关系",
+		Args:  cobra.ExactArgs(2),
+		Run:   runReviewAdd,
+	}
+	addCmd.Flags().StringVar(&reviewNote, "note", "", "备注")
+
+	removeCmd := &cobra.Command{
+		Use:   "remove <edge-id>...",
+		Short: "撤销审核结论",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runReviewRemove,
+	}
+
+	cmd.AddCommand(listCmd, acceptCmd, rejectCmd, addCmd, removeCmd)
+	return cmd
+}
+
+// reviewStorePath 审核记录文件路径
+func reviewStorePath() string {
+	if reviewFile != "" {
+		return reviewFile
+	}
+	return filepath.Join(outputDir, review.DefaultFileName)
+}
+
+func loadReviewStore() *review.Store {
+	store, err := review.Load(reviewStorePath())
+	if err != nil {
+		log.Fatalf("加载审核记录失败: %v", err)
+	}
+	return store
+}
+
+func saveReviewStore(store *review.Store) {
+	os.MkdirAll(filepath.Dir(store.Path()), 0755)
+	if err := store.Save(); err != nil {
+		log.Fatalf("保存审核记录失败: %v", err)
+	}
+}
+
+func runReviewDecide(ids []string, decide func(*review.Store, string) error, label string) {
+	store := loadReviewStore()
+	for _, id := range ids {
+		if err := decide(store, id); err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Printf("✓ %s: %s\n", label, id)
+	}
+	saveReviewStore(store)
+}
+
+func runReviewAdd(cmd *cobra.Command, args []string) {
+	store := loadReviewStore()
+	id, err := store.Add(args[0], args[1], reviewNote)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	saveReviewStore(store)
+	fmt.Printf("✓ 已添加: %s\n", id)
+}
+
+func runReviewRemove(cmd *cobra.Command, args []string) {
+	store := loadReviewStore()
+	for _, id := range args {
+		if store.Remove(id) {
+			fmt.Printf("✓ 已撤销: %s\n", id)
+		} else {
+			fmt.Printf("⚠️  没有审核记录: %s\n", id)
+		}
+	}
+	saveReviewStore(store)
+}
+
+func runReviewList(cmd *cobra.Command, args []string) {
+	store := loadReviewStore()
+
+	decisions := store.List()
+	fmt.Printf("📋 审核记录 (%d)\n", len(decisions))
+	for _, d := range decisions {
+		note := ""
+		if d.Note != "" {
+			note = "  # " + d.Note
+		}
+		fmt.Printf("  [%s] %s%s\n", d.Verdict, d.EdgeID, note)
+	}
+
+	// 从最近一次扫描结果中找出待审核的候选关系
+	data, err := os.ReadFile(filepath.Join(outputDir, "schema.json"))
+	if err != nil {
+		return
+	}
+	g, err := graph.FromJSON(data)
+	if err != nil {
+		log.Fatalf("解析 schema.json 失败: %v", err)
+	}
+
+	var pending []*graph.Edge
+	for _, edge := range g.Edges {
+		if edge.Type != graph.EdgeTypeInferredFK || store.Get(edge.ID) != nil {
+			continue
+		}
+		if _, ok := edge.Properties["from_column"]; !ok {
+			continue
+		}
+		pending = append(pending, edge)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Confidence > pending[j].Confidence
+	})
+
+	fmt.Printf("\n🔍 待审核的候选关系 (%d)\n", len(pending))
+	for _, edge := range pending {
+		fmt.Printf("  %.2f  %s\n", edge.Confidence, edge.ID)
+	}
+}
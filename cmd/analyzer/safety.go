@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"schema-analyzer/internal/adapter"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	safeMode        bool
+	readOnly        bool
+	queryTimeout    time.Duration
+	lockTimeout     time.Duration
+	readUncommitted bool
+	maxTableRows    int64
+	largeTables     string
+	maxQPS          float64
+)
+
+// addSafetyFlags 注册生产库低影响扫描参数
+func addSafetyFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&safeMode, "safe", false, "安全模式：只读会话、30s 查询超时、5s 锁超时、READ UNCOMMITTED、每秒最多 20 条查询（可用下列参数单独覆盖）")
+	cmd.Flags().BoolVar(&readOnly, "read-only", false, "只读会话（MySQL 只读事务，SQL Server ApplicationIntent=ReadOnly）")
+	cmd.Flags().DurationVar(&queryTimeout, "query-timeout", 0, "单条查询超时，如 30s（0 不限制）")
+	cmd.Flags().DurationVar(&lockTimeout, "lock-timeout", 0, "SQL Server 锁等待超时，如 5s（0 不设置）")
+	cmd.Flags().BoolVar(&readUncommitted, "read-uncommitted", false, "SQL Server 使用 READ UNCOMMITTED 并在取数查询中加 NOLOCK")
+	cmd.Flags().Int64Var(&maxTableRows, "max-table-rows", 0, "估算行数超过该值的表按 --large-tables 处理（0 不限制）")
+	cmd.Flags().StringVar(&largeTables, "large-tables", adapter.LargeTableSample, "大表处理方式：sample（只读开头若干行，跳过 MIN/MAX 等全表查询）/ skip（不读取数据）")
+	cmd.Flags().Float64Var(&maxQPS, "max-qps", 0, "全局查询速率上限，每秒查询数（0 不限制）")
+}
+
+// safetyOptions 根据命令行参数生成安全选项：--safe 提供默认值，显式指定的参数优先
+func safetyOptions(cmd *cobra.Command) adapter.SafetyOptions {
+	var opts adapter.SafetyOptions
+	if safeMode {
+		opts = adapter.SafeModeOptions()
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("read-only") {
+		opts.ReadOnly = readOnly
+	}
+	if flags.Changed("query-timeout") {
+		opts.QueryTimeout = queryTimeout
+	}
+	if flags.Changed("lock-timeout") {
+		opts.LockTimeout = lockTimeout
+	}
+	if flags.Changed("read-uncommitted") {
+		opts.ReadUncommitted = readUncommitted
+	}
+	if flags.Changed("max-qps") {
+		opts.MaxQPS = maxQPS
+	}
+	opts.MaxTableRows = maxTableRows
+
+	switch largeTables {
+	case adapter.LargeTableSample, adapter.LargeTableSkip:
+		opts.LargeTable = largeTables
+	default:
+		log.Fatalf("--large-tables 只能是 sample 或 skip: %s", largeTables)
+	}
+	return opts
+}
+
+// describeSafety 安全选项的简要说明，未启用任何限制时返回空
+func describeSafety(opts adapter.SafetyOptions) string {
+	var parts []string
+	if opts.ReadOnly {
+		parts = append(parts, "只读")
+	}
+	if opts.QueryTimeout > 0 {
+		parts = append(parts, fmt.Sprintf("查询超时 %s", opts.QueryTimeout))
+	}
+	if opts.LockTimeout > 0 {
+		parts = append(parts, fmt.Sprintf("锁超时 %s", opts.LockTimeout))
+	}
+	if opts.ReadUncommitted {
+		parts = append(parts, "READ UNCOMMITTED")
+	}
+	if opts.MaxTableRows > 0 {
+		parts = append(parts, fmt.Sprintf("大表（> %d 行）%s", opts.MaxTableRows, map[string]string{
+			adapter.LargeTableSample: "只读开头的行",
+			adapter.LargeTableSkip:   "跳过",
+		}[opts.LargeTable]))
+	}
+	if opts.MaxQPS > 0 {
+		parts = append(parts, fmt.Sprintf("每秒最多 %g 条查询", opts.MaxQPS))
+	}
+	return strings.Join(parts, "，")
+}
+
+// writeSafetyReport 输出安全模式跳过的操作（safety.md），没有跳过任何操作时不生成
+func writeSafetyReport(dbAdapter adapter.DBAdapter, opts adapter.SafetyOptions) {
+	reporter, ok := dbAdapter.(adapter.SafetyReporter)
+	if !ok {
+		return
+	}
+	records := reporter.SkipRecords()
+	if len(records) == 0 {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# 安全模式报告\n\n")
+	sb.WriteString(fmt.Sprintf("安全选项：%s\n\n", describeSafety(opts)))
+	sb.WriteString("以下操作未执行或被中断，相关列的统计、画像或码表信息可能缺失。\n\n")
+	sb.WriteString("| 表 | 列 | 操作 | 原因 |\n")
+	sb.WriteString("|----|----|------|------|\n")
+	tables := make(map[string]bool)
+	for _, r := range records {
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+			r.Table, r.Column, r.Operation, strings.ReplaceAll(r.Reason, "|", "\\|")))
+		if r.Table != "" {
+			tables[r.Table] = true
+		}
+	}
+
+	path := filepath.Join(outputDir, "safety.md")
+	os.WriteFile(path, []byte(sb.String()), 0644)
+	fmt.Printf("⚠️  安全模式跳过 %d 项操作（涉及 %d 个表），详见 %s\n", len(records), len(tables), path)
+}
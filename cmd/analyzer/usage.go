@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"schema-analyzer/internal/ai"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	aiMaxTokens   int
+	aiMaxCost     float64
+	aiPriceInput  float64
+	aiPriceOutput float64
+)
+
+// addAIBudgetFlags 注册 AI 用量上限和价格参数
+func addAIBudgetFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&aiMaxTokens, "ai-max-tokens", 0, "AI 用量上限（估算的 token 总数），达到后停止 AI 增强（0 不限制）")
+	cmd.Flags().Float64Var(&aiMaxCost, "ai-max-cost", 0, "AI 费用上限（按模型价格的币种估算），达到后停止 AI 增强（0 不限制）")
+	cmd.Flags().Float64Var(&aiPriceInput, "ai-price-input", 0, "模型每千输入 token 的价格（默认使用内置参考价格）")
+	cmd.Flags().Float64Var(&aiPriceOutput, "ai-price-output", 0, "模型每千输出 token 的价格（默认使用内置参考价格）")
+}
+
+// newAIUsage 根据命令行参数创建 AI 用量统计
+func newAIUsage() *ai.Usage {
+	usage := ai.NewUsage(aiMaxTokens, aiMaxCost)
+	if aiPriceInput > 0 || aiPriceOutput > 0 {
+		pricing, _ := ai.LookupPricing(aiProvider, aiModel)
+		pricing.Input, pricing.Output = aiPriceInput, aiPriceOutput
+		usage.SetPricing(pricing)
+	}
+	return usage
+}
+
+// writeAIUsage 输出 AI 用量摘要，并写入 ai_usage.md / ai_usage.json
+func writeAIUsage(usage *ai.Usage) {
+	if usage == nil {
+		return
+	}
+	fmt.Printf("💰 AI 用量: %s\n", usage.Summary())
+
+	os.MkdirAll(outputDir, 0755)
+	data, err := usage.JSON()
+	if err != nil {
+		fmt.Printf("⚠️  生成 AI 用量报告失败: %v\n", err)
+		return
+	}
+	os.WriteFile(filepath.Join(outputDir, "ai_usage.json"), data, 0644)
+	os.WriteFile(filepath.Join(outputDir, "ai_usage.md"), []byte(usage.Markdown()), 0644)
+	fmt.Printf("✓ %s\n", filepath.Join(outputDir, "ai_usage.md"))
+}
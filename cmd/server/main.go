@@ -8,14 +8,20 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
 	"schema-analyzer/internal/adapter"
 	"schema-analyzer/internal/ai"
+	"schema-analyzer/internal/ai/cache"
+	"schema-analyzer/internal/ai/prompt"
+	"schema-analyzer/internal/ai/redact"
 	"schema-analyzer/internal/analyzer"
 	"schema-analyzer/internal/graph"
 	"schema-analyzer/internal/renderer"
+	"schema-analyzer/internal/review"
 
 	_ "github.com/denisenkom/go-mssqldb"
 	_ "github.com/go-sql-driver/mysql"
@@ -36,10 +42,15 @@ type AnalysisRequest struct {
 	Username   string `json:"username"`    // 用户名
 	Password   string `json:"password"`    // 密码
 	Database   string `json:"database"`    // 数据库名
-	Schema     string `json:"schema"`      // Schema（MySQL需要）
+	Schema     string `json:"schema"`      // Schema，多个用逗号分隔（MySQL 默认为 database）
 	SampleSize int    `json:"sample_size"` // 采样大小
 	EnableAI   bool   `json:"enable_ai"`   // 是否启用AI
 	APIKey     string `json:"api_key"`     // AI API Key
+	AIProvider string `json:"ai_provider"` // AI 服务商（dashscope/openai），默认 dashscope
+	AIBaseURL  string `json:"ai_base_url"` // OpenAI 兼容接口地址
+	AIModel    string `json:"ai_model"`    // AI 模型，为空时使用服务商默认模型
+	AIContext  int    `json:"ai_context"`  // 本地模型的上下文长度，0 表示自动
+	AIDomain   string `json:"ai_domain"`   // AI prompt 的业务领域（u8/generic/ecommerce），默认 u8
 }
 
 // AnalysisTask 分析任务
@@ -65,6 +76,13 @@ type AnalysisResult struct {
 var (
 	tasks   = make(map[string]*AnalysisTask)
 	tasksMu sync.RWMutex
+
+	// aiCache 各分析任务共用的 AI 响应缓存，nil 表示不缓存
+	aiCache *cache.Store
+	// aiAudit 各分析任务共用的 AI 请求审计日志，nil 表示不记录
+	aiAudit *ai.AuditLog
+	// aiPrivacy 各分析任务共用的 AI 隐私策略，nil 时使用默认策略
+	aiPrivacy *redact.Policy
 )
 
 func main() {
@@ -77,7 +95,33 @@ func main() {
 	http.HandleFunc("/api/ws", handleWebSocket)
 	http.HandleFunc("/api/test-connection", handleTestConnection)
 	http.HandleFunc("/api/list-databases", handleListDatabases)
+	http.HandleFunc("/api/review", handleReview)
 	
+	// AI 响应缓存目录可用环境变量 AI_CACHE_DIR 指定，设为 off 时不缓存
+	if dir := os.Getenv("AI_CACHE_DIR"); dir != "off" {
+		var err error
+		if aiCache, err = cache.Open(dir, cache.DefaultTTL); err != nil {
+			log.Printf("打开 AI 缓存失败: %v，不使用缓存", err)
+		}
+	}
+	// 环境变量 AI_AUDIT_LOG 指定审计日志文件，记录实际发给 AI 服务商的请求
+	if path := os.Getenv("AI_AUDIT_LOG"); path != "" {
+		var err error
+		if aiAudit, err = ai.OpenAuditLog(path); err != nil {
+			log.Fatalf("打开 AI 审计日志失败: %v", err)
+		}
+	}
+	// 环境变量 AI_PRIVACY 指定隐私策略文件（与命令行版 --ai-privacy 格式相同）
+	if path := os.Getenv("AI_PRIVACY"); path != "" {
+		var err error
+		if aiPrivacy, err = redact.LoadPolicy(path); err != nil {
+			log.Fatalf("加载 AI 隐私策略失败: %v", err)
+		}
+		if err := aiPrivacy.Compile(); err != nil {
+			log.Fatalf("AI 隐私策略无效: %v", err)
+		}
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -203,16 +247,25 @@ func runAnalysis(task *AnalysisTask) {
 	var err error
 	
 	req := task.Request
+	schemas := splitSchemas(req.Schema)
 	
 	switch req.DBType {
 	case "sqlserver":
 		connStr = fmt.Sprintf("server=%s;port=%s;user id=%s;password=%s;database=%s",
 			req.Host, req.Port, req.Username, req.Password, req.Database)
-		dbAdapter, err = adapter.NewSQLServerAdapter(connStr)
+		var ss *adapter.SQLServerAdapter
+		ss, err = adapter.NewSQLServerAdapter(connStr)
+		if err == nil {
+			ss.SetSchemas(schemas)
+			dbAdapter = ss
+		}
 	case "mysql":
 		connStr = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?timeout=30s&readTimeout=30s&writeTimeout=30s",
 			req.Username, req.Password, req.Host, req.Port, req.Database)
-		dbAdapter, err = adapter.NewMySQLAdapter(connStr, req.Schema)
+		if len(schemas) == 0 && req.Database != "" {
+			schemas = []string{req.Database}
+		}
+		dbAdapter, err = adapter.NewMySQLAdapter(connStr, schemas...)
 	default:
 		updateTask("failed", 0, "不支持的数据库类型")
 		return
@@ -237,6 +290,7 @@ func runAnalysis(task *AnalysisTask) {
 	
 	// 构建 Graph
 	g := graph.NewSchemaGraph()
+	evidence := analyzer.NewEvidenceCollector()
 	
 	sampleSize := req.SampleSize
 	if sampleSize == 0 {
@@ -245,11 +299,11 @@ func runAnalysis(task *AnalysisTask) {
 	
 	for i, table := range meta.Tables {
 		progress := 40 + int(float64(i)/float64(len(meta.Tables))*20)
-		updateTask("running", progress, fmt.Sprintf("分析表 %s (%d/%d)...", table.Name, i+1, len(meta.Tables)))
+		updateTask("running", progress, fmt.Sprintf("分析表 %s (%d/%d)...", table.QualifiedName(), i+1, len(meta.Tables)))
 		
 		// 表节点
 		tableNode := &graph.Node{
-			ID:   table.Name,
+			ID:   table.QualifiedName(),
 			Type: graph.NodeTypeTable,
 			Name: table.Name,
 			Properties: map[string]interface{}{
@@ -260,7 +314,8 @@ func runAnalysis(task *AnalysisTask) {
 		
 		// 列节点
 		for _, col := range table.Columns {
-			stats, _ := dbAdapter.SampleColumnStats(table.Name, col.Name, sampleSize)
+			stats, _ := dbAdapter.SampleColumnStats(table.QualifiedName(), col.Name, sampleSize)
+			evidence.AddStats(table.QualifiedName(), col.Name, stats)
 			
 			nullRatio := 0.0
 			distinctRate := 0.0
@@ -270,11 +325,11 @@ func runAnalysis(task *AnalysisTask) {
 			}
 			
 			colNode := &graph.Node{
-				ID:   fmt.Sprintf("%s.%s", table.Name, col.Name),
+				ID:   fmt.Sprintf("%s.%s", table.QualifiedName(), col.Name),
 				Type: graph.NodeTypeColumn,
 				Name: col.Name,
 				Properties: map[string]interface{}{
-					"table":          table.Name,
+					"table":          table.QualifiedName(),
 					"data_type":      col.DataType,
 					"length":         col.Length,
 					"nullable":       col.Nullable,
@@ -283,19 +338,75 @@ func runAnalysis(task *AnalysisTask) {
 					"distinct_rate":  distinctRate,
 				},
 			}
+			if stats != nil && stats.SampleMethod != "" {
+				colNode.Properties["sample_method"] = stats.SampleMethod
+				if stats.EstimatedRows > 0 {
+					colNode.Properties["estimated_distinct"] = stats.EstimatedDistinct
+				}
+			}
 			g.AddNode(colNode)
 		}
 	}
 	
 	// AI 增强
-	if req.EnableAI && req.APIKey != "" {
-		updateTask("running", 60, "AI 增强分析中...")
+	var aiClient ai.Client
+	aiUsage := ai.NewUsage(0, 0)
+	if req.EnableAI {
+		var prompts *prompt.Set
+		if domain, ok := prompt.GetDomain(req.AIDomain); ok {
+			prompts, err = prompt.Load("", domain)
+			if err != nil {
+				log.Printf("加载 prompt 模板失败: %v", err)
+			}
+		}
+		aiClient, err = ai.NewClient(ai.Config{
+			Provider: req.AIProvider,
+			BaseURL:  req.AIBaseURL,
+			Model:    req.AIModel,
+			APIKey:   req.APIKey,
+
+			ContextLength: req.AIContext,
+			Prompts:       prompts,
+			Cache:         aiCache,
+			Usage:         aiUsage,
+			Privacy:       aiPrivacy,
+			Audit:         aiAudit,
+		})
+		if err != nil {
+			log.Printf("跳过 AI 增强: %v", err)
+		}
+	}
+	
+	updateTask("running", 60, "推断表间关系...")
+	
+	// 推断关系
+	inferer := analyzer.NewRelationshipInferer(dbAdapter)
+	edges, _ := inferer.InferRelationships(meta)
+	for _, edge := range edges {
+		g.AddEdge(edge)
+	}
+	
+	aiFailures := 0
+	if aiClient != nil {
+		updateTask("running", 70, "AI 增强分析中...")
 		
-		aiClient := ai.NewAlibabaClient(req.APIKey)
 		hybridAnalyzer := analyzer.NewHybridAnalyzer(dbAdapter, aiClient)
+		hybridAnalyzer.SetConcurrency(ai.DefaultConcurrency(req.AIProvider))
+		evidence.AddEdges(edges)
+		hybridAnalyzer.SetEvidence(evidence)
 		
 		enhanced, err := hybridAnalyzer.AnalyzeWithAI(meta)
 		if err == nil {
+			aiFailures = len(enhanced.Failures)
+			log.Printf("AI 用量: %s", aiUsage.Summary())
+			if aiPrivacy != nil {
+				if err := aiPrivacy.SaveMapping(); err != nil {
+					log.Printf("保存代号对照表失败: %v", err)
+				}
+			}
+			for _, f := range enhanced.Failures {
+				log.Printf("AI 调用失败 [%s] %s: %s", f.Stage, f.Target, f.Error)
+			}
 			// 更新节点
 			for tableName, table := range enhanced.Tables {
 				for colName, col := range table.Columns {
@@ -314,20 +425,27 @@ func runAnalysis(task *AnalysisTask) {
 		}
 	}
 	
-	updateTask("running", 70, "推断表间关系...")
-	
-	// 推断关系
-	inferer := analyzer.NewRelationshipInferer(dbAdapter)
-	edges, _ := inferer.InferRelationships(meta)
-	for _, edge := range edges {
-		g.AddEdge(edge)
+	// 应用人工审核结论
+	newCandidates := 0
+	if store, err := review.Load(reviewStorePath(req.DBType, req.Host, req.Database)); err == nil {
+		reviewResult := store.Apply(g)
+		newCandidates = len(reviewResult.NewCandidates)
+		os.MkdirAll(filepath.Dir(store.Path()), 0755)
+		store.Save()
 	}
+	analyzer.NewEdgePruner().PruneGraph(g, meta)
 	
 	updateTask("running", 85, "检测枚举表...")
 	
 	// 检测枚举表
 	enumDetector := analyzer.NewEnumDetector(dbAdapter)
+	enumDetector.SetSampleSize(sampleSize)
+	enumDetector.SetEvidence(evidence)
 	enumTables, _ := enumDetector.DetectEnumTables(meta)
+	enumColumns, _ := enumDetector.DetectEnumColumns(meta)
+	enumRefs := enumDetector.LinkEnumReferences(meta, enumTables)
+	analyzer.AnnotateEnums(g, enumTables, enumColumns)
+	analyzer.AddEnumReferences(g, enumRefs)
 	
 	updateTask("running", 95, "生成输出...")
 	
@@ -346,9 +464,13 @@ func runAnalysis(task *AnalysisTask) {
 		DictMD:     dictMD,
 		ErMermaid:  erMermaid,
 		Stats: map[string]int{
-			"tables":      len(meta.Tables),
-			"relations":   len(edges),
-			"enum_tables": len(enumTables),
+			"tables":         len(meta.Tables),
+			"relations":      len(edges),
+			"enum_tables":    len(enumTables),
+			"enum_columns":   len(enumColumns),
+			"enum_refs":      len(enumRefs),
+			"new_candidates": newCandidates,
+			"ai_failures":    aiFailures,
 		},
 	}
 	
@@ -504,3 +626,90 @@ func handleListDatabases(w http.ResponseWriter, r *http.Request) {
 		"databases": databases,
 	})
 }
+
+// reviewStorePath 审核记录文件路径（按数据库区分，目录可用 REVIEW_DIR 配置）
+func reviewStorePath(dbType, host, database string) string {
+	dir := os.Getenv("REVIEW_DIR")
+	if dir == "" {
+		dir = "./reviews"
+	}
+	name := regexp.MustCompile(`[^A-Za-z0-9_.-]+`).ReplaceAllString(
+		fmt.Sprintf("%s_%s_%s", dbType, host, database), "_")
+	return filepath.Join(dir, name+".json")
+}
+
+// handleReview 人工审核推断关系（list/accept/reject/add/remove）
+func handleReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	
+	var req struct {
+		DBType   string `json:"db_type"`
+		Host     string `json:"host"`
+		Database string `json:"database"`
+		Action   string `json:"action"`
+		EdgeID   string `json:"edge_id"`
+		From     string `json:"from"`
+		To       string `json:"to"`
+		Note     string `json:"note"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	store, err := review.Load(reviewStorePath(req.DBType, req.Host, req.Database))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	switch req.Action {
+	case "list":
+	case "accept":
+		err = store.Accept(req.EdgeID, req.Note)
+	case "reject":
+		err = store.Reject(req.EdgeID, req.Note)
+	case "add":
+		req.EdgeID, err = store.Add(req.From, req.To, req.Note)
+	case "remove":
+		store.Remove(req.EdgeID)
+	default:
+		http.Error(w, "Unsupported action", http.StatusBadRequest)
+		return
+	}
+	
+	if err == nil && req.Action != "list" {
+		os.MkdirAll(filepath.Dir(store.Path()), 0755)
+		err = store.Save()
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"edge_id":   req.EdgeID,
+		"decisions": store.List(),
+	})
+}
+
+// splitSchemas 拆分逗号分隔的 schema 列表
+func splitSchemas(value string) []string {
+	var schemas []string
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			schemas = append(schemas, s)
+		}
+	}
+	return schemas
+}
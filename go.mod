@@ -5,6 +5,7 @@ go 1.17
 require (
 	github.com/denisenkom/go-mssqldb v0.12.3
 	github.com/go-sql-driver/mysql v1.7.1
+	github.com/gorilla/websocket v1.5.1
 	github.com/spf13/cobra v1.8.0
 	github.com/texttheater/golang-levenshtein v1.0.1
 )
@@ -12,7 +13,6 @@ require (
 require (
 	github.com/golang-sql/civil v0.0.0-20190719163853-cb61b32ac6fe // indirect
 	github.com/golang-sql/sqlexp v0.1.0 // indirect
-	github.com/gorilla/websocket v1.5.1 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/crypto v0.16.0 // indirect
@@ -1,6 +1,9 @@
 package adapter
 
-import "database/sql"
+import (
+	"database/sql"
+	"time"
+)
 
 // DBAdapter 数据库适配器接口
 type DBAdapter interface {
@@ -19,6 +22,15 @@ type DBAdapter interface {
 	// GetForeignKeys 获取外键约束
 	GetForeignKeys() ([]ForeignKey, error)
 	
+	// SampleValues 采样读取列的原始取值（NULL 以 Valid=false 表示）
+	SampleValues(table, column string, sampleSize int) ([]sql.NullString, error)
+	
+	// GetColumnRange 获取列的最小值和最大值
+	GetColumnRange(table, column string) (min, max sql.NullString, err error)
+	
+	// GetValuePairs 读取码表的 键→含义 对（按键排序，最多 limit 行）
+	GetValuePairs(table, keyColumn, valueColumn string, limit int) ([]ValuePair, error)
+	
 	// Close 关闭连接
 	Close() error
 }
@@ -29,11 +41,35 @@ type SchemaMetadata struct {
 	Indexes []Index
 }
 
+// Schemas 元数据中出现的 schema（按首次出现的顺序）
+func (m *SchemaMetadata) Schemas() []string {
+	var schemas []string
+	seen := make(map[string]bool)
+	for _, t := range m.Tables {
+		if !seen[t.Schema] {
+			seen[t.Schema] = true
+			schemas = append(schemas, t.Schema)
+		}
+	}
+	return schemas
+}
+
 // Table 表信息
 type Table struct {
 	Schema  string
 	Name    string
 	Columns []Column
+	
+	// RowCount 目录中记录的估算行数（0 表示未知）
+	RowCount int64
+	// LastModified 最后一次数据修改时间（零值表示数据库不提供）
+	LastModified time.Time
+}
+
+// QualifiedName 带 schema 前缀的表名。它是表的唯一标识：
+// 图节点 ID、关系两端和适配器方法的 table 参数都使用它，避免不同 schema 的同名表冲突
+func (t Table) QualifiedName() string {
+	return QualifyName(t.Schema, t.Name)
 }
 
 // Column 列信息
@@ -48,13 +84,13 @@ type Column struct {
 
 // Index 索引信息
 type Index struct {
-	Table   string
+	Table   string // 带 schema 前缀的表名
 	Name    string
 	Columns []string
 	Unique  bool
 }
 
-// ForeignKey 外键
+// ForeignKey 外键（表名带 schema 前缀）
 type ForeignKey struct {
 	FromTable  string
 	FromColumn string
@@ -62,7 +98,16 @@ type ForeignKey struct {
 	ToColumn   string
 }
 
+// 采样方式
+const (
+	SampleFull        = "full"        // 行数不超过采样大小，统计全表
+	SampleBernoulli   = "bernoulli"   // 按概率 p 逐行抽样（RAND() < p）
+	SampleTableSample = "tablesample" // SQL Server TABLESAMPLE
+	SampleHead        = "head"        // 安全模式下的大表只读开头的行
+)
+
 // ColumnStats 列统计
+// TotalRows/NullCount/DistinctCount/TopValues 均基于采样，Estimated* 为外推到全表的估计值
 type ColumnStats struct {
 	TotalRows    int64
 	NullCount    int64
@@ -70,6 +115,48 @@ type ColumnStats struct {
 	TopValues    []ValueCount
 	MinValue     sql.NullString
 	MaxValue     sql.NullString
+	
+	SampleMethod      string  // 采样方式
+	SampleRatio       float64 // 抽样概率（全表统计时为 1）
+	EstimatedRows     int64   // 全表行数估计
+	EstimatedNulls    int64   // 全表 NULL 数估计
+	EstimatedDistinct int64   // 全表不同值数估计
+}
+
+// Extrapolate 根据采样结果外推全表统计。
+// 不同值数使用 Duj1 估计：D = n·d / (n - f1 + f1·n/N)，f1 为采样中只出现一次的取值数
+func (s *ColumnStats) Extrapolate(tableRows, singletons int64) {
+	n := s.TotalRows
+	if tableRows < n {
+		tableRows = n
+	}
+	s.EstimatedRows = tableRows
+	if n == 0 || tableRows == n {
+		s.EstimatedNulls = s.NullCount
+		s.EstimatedDistinct = s.DistinctCount
+		return
+	}
+
+	scale := float64(tableRows) / float64(n)
+	s.EstimatedNulls = int64(float64(s.NullCount)*scale + 0.5)
+
+	nonNull := float64(n - s.NullCount)
+	if nonNull <= 0 {
+		return
+	}
+	denominator := nonNull - float64(singletons) + float64(singletons)*nonNull/float64(tableRows-s.EstimatedNulls)
+	if denominator <= 0 {
+		s.EstimatedDistinct = s.DistinctCount
+		return
+	}
+	estimate := int64(nonNull*float64(s.DistinctCount)/denominator + 0.5)
+	if estimate > tableRows-s.EstimatedNulls {
+		estimate = tableRows - s.EstimatedNulls
+	}
+	if estimate < s.DistinctCount {
+		estimate = s.DistinctCount
+	}
+	s.EstimatedDistinct = estimate
 }
 
 // ValueCount 值计数
@@ -77,3 +164,60 @@ type ValueCount struct {
 	Value string
 	Count int64
 }
+
+// ValuePair 码表的键和含义
+type ValuePair struct {
+	Key   string
+	Label string
+}
+
+// scanValues 执行查询并读取单列结果
+func scanValues(db *safeDB, query string) ([]sql.NullString, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []sql.NullString
+	for rows.Next() {
+		var v sql.NullString
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// scanValuePairs 执行查询并读取两列结果，NULL 含义按空字符串处理
+func scanValuePairs(db *safeDB, query string) ([]ValuePair, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pairs []ValuePair
+	for rows.Next() {
+		var key, label sql.NullString
+		if err := rows.Scan(&key, &label); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, ValuePair{Key: key.String, Label: label.String})
+	}
+	return pairs, rows.Err()
+}
+
+// parseCatalogTime 解析目录视图中的时间（驱动可能返回文本或 RFC3339 格式）
+func parseCatalogTime(value sql.NullString) time.Time {
+	if !value.Valid {
+		return time.Time{}
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", time.RFC3339Nano} {
+		if t, err := time.Parse(layout, value.String); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,61 @@
+package adapter
+
+import "testing"
+
+func TestExtrapolate(t *testing.T) {
+	// 全表统计：估计值等于采样值
+	full := &ColumnStats{TotalRows: 500, NullCount: 50, DistinctCount: 10}
+	full.Extrapolate(500, 0)
+	if full.EstimatedRows != 500 || full.EstimatedNulls != 50 || full.EstimatedDistinct != 10 {
+		t.Errorf("unexpected full-table estimates %+v", full)
+	}
+
+	// 低基数列：没有只出现一次的取值，不同值数不随表大小放大
+	status := &ColumnStats{TotalRows: 1000, NullCount: 100, DistinctCount: 3}
+	status.Extrapolate(100000, 0)
+	if status.EstimatedNulls != 10000 || status.EstimatedDistinct != 3 {
+		t.Errorf("unexpected low-cardinality estimates %+v", status)
+	}
+
+	// 唯一列：样本中全部取值只出现一次，外推到全表行数
+	unique := &ColumnStats{TotalRows: 1000, DistinctCount: 1000}
+	unique.Extrapolate(100000, 1000)
+	if unique.EstimatedDistinct != 100000 {
+		t.Errorf("expected unique column to extrapolate to 100000 distinct values, got %d", unique.EstimatedDistinct)
+	}
+}
+
+func TestDialectQuoting(t *testing.T) {
+	if got := MySQLDialect.Quote("we`ird"); got != "`we``ird`" {
+		t.Errorf("unexpected MySQL quoting %s", got)
+	}
+	if got := SQLServerDialect.Quote("a]b"); got != "[a]]b]" {
+		t.Errorf("unexpected SQL Server quoting %s", got)
+	}
+	if got := SQLServerDialect.Table("Customer", "dbo"); got != "[dbo].[Customer]" {
+		t.Errorf("expected default schema, got %s", got)
+	}
+	if got := MySQLDialect.Table("erp.SO_SOMain", ""); got != "`erp`.`SO_SOMain`" {
+		t.Errorf("unexpected qualified table %s", got)
+	}
+	if got := MySQLDialect.Table("SO_SOMain", ""); got != "`SO_SOMain`" {
+		t.Errorf("unexpected unqualified table %s", got)
+	}
+
+	table := Table{Schema: "sales", Name: "Order.Detail"}
+	if schema, name := SplitQualifiedName(table.QualifiedName()); schema != "sales" || name != "Order.Detail" {
+		t.Errorf("round trip failed: %s / %s", schema, name)
+	}
+
+	// MySQL 只按扫描的 schema 拆分，不带前缀的表名可以含点
+	my := &MySQLAdapter{schema: "erp", schemas: []string{"erp", "crm"}}
+	if got := my.table("a.b"); got != "`erp`.`a.b`" {
+		t.Errorf("expected a.b to be a table in the default schema, got %s", got)
+	}
+	if got := my.table("crm.Order.Detail"); got != "`crm`.`Order.Detail`" {
+		t.Errorf("unexpected qualified table %s", got)
+	}
+	if schema, name := my.splitTable("erp.SO_SOMain"); schema != "erp" || name != "SO_SOMain" {
+		t.Errorf("unexpected split %s / %s", schema, name)
+	}
+}
@@ -0,0 +1,80 @@
+package adapter
+
+import "strings"
+
+// Dialect SQL 方言的标识符引用规则，所有动态拼接的表名/列名都必须经过它
+type Dialect struct {
+	open  string
+	close string
+}
+
+var (
+	// MySQLDialect 反引号引用：`name`
+	MySQLDialect = Dialect{open: "`", close: "`"}
+	// SQLServerDialect 方括号引用：[name]
+	SQLServerDialect = Dialect{open: "[", close: "]"}
+)
+
+// Quote 引用标识符，标识符中的结束引号写两次转义（MySQL 的反引号、SQL Server 的 ]）
+func (d Dialect) Quote(name string) string {
+	return d.open + strings.ReplaceAll(name, d.close, d.close+d.close) + d.close
+}
+
+// Table 引用表名：ref 可以是 schema.table 或不带 schema 的表名（使用 defaultSchema）
+func (d Dialect) Table(ref, defaultSchema string) string {
+	schema, table := SplitQualifiedName(ref)
+	if schema == "" {
+		schema = defaultSchema
+	}
+	return d.QuoteTable(schema, table)
+}
+
+// QuoteTable 引用已拆分的 schema 和表名，schema 为空时只引用表名
+func (d Dialect) QuoteTable(schema, table string) string {
+	if schema == "" {
+		return d.Quote(table)
+	}
+	return d.Quote(schema) + "." + d.Quote(table)
+}
+
+// QualifyName 拼接带 schema 前缀的表名，schema 为空时返回表名本身
+func QualifyName(schema, table string) string {
+	if schema == "" {
+		return table
+	}
+	return schema + "." + table
+}
+
+// SplitQualifiedName 拆分 schema.table，没有 schema 前缀时 schema 为空。
+// 按第一个点拆分，只适用于一定带 schema 前缀的名称；表名本身可能含点时用 SplitSchema
+func SplitQualifiedName(ref string) (schema, table string) {
+	if i := strings.Index(ref, "."); i > 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return "", ref
+}
+
+// SplitSchema 只在前缀是 schemas 之一时拆分 schema.table（多个匹配时取最长的），
+// 否则整个 ref 都是表名，schema 为空。例如 schemas 为 [erp] 时，a.b 是名为 a.b 的表
+func SplitSchema(ref string, schemas []string) (schema, table string) {
+	for _, s := range schemas {
+		if len(s) > len(schema) && strings.HasPrefix(ref, s+".") && len(ref) > len(s)+1 {
+			schema = s
+		}
+	}
+	if schema == "" {
+		return "", ref
+	}
+	return schema, ref[len(schema)+1:]
+}
+
+// inList 生成 IN 子句的占位符和参数，placeholder 返回第 i 个参数的占位符（MySQL 为 ?，SQL Server 为 @pN）
+func inList(values []string, placeholder func(i int) string) (string, []interface{}) {
+	marks := make([]string, len(values))
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		marks[i] = placeholder(i)
+		args[i] = v
+	}
+	return strings.Join(marks, ", "), args
+}
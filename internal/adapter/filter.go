@@ -0,0 +1,199 @@
+package adapter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// TableFilter 表过滤规则，在 IntrospectSchema 中应用，未选中的表不会读取列信息
+//
+// 模式默认为 glob（不区分大小写，如 Inventory*、dbo.ST_*），以 re: 开头的为正则表达式；
+// 模式同时匹配表名和带 schema 前缀的表名
+type TableFilter struct {
+	include []tablePattern
+	exclude []tablePattern
+
+	// Neighbors 邻域模式：额外扫描被选中表通过声明外键直接引用的表
+	Neighbors bool
+
+	selected map[string]bool // 实际扫描的表（限定名），Select 之后才有值
+}
+
+type tablePattern struct {
+	raw   string
+	glob  string
+	regex *regexp.Regexp
+}
+
+// NewTableFilter 创建表过滤器，include 为空时选中全部表
+func NewTableFilter(include, exclude []string) (*TableFilter, error) {
+	f := &TableFilter{}
+	var err error
+	if f.include, err = compilePatterns(include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = compilePatterns(exclude); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func compilePatterns(patterns []string) ([]tablePattern, error) {
+	var result []tablePattern
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		tp := tablePattern{raw: p}
+		if strings.HasPrefix(p, "re:") {
+			re, err := regexp.Compile(strings.TrimPrefix(p, "re:"))
+			if err != nil {
+				return nil, fmt.Errorf("无效的正则表达式 %q: %v", p, err)
+			}
+			tp.regex = re
+		} else {
+			tp.glob = strings.ToLower(p)
+			if _, err := path.Match(tp.glob, ""); err != nil {
+				return nil, fmt.Errorf("无效的通配符模式 %q: %v", p, err)
+			}
+		}
+		result = append(result, tp)
+	}
+	return result, nil
+}
+
+// LoadTableList 读取表清单文件：每行一个表名或模式，忽略空行和 # 开头的注释
+func LoadTableList(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+func (p tablePattern) match(t Table) bool {
+	for _, name := range []string{t.Name, t.QualifiedName()} {
+		if p.regex != nil {
+			if p.regex.MatchString(name) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(p.glob, strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Match 表是否被 include/exclude 规则选中（不考虑邻域）
+func (f *TableFilter) Match(t Table) bool {
+	for _, p := range f.exclude {
+		if p.match(t) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, p := range f.include {
+		if p.match(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select 从全部表中选出要扫描的表。邻域模式下通过 foreignKeys 取得声明外键，
+// 加入被选中表直接引用的表（即使它们被 exclude 排除）。f 为 nil 时返回全部表
+func (f *TableFilter) Select(tables []Table, foreignKeys func() ([]ForeignKey, error)) ([]Table, error) {
+	if f == nil {
+		return tables, nil
+	}
+
+	selected := make(map[string]bool)
+	for _, t := range tables {
+		if f.Match(t) {
+			selected[t.QualifiedName()] = true
+		}
+	}
+
+	if f.Neighbors {
+		fks, err := foreignKeys()
+		if err != nil {
+			return nil, err
+		}
+		neighbors := make(map[string]bool)
+		for _, fk := range fks {
+			if selected[fk.FromTable] {
+				neighbors[fk.ToTable] = true
+			}
+		}
+		for name := range neighbors {
+			selected[name] = true
+		}
+	}
+
+	var result []Table
+	for _, t := range tables {
+		if selected[t.QualifiedName()] {
+			result = append(result, t)
+		}
+	}
+	f.selected = selected
+	return result, nil
+}
+
+// Selected Select 是否已经执行过
+func (f *TableFilter) Selected() bool {
+	return f.selected != nil
+}
+
+// Contains 表（限定名）是否在扫描范围内。f 为 nil 时总是返回 true
+func (f *TableFilter) Contains(table string) bool {
+	return f == nil || f.selected[table]
+}
+
+// Indexes 只保留扫描范围内的表的索引
+func (f *TableFilter) Indexes(indexes []Index) []Index {
+	if f == nil {
+		return indexes
+	}
+	var result []Index
+	for _, idx := range indexes {
+		if f.Contains(idx.Table) {
+			result = append(result, idx)
+		}
+	}
+	return result
+}
+
+// ForeignKeys 只保留两端都在扫描范围内的外键
+func (f *TableFilter) ForeignKeys(fks []ForeignKey) []ForeignKey {
+	if f == nil {
+		return fks
+	}
+	var result []ForeignKey
+	for _, fk := range fks {
+		if f.Contains(fk.FromTable) && f.Contains(fk.ToTable) {
+			result = append(result, fk)
+		}
+	}
+	return result
+}
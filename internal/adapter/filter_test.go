@@ -0,0 +1,47 @@
+package adapter
+
+import "testing"
+
+func TestTableFilterSelect(t *testing.T) {
+	tables := []Table{
+		{Schema: "dbo", Name: "Inventory"},
+		{Schema: "dbo", Name: "InventoryClass"},
+		{Schema: "dbo", Name: "Inventory_bak"},
+		{Schema: "dbo", Name: "ComputationUnit"},
+		{Schema: "dbo", Name: "Warehouse"},
+		{Schema: "dbo", Name: "SO_SOMain"},
+	}
+	fks := []ForeignKey{
+		{FromTable: "dbo.Inventory", FromColumn: "cInvCCode", ToTable: "dbo.InventoryClass", ToColumn: "cInvCCode"},
+		{FromTable: "dbo.Inventory", FromColumn: "cComUnitCode", ToTable: "dbo.ComputationUnit", ToColumn: "cComunitCode"},
+		{FromTable: "dbo.SO_SOMain", FromColumn: "cWhCode", ToTable: "dbo.Warehouse", ToColumn: "cWhCode"},
+	}
+
+	filter, err := NewTableFilter([]string{"inventory*"}, []string{"re:_bak$"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	selected, err := filter.Select(tables, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 2 || selected[0].Name != "Inventory" || selected[1].Name != "InventoryClass" {
+		t.Errorf("unexpected selection %+v", selected)
+	}
+
+	filter.Neighbors = true
+	selected, err = filter.Select(tables, func() ([]ForeignKey, error) { return fks, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 3 || !filter.Contains("dbo.ComputationUnit") || filter.Contains("dbo.Warehouse") {
+		t.Errorf("expected referenced ComputationUnit to be pulled in, got %+v", selected)
+	}
+	if kept := filter.ForeignKeys(fks); len(kept) != 2 {
+		t.Errorf("expected 2 foreign keys inside the selection, got %d", len(kept))
+	}
+
+	if _, err := NewTableFilter([]string{"re:("}, nil); err == nil {
+		t.Error("expected invalid regex to be rejected")
+	}
+}
@@ -3,38 +3,139 @@ package adapter
 import (
 	"database/sql"
 	"fmt"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+	"strings"
 )
 
 // MySQLAdapter MySQL 适配器
 type MySQLAdapter struct {
-	db     *sql.DB
-	schema string
+	db      *safeDB
+	schema  string   // 默认 schema，不带前缀的表名属于它
+	schemas []string // 扫描的 schema（MySQL 中即数据库）
+	filter  *TableFilter
 }
 
-// NewMySQLAdapter 创建 MySQL 适配器
-func NewMySQLAdapter(connStr, schema string) (*MySQLAdapter, error) {
-	db, err := sql.Open("mysql", connStr)
+// NewMySQLAdapter 创建 MySQL 适配器，可同时扫描多个 schema，第一个为默认 schema
+func NewMySQLAdapter(connStr string, schemas ...string) (*MySQLAdapter, error) {
+	return NewMySQLAdapterWithOptions(connStr, SafetyOptions{}, schemas...)
+}
+
+// NewMySQLAdapterWithOptions 按安全选项创建 MySQL 适配器
+func NewMySQLAdapterWithOptions(connStr string, opts SafetyOptions, schemas ...string) (*MySQLAdapter, error) {
+	if len(schemas) == 0 {
+		return nil, fmt.Errorf("MySQL 需要至少指定一个 schema")
+	}
+	db, err := openMySQL(connStr, opts, "transaction_read_only")
+	if err != nil && isUnknownVariable(err) {
+		// MySQL 5.7.20 之前只有 tx_read_only，MariaDB 没有 max_execution_time
+		db, err = openMySQL(connStr, SafetyOptions{ReadOnly: opts.ReadOnly}, "tx_read_only")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &MySQLAdapter{db: newSafeDB(db, opts), schema: schemas[0], schemas: schemas}, nil
+}
+
+// openMySQL 打开连接，安全选项以会话变量的形式写入 DSN，由驱动在建立连接时设置
+func openMySQL(connStr string, opts SafetyOptions, readOnlyVar string) (*sql.DB, error) {
+	dsn, err := mysqlSafeDSN(connStr, opts, readOnlyVar)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("mysql", dsn)
 	if err != nil {
 		return nil, err
 	}
 	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// mysqlSafeDSN 在 DSN 中加入只读事务和语句超时（max_execution_time 只对 SELECT 生效，单位毫秒）
+func mysqlSafeDSN(connStr string, opts SafetyOptions, readOnlyVar string) (string, error) {
+	if !opts.ReadOnly && opts.QueryTimeout <= 0 {
+		return connStr, nil
+	}
+	cfg, err := mysql.ParseDSN(connStr)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Params == nil {
+		cfg.Params = make(map[string]string)
+	}
+	if opts.ReadOnly {
+		cfg.Params[readOnlyVar] = "1"
+	}
+	if opts.QueryTimeout > 0 {
+		cfg.Params["max_execution_time"] = fmt.Sprint(opts.QueryTimeout.Milliseconds())
+	}
+	return cfg.FormatDSN(), nil
+}
+
+// isUnknownVariable 服务器不认识会话变量（Error 1193）
+func isUnknownVariable(err error) bool {
+	if e, ok := err.(*mysql.MySQLError); ok {
+		return e.Number == 1193
+	}
+	return strings.Contains(err.Error(), "1193")
+}
+
+// SkipRecords 安全模式跳过的操作
+func (a *MySQLAdapter) SkipRecords() []SkipRecord {
+	return a.db.SkipRecords()
+}
+
+// guard 大表取数前的检查
+func (a *MySQLAdapter) guard(table, column, operation string, fullScan bool) error {
+	return a.db.guard(table, column, operation, fullScan, a.EstimateRowCount)
+}
+
+// table 引用表名，不带 schema 前缀时使用适配器的 schema
+func (a *MySQLAdapter) table(ref string) string {
+	return MySQLDialect.QuoteTable(a.splitTable(ref))
+}
+
+// splitTable 拆出 schema 和表名。只有前缀是扫描的 schema 时才拆分，表名本身可以含点
+func (a *MySQLAdapter) splitTable(ref string) (string, string) {
+	schema, table := SplitSchema(ref, a.schemas)
+	if schema == "" {
+		schema = a.schema
+	}
+	return schema, table
+}
+
+// schemaIn 生成 schema 列表的 IN 子句占位符和参数
+func (a *MySQLAdapter) schemaIn() (string, []interface{}) {
+	return inList(a.schemas, func(int) string { return "?" })
+}
+
+// SetTableFilter 设置表过滤规则
+func (a *MySQLAdapter) SetTableFilter(filter *TableFilter) {
+	a.filter = filter
+}
+
+// listTables 列出要扫描的表（已应用表过滤）
+func (a *MySQLAdapter) listTables() ([]Table, error) {
+	tables, err := a.getTables()
+	if err != nil {
 		return nil, err
 	}
-	return &MySQLAdapter{db: db, schema: schema}, nil
+	return a.filter.Select(tables, a.getForeignKeys)
 }
 
 // IntrospectSchema 获取元数据
 func (a *MySQLAdapter) IntrospectSchema() (*SchemaMetadata, error) {
 	meta := &SchemaMetadata{}
 	
-	tables, err := a.getTables()
+	tables, err := a.listTables()
 	if err != nil {
 		return nil, err
 	}
 	
 	for i := range tables {
-		columns, err := a.getColumns(tables[i].Name)
+		columns, err := a.getColumns(tables[i].Schema, tables[i].Name)
 		if err != nil {
 			return nil, err
 		}
@@ -47,19 +148,20 @@ func (a *MySQLAdapter) IntrospectSchema() (*SchemaMetadata, error) {
 	if err != nil {
 		return nil, err
 	}
-	meta.Indexes = indexes
+	meta.Indexes = a.filter.Indexes(indexes)
 	
 	return meta, nil
 }
 
 func (a *MySQLAdapter) getTables() ([]Table, error) {
-	query := `
-		SELECT TABLE_NAME
+	in, args := a.schemaIn()
+	query := fmt.Sprintf(`
+		SELECT TABLE_SCHEMA, TABLE_NAME, COALESCE(TABLE_ROWS, 0), UPDATE_TIME
 		FROM INFORMATION_SCHEMA.TABLES
-		WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'
-		ORDER BY TABLE_NAME
-	`
-	rows, err := a.db.Query(query, a.schema)
+		WHERE TABLE_SCHEMA IN (%s) AND TABLE_TYPE = 'BASE TABLE'
+		ORDER BY TABLE_SCHEMA, TABLE_NAME
+	`, in)
+	rows, err := a.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -68,16 +170,18 @@ func (a *MySQLAdapter) getTables() ([]Table, error) {
 	var tables []Table
 	for rows.Next() {
 		var t Table
-		t.Schema = a.schema
-		if err := rows.Scan(&t.Name); err != nil {
+		var updated sql.NullString
+		if err := rows.Scan(&t.Schema, &t.Name, &t.RowCount, &updated); err != nil {
 			return nil, err
 		}
+		// UPDATE_TIME 对 InnoDB 只在重启前有效，取不到时为零值
+		t.LastModified = parseCatalogTime(updated)
 		tables = append(tables, t)
 	}
 	return tables, nil
 }
 
-func (a *MySQLAdapter) getColumns(table string) ([]Column, error) {
+func (a *MySQLAdapter) getColumns(schema, table string) ([]Column, error) {
 	query := `
 		SELECT 
 			COLUMN_NAME,
@@ -89,7 +193,7 @@ func (a *MySQLAdapter) getColumns(table string) ([]Column, error) {
 		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
 		ORDER BY ORDINAL_POSITION
 	`
-	rows, err := a.db.Query(query, a.schema, table)
+	rows, err := a.db.Query(query, schema, table)
 	if err != nil {
 		return nil, err
 	}
@@ -107,17 +211,19 @@ func (a *MySQLAdapter) getColumns(table string) ([]Column, error) {
 }
 
 func (a *MySQLAdapter) getIndexes() ([]Index, error) {
-	query := `
+	in, args := a.schemaIn()
+	query := fmt.Sprintf(`
 		SELECT 
+			TABLE_SCHEMA,
 			TABLE_NAME,
 			INDEX_NAME,
 			COLUMN_NAME,
 			NON_UNIQUE = 0
 		FROM INFORMATION_SCHEMA.STATISTICS
-		WHERE TABLE_SCHEMA = ? AND INDEX_NAME != 'PRIMARY'
-		ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
-	`
-	rows, err := a.db.Query(query, a.schema)
+		WHERE TABLE_SCHEMA IN (%s) AND INDEX_NAME != 'PRIMARY'
+		ORDER BY TABLE_SCHEMA, TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
+	`, in)
+	rows, err := a.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -125,11 +231,12 @@ func (a *MySQLAdapter) getIndexes() ([]Index, error) {
 	
 	indexMap := make(map[string]*Index)
 	for rows.Next() {
-		var tableName, indexName, columnName string
+		var schemaName, tableName, indexName, columnName string
 		var isUnique bool
-		if err := rows.Scan(&tableName, &indexName, &columnName, &isUnique); err != nil {
+		if err := rows.Scan(&schemaName, &tableName, &indexName, &columnName, &isUnique); err != nil {
 			return nil, err
 		}
+		tableName = QualifyName(schemaName, tableName)
 		
 		key := tableName + "." + indexName
 		if idx, exists := indexMap[key]; exists {
@@ -159,7 +266,8 @@ func (a *MySQLAdapter) EstimateRowCount(table string) (int64, error) {
 		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
 	`
 	var count sql.NullInt64
-	err := a.db.QueryRow(query, a.schema, table).Scan(&count)
+	schema, name := a.splitTable(table)
+	err := a.db.QueryRow(query, schema, name).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -169,49 +277,123 @@ func (a *MySQLAdapter) EstimateRowCount(table string) (int64, error) {
 	return count.Int64, nil
 }
 
-// SampleColumnStats 采样列统计
-func (a *MySQLAdapter) SampleColumnStats(table, column string, sampleSize int) (*ColumnStats, error) {
-	stats := &ColumnStats{}
+// bernoulliLimitFactor 逐行抽样的 LIMIT 为采样大小的倍数
+const bernoulliLimitFactor = 4
+
+// sampleSource 生成采样子查询。行数不超过采样大小时读全表，
+// 否则按概率 p = n/N 逐行抽样（RAND() < p 只需顺序扫描，不像 ORDER BY RAND() 需要对全表排序）。
+// LIMIT 只是 TABLE_ROWS 严重低估时的保护，远大于 n，正常情况下不会截断，样本覆盖整张表；
+// 全表读取被截断时由 SampleColumnStats 修正行数后重新抽样。
+// 返回的 ratio 是计划的抽样概率，实际比例以返回的行数为准
+func (a *MySQLAdapter) sampleSource(table, column string, sampleSize int) (source, method string, ratio float64, tableRows int64) {
+	tableRows = a.db.tableRows(table, a.EstimateRowCount)
+	if tableRows <= int64(sampleSize) {
+		// LIMIT 防止 TABLE_ROWS 严重低估时扫描整张大表，多读一行用于发现截断
+		return fmt.Sprintf("SELECT %s AS v FROM %s LIMIT %d", MySQLDialect.Quote(column), a.table(table), sampleSize+1), SampleFull, 1, tableRows
+	}
 	
-	query := fmt.Sprintf(`
-		SELECT 
-			COUNT(*) as total,
-			SUM(CASE WHEN %s IS NULL THEN 1 ELSE 0 END) as nulls,
-			COUNT(DISTINCT %s) as distincts
-		FROM %s
-		ORDER BY RAND()
-		LIMIT %d
-	`, column, column, table, sampleSize)
+	if a.db.largeTable(table, a.EstimateRowCount) {
+		// 安全模式下的大表只读开头的行，避免 RAND() 顺序扫描整张表
+		ratio = float64(sampleSize) / float64(tableRows)
+		return fmt.Sprintf("SELECT %s AS v FROM %s LIMIT %d", MySQLDialect.Quote(column), a.table(table), sampleSize), SampleHead, ratio, tableRows
+	}
 	
-	err := a.db.QueryRow(query).Scan(&stats.TotalRows, &stats.NullCount, &stats.DistinctCount)
+	ratio = float64(sampleSize) / float64(tableRows)
+	source = fmt.Sprintf("SELECT %s AS v FROM %s WHERE RAND() < %.8f LIMIT %d", MySQLDialect.Quote(column), a.table(table), ratio, sampleSize*bernoulliLimitFactor)
+	return source, SampleBernoulli, ratio, tableRows
+}
+
+// SampleColumnStats 采样列统计
+// 在同一份样本上按取值分组，一次查询得到行数、NULL 数、不同值数和 TopN，并外推全表统计
+func (a *MySQLAdapter) SampleColumnStats(table, column string, sampleSize int) (*ColumnStats, error) {
+	if err := a.guard(table, column, OpColumnStats, false); err != nil {
+		return nil, err
+	}
+	source, method, ratio, tableRows := a.sampleSource(table, column, sampleSize)
+	stats, singletons, err := a.sampleStats(source, method, ratio)
 	if err != nil {
 		return nil, err
 	}
 	
-	topQuery := fmt.Sprintf(`
-		SELECT %s, COUNT(*) as cnt
-		FROM (SELECT %s FROM %s ORDER BY RAND() LIMIT %d) sample
-		WHERE %s IS NOT NULL
-		GROUP BY %s
+	if method == SampleFull && stats.TotalRows > int64(sampleSize) {
+		// TABLE_ROWS 严重低估，全表读取被 LIMIT 截断：读到的只是开头的行。
+		// 用有上限的计数修正行数后按概率重新抽样
+		if tableRows, err = a.countRows(table, int64(sampleSize)*rowCountCapFactor); err != nil {
+			return nil, err
+		}
+		a.db.setTableRows(table, tableRows)
+		source, method, ratio, tableRows = a.sampleSource(table, column, sampleSize)
+		if stats, singletons, err = a.sampleStats(source, method, ratio); err != nil {
+			return nil, err
+		}
+	}
+	
+	if method == SampleFull {
+		// TABLE_ROWS 是估计值，全表统计时以实际行数为准
+		tableRows = stats.TotalRows
+	} else if tableRows > 0 {
+		// 记录实际抽到的比例
+		stats.SampleRatio = float64(stats.TotalRows) / float64(tableRows)
+		if stats.SampleRatio > 1 {
+			stats.SampleRatio = 1
+		}
+	}
+	stats.Extrapolate(tableRows, singletons)
+	return stats, nil
+}
+
+// sampleStats 在样本上按取值分组统计，返回统计和只出现一次的取值数
+func (a *MySQLAdapter) sampleStats(source, method string, ratio float64) (*ColumnStats, int64, error) {
+	stats := &ColumnStats{SampleMethod: method, SampleRatio: ratio}
+	
+	query := fmt.Sprintf(`
+		SELECT v, COUNT(*) AS cnt
+		FROM (%s) sample
+		GROUP BY v
 		ORDER BY cnt DESC
-		LIMIT 10
-	`, column, column, table, sampleSize, column, column)
+	`, source)
 	
-	rows, err := a.db.Query(topQuery)
+	rows, err := a.db.Query(query)
 	if err != nil {
-		return stats, nil
+		return nil, 0, err
 	}
 	defer rows.Close()
 	
+	var singletons int64
 	for rows.Next() {
-		var vc ValueCount
-		if err := rows.Scan(&vc.Value, &vc.Count); err != nil {
+		var v sql.NullString
+		var cnt int64
+		if err := rows.Scan(&v, &cnt); err != nil {
+			return nil, 0, err
+		}
+		stats.TotalRows += cnt
+		if !v.Valid {
+			stats.NullCount += cnt
 			continue
 		}
-		stats.TopValues = append(stats.TopValues, vc)
+		stats.DistinctCount++
+		if cnt == 1 {
+			singletons++
+		}
+		if len(stats.TopValues) < 10 {
+			stats.TopValues = append(stats.TopValues, ValueCount{Value: v.String, Count: cnt})
+		}
 	}
-	
-	return stats, nil
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return stats, singletons, nil
+}
+
+// rowCountCapFactor 修正行数时最多计数采样大小的多少倍
+const rowCountCapFactor = 100
+
+// countRows 有上限的行数统计，最多扫描 limit 行，达到上限时返回 limit（实际行数不少于它）
+func (a *MySQLAdapter) countRows(table string, limit int64) (int64, error) {
+	var count int64
+	query := fmt.Sprintf("SELECT COUNT(*) FROM (SELECT 1 FROM %s LIMIT %d) t", a.table(table), limit)
+	err := a.db.QueryRow(query).Scan(&count)
+	return count, err
 }
 
 // GetPrimaryKeys 获取主键
@@ -222,7 +404,8 @@ func (a *MySQLAdapter) GetPrimaryKeys(table string) ([]string, error) {
 		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'
 		ORDER BY ORDINAL_POSITION
 	`
-	rows, err := a.db.Query(query, a.schema, table)
+	schema, name := a.splitTable(table)
+	rows, err := a.db.Query(query, schema, name)
 	if err != nil {
 		return nil, err
 	}
@@ -239,19 +422,35 @@ func (a *MySQLAdapter) GetPrimaryKeys(table string) ([]string, error) {
 	return keys, nil
 }
 
-// GetForeignKeys 获取外键约束
+// GetForeignKeys 获取外键约束（设置了表过滤时只返回两端都在扫描范围内的外键）
 func (a *MySQLAdapter) GetForeignKeys() ([]ForeignKey, error) {
-	query := `
+	fks, err := a.getForeignKeys()
+	if err != nil || a.filter == nil {
+		return fks, err
+	}
+	if !a.filter.Selected() {
+		if _, err := a.listTables(); err != nil {
+			return nil, err
+		}
+	}
+	return a.filter.ForeignKeys(fks), nil
+}
+
+func (a *MySQLAdapter) getForeignKeys() ([]ForeignKey, error) {
+	in, args := a.schemaIn()
+	query := fmt.Sprintf(`
 		SELECT 
+			kcu.TABLE_SCHEMA,
 			kcu.TABLE_NAME,
 			kcu.COLUMN_NAME,
+			kcu.REFERENCED_TABLE_SCHEMA,
 			kcu.REFERENCED_TABLE_NAME,
 			kcu.REFERENCED_COLUMN_NAME
 		FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu
-		WHERE kcu.TABLE_SCHEMA = ? 
+		WHERE kcu.TABLE_SCHEMA IN (%s) 
 			AND kcu.REFERENCED_TABLE_NAME IS NOT NULL
-	`
-	rows, err := a.db.Query(query, a.schema)
+	`, in)
+	rows, err := a.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -260,14 +459,54 @@ func (a *MySQLAdapter) GetForeignKeys() ([]ForeignKey, error) {
 	var fks []ForeignKey
 	for rows.Next() {
 		var fk ForeignKey
-		if err := rows.Scan(&fk.FromTable, &fk.FromColumn, &fk.ToTable, &fk.ToColumn); err != nil {
+		var fromSchema, toSchema string
+		if err := rows.Scan(&fromSchema, &fk.FromTable, &fk.FromColumn, &toSchema, &fk.ToTable, &fk.ToColumn); err != nil {
 			return nil, err
 		}
+		fk.FromTable = QualifyName(fromSchema, fk.FromTable)
+		fk.ToTable = QualifyName(toSchema, fk.ToTable)
 		fks = append(fks, fk)
 	}
 	return fks, nil
 }
 
+// SampleValues 采样读取列的原始取值
+func (a *MySQLAdapter) SampleValues(table, column string, sampleSize int) ([]sql.NullString, error) {
+	if err := a.guard(table, column, OpSampleValues, false); err != nil {
+		return nil, err
+	}
+	source, _, _, _ := a.sampleSource(table, column, sampleSize)
+	return scanValues(a.db, source)
+}
+
+// GetColumnRange 获取列的最小值和最大值
+func (a *MySQLAdapter) GetColumnRange(table, column string) (min, max sql.NullString, err error) {
+	if err = a.guard(table, column, OpColumnRange, true); err != nil {
+		return
+	}
+	col := MySQLDialect.Quote(column)
+	query := fmt.Sprintf(`SELECT MIN(%s), MAX(%s) FROM %s`, col, col, a.table(table))
+	err = a.db.QueryRow(query).Scan(&min, &max)
+	return
+}
+
+// GetValuePairs 读取码表的 键→含义 对
+func (a *MySQLAdapter) GetValuePairs(table, keyColumn, valueColumn string, limit int) ([]ValuePair, error) {
+	if err := a.guard(table, keyColumn, OpValuePairs, true); err != nil {
+		return nil, err
+	}
+	key := MySQLDialect.Quote(keyColumn)
+	query := fmt.Sprintf(`
+		SELECT %s, %s
+		FROM %s
+		WHERE %s IS NOT NULL
+		ORDER BY %s
+		LIMIT %d
+	`, key, MySQLDialect.Quote(valueColumn), a.table(table), key, key, limit)
+	
+	return scanValuePairs(a.db, query)
+}
+
 // Close 关闭连接
 func (a *MySQLAdapter) Close() error {
 	return a.db.Close()
@@ -0,0 +1,76 @@
+package adapter
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+// fakeMySQL 按查询内容返回 TABLE_ROWS 估计值、计数和分组结果，并记录执行过的查询
+func fakeMySQL(estimate, count int64, full, sampled [][]driver.Value, queries *[]string) *MySQLAdapter {
+	db := fakeDB(func(_ context.Context, query string, _ []driver.NamedValue) ([][]driver.Value, error) {
+		*queries = append(*queries, query)
+		switch {
+		case strings.Contains(query, "TABLE_ROWS"):
+			return [][]driver.Value{{estimate}}, nil
+		case strings.Contains(query, "SELECT COUNT(*) FROM ("):
+			return [][]driver.Value{{count}}, nil
+		case strings.Contains(query, "RAND()"):
+			return sampled, nil
+		}
+		return full, nil
+	}).open()
+	return &MySQLAdapter{db: newSafeDB(db, SafetyOptions{}), schema: "erp", schemas: []string{"erp"}}
+}
+
+func TestSampleColumnStatsFull(t *testing.T) {
+	var queries []string
+	a := fakeMySQL(10, 0, [][]driver.Value{{"A", int64(6)}, {"B", int64(4)}}, nil, &queries)
+
+	stats, err := a.SampleColumnStats("erp.T", "c", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.SampleMethod != SampleFull || stats.SampleRatio != 1 || stats.EstimatedRows != 10 || stats.EstimatedDistinct != 2 {
+		t.Errorf("全表统计结果不对: %+v", stats)
+	}
+	for _, q := range queries {
+		if strings.Contains(q, "SELECT COUNT(*) FROM (") {
+			t.Errorf("估计值准确时不应重新计数: %s", q)
+		}
+	}
+}
+
+func TestSampleColumnStatsTruncatedFullRead(t *testing.T) {
+	// TABLE_ROWS 只有 10，实际有 50000 行：全表读取读满 LIMIT，应改为按概率抽样并外推
+	var queries []string
+	full := [][]driver.Value{{"A", int64(1001)}}
+	sampled := [][]driver.Value{{"A", int64(600)}, {"B", int64(400)}}
+	a := fakeMySQL(10, 50000, full, sampled, &queries)
+
+	stats, err := a.SampleColumnStats("erp.T", "c", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.SampleMethod != SampleBernoulli {
+		t.Errorf("被截断的全表读取应改为逐行抽样，实际 %s", stats.SampleMethod)
+	}
+	if stats.SampleRatio != 0.02 {
+		t.Errorf("抽样比例应为 1000/50000，实际 %v", stats.SampleRatio)
+	}
+	if stats.TotalRows != 1000 || stats.EstimatedRows != 50000 {
+		t.Errorf("应按修正后的行数外推: %+v", stats)
+	}
+
+	// 修正后的行数被缓存，同一张表的其他列直接抽样
+	queries = nil
+	if _, err := a.SampleColumnStats("erp.T", "d", 1000); err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range queries {
+		if strings.Contains(q, "SELECT COUNT(*) FROM (") || strings.Contains(q, "TABLE_ROWS") {
+			t.Errorf("修正后的行数应被缓存: %s", q)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 大表处理策略
+const (
+	LargeTableSkip   = "skip"   // 跳过大表的所有取数查询
+	LargeTableSample = "sample" // 大表只读取开头若干行，不做全表扫描类查询（如 MIN/MAX）
+)
+
+// ErrSkipped 安全模式下未执行的查询
+var ErrSkipped = errors.New("安全模式跳过")
+
+// SafetyOptions 生产库低影响扫描选项，零值表示不做任何限制
+type SafetyOptions struct {
+	ReadOnly        bool          // 只读会话（MySQL transaction_read_only，SQL Server ApplicationIntent=ReadOnly）
+	QueryTimeout    time.Duration // 单条查询超时（MySQL 同时设置 max_execution_time）
+	LockTimeout     time.Duration // SQL Server SET LOCK_TIMEOUT
+	ReadUncommitted bool          // SQL Server 会话使用 READ UNCOMMITTED，取数查询加 NOLOCK
+	MaxTableRows    int64         // 估算行数超过该值的表按 LargeTable 策略处理（0 不限制）
+	LargeTable      string        // 大表策略：skip / sample
+	MaxQPS          float64       // 全局查询速率上限（0 不限制）
+}
+
+// SafeModeOptions 安全模式的默认选项
+func SafeModeOptions() SafetyOptions {
+	return SafetyOptions{
+		ReadOnly:        true,
+		QueryTimeout:    30 * time.Second,
+		LockTimeout:     5 * time.Second,
+		ReadUncommitted: true,
+		LargeTable:      LargeTableSample,
+		MaxQPS:          20,
+	}
+}
+
+// 被跳过的操作
+const (
+	OpColumnStats  = "column_stats"  // 采样统计
+	OpSampleValues = "sample_values" // 采样取值（数据画像）
+	OpColumnRange  = "column_range"  // MIN/MAX
+	OpValuePairs   = "value_pairs"   // 码表取值
+	OpQuery        = "query"         // 查询超时
+)
+
+// SkipRecord 安全模式跳过或中断的操作
+type SkipRecord struct {
+	Table     string `json:"table"`
+	Column    string `json:"column,omitempty"`
+	Operation string `json:"operation"`
+	Reason    string `json:"reason"`
+}
+
+// SafetyReporter 提供安全模式跳过记录的适配器
+type SafetyReporter interface {
+	SkipRecords() []SkipRecord
+}
+
+// safeDB 包装 *sql.DB：每条查询前按速率限制等待，并附带超时
+type safeDB struct {
+	db      *sql.DB
+	opts    SafetyOptions
+	mu      sync.Mutex
+	next    time.Time // 速率限制：下一条查询最早的开始时间
+	records []SkipRecord
+	skipped map[string]bool  // 已记录的 表.列.操作，避免重复记录
+	rows    map[string]int64 // 表的估算行数缓存
+}
+
+func newSafeDB(db *sql.DB, opts SafetyOptions) *safeDB {
+	return &safeDB{db: db, opts: opts, skipped: make(map[string]bool), rows: make(map[string]int64)}
+}
+
+// wait 按 MaxQPS 均匀地放行查询
+func (s *safeDB) wait() {
+	if s.opts.MaxQPS <= 0 {
+		return
+	}
+	interval := time.Duration(float64(time.Second) / s.opts.MaxQPS)
+	s.mu.Lock()
+	now := time.Now()
+	start := s.next
+	if start.Before(now) {
+		start = now
+	}
+	s.next = start.Add(interval)
+	s.mu.Unlock()
+	time.Sleep(time.Until(start))
+}
+
+// context 查询上下文。结果集在函数返回后才读取，所以 cancel 由 safeRows.Close / safeRow.Scan 调用，而不是在返回时调用
+func (s *safeDB) context() (context.Context, context.CancelFunc) {
+	if s.opts.QueryTimeout <= 0 {
+		return context.Background(), func() {}
+	}
+	return context.WithTimeout(context.Background(), s.opts.QueryTimeout)
+}
+
+// Query 执行查询
+func (s *safeDB) Query(query string, args ...interface{}) (*safeRows, error) {
+	s.wait()
+	ctx, cancel := s.context()
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		s.checkTimeout(query, err)
+		return nil, err
+	}
+	return &safeRows{Rows: rows, db: s, query: query, cancel: cancel}, nil
+}
+
+// QueryRow 执行单行查询
+func (s *safeDB) QueryRow(query string, args ...interface{}) *safeRow {
+	s.wait()
+	ctx, cancel := s.context()
+	return &safeRow{row: s.db.QueryRowContext(ctx, query, args...), db: s, query: query, cancel: cancel}
+}
+
+// safeRows 查询结果集，关闭时释放超时上下文，读取过程中超时的查询记入跳过记录
+type safeRows struct {
+	*sql.Rows
+	db     *safeDB
+	query  string
+	cancel context.CancelFunc
+}
+
+// Err 读取结果集时的错误
+func (r *safeRows) Err() error {
+	err := r.Rows.Err()
+	r.db.checkTimeout(r.query, err)
+	return err
+}
+
+// Close 关闭结果集并释放超时上下文
+func (r *safeRows) Close() error {
+	r.db.checkTimeout(r.query, r.Rows.Err())
+	err := r.Rows.Close()
+	r.cancel()
+	return err
+}
+
+// safeRow 单行查询结果，Scan 后释放超时上下文
+type safeRow struct {
+	row    *sql.Row
+	db     *safeDB
+	query  string
+	cancel context.CancelFunc
+}
+
+// Scan 读取结果，超时的查询记入跳过记录
+func (r *safeRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	r.cancel()
+	r.db.checkTimeout(r.query, err)
+	return err
+}
+
+// Close 关闭连接
+func (s *safeDB) Close() error {
+	return s.db.Close()
+}
+
+// checkTimeout 记录超时的查询
+func (s *safeDB) checkTimeout(query string, err error) {
+	if err == nil || s.opts.QueryTimeout <= 0 || !errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	s.record(SkipRecord{Operation: OpQuery, Reason: fmt.Sprintf("超过 %s 超时: %s", s.opts.QueryTimeout, abbreviate(query))})
+}
+
+func (s *safeDB) record(r SkipRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := r.Table + "\x00" + r.Column + "\x00" + r.Operation + "\x00" + r.Reason
+	if s.skipped[key] {
+		return
+	}
+	s.skipped[key] = true
+	s.records = append(s.records, r)
+}
+
+// SkipRecords 跳过记录（按表、操作排序）
+func (s *safeDB) SkipRecords() []SkipRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := append([]SkipRecord(nil), s.records...)
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Table != records[j].Table {
+			return records[i].Table < records[j].Table
+		}
+		return records[i].Operation < records[j].Operation
+	})
+	return records
+}
+
+// tableRows 表的估算行数（按表缓存，每个表只查询一次）
+func (s *safeDB) tableRows(table string, estimate func(string) (int64, error)) int64 {
+	s.mu.Lock()
+	rows, ok := s.rows[table]
+	s.mu.Unlock()
+	if ok {
+		return rows
+	}
+	rows, _ = estimate(table)
+	s.mu.Lock()
+	s.rows[table] = rows
+	s.mu.Unlock()
+	return rows
+}
+
+// setTableRows 修正表的估算行数（如发现 TABLE_ROWS 严重低估时）
+func (s *safeDB) setTableRows(table string, rows int64) {
+	s.mu.Lock()
+	s.rows[table] = rows
+	s.mu.Unlock()
+}
+
+// largeTable 判断表的估算行数是否超过上限
+func (s *safeDB) largeTable(table string, estimate func(string) (int64, error)) bool {
+	return s.opts.MaxTableRows > 0 && s.tableRows(table, estimate) > s.opts.MaxTableRows
+}
+
+// guard 取数查询前的检查：大表按策略跳过。fullScan 表示该查询可能扫描全表（如 MIN/MAX、排序），
+// 在 sample 策略下也会被跳过
+func (s *safeDB) guard(table, column, operation string, fullScan bool, estimate func(string) (int64, error)) error {
+	if !s.largeTable(table, estimate) {
+		return nil
+	}
+	if s.opts.LargeTable != LargeTableSkip && !fullScan {
+		return nil
+	}
+	rows := s.tableRows(table, estimate)
+	s.record(SkipRecord{
+		Table:     table,
+		Column:    column,
+		Operation: operation,
+		Reason:    fmt.Sprintf("估算行数 %d 超过上限 %d", rows, s.opts.MaxTableRows),
+	})
+	return fmt.Errorf("%w: %s 行数 %d 超过上限 %d", ErrSkipped, table, rows, s.opts.MaxTableRows)
+}
+
+func abbreviate(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if r := []rune(query); len(r) > 120 {
+		return string(r[:120]) + "…"
+	}
+	return query
+}
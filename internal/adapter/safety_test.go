@@ -0,0 +1,164 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSafetyGuard(t *testing.T) {
+	estimates := 0
+	estimate := func(table string) (int64, error) {
+		estimates++
+		if table == "dbo.Voucher" {
+			return 5000000, nil
+		}
+		return 100, nil
+	}
+
+	sample := newSafeDB(nil, SafetyOptions{MaxTableRows: 1000000, LargeTable: LargeTableSample})
+	if err := sample.guard("dbo.Voucher", "cCode", OpColumnStats, false, estimate); err != nil {
+		t.Errorf("sample policy should allow sampling a large table, got %v", err)
+	}
+	if err := sample.guard("dbo.Voucher", "cCode", OpColumnRange, true, estimate); !errors.Is(err, ErrSkipped) {
+		t.Errorf("sample policy should skip full scans of a large table, got %v", err)
+	}
+	if err := sample.guard("dbo.Customer", "cCode", OpColumnRange, true, estimate); err != nil {
+		t.Errorf("small table should not be skipped, got %v", err)
+	}
+	if estimates != 2 {
+		t.Errorf("expected row counts to be cached per table, estimated %d times", estimates)
+	}
+
+	skip := newSafeDB(nil, SafetyOptions{MaxTableRows: 1000000, LargeTable: LargeTableSkip})
+	for i := 0; i < 2; i++ {
+		if err := skip.guard("dbo.Voucher", "cCode", OpSampleValues, false, estimate); !errors.Is(err, ErrSkipped) {
+			t.Errorf("skip policy should skip every data query, got %v", err)
+		}
+	}
+	records := skip.SkipRecords()
+	if len(records) != 1 || records[0].Table != "dbo.Voucher" || records[0].Operation != OpSampleValues {
+		t.Errorf("expected one deduplicated skip record, got %+v", records)
+	}
+
+	unlimited := newSafeDB(nil, SafetyOptions{})
+	if err := unlimited.guard("dbo.Voucher", "cCode", OpColumnRange, true, func(string) (int64, error) {
+		t.Error("row count should not be estimated without a limit")
+		return 0, nil
+	}); err != nil {
+		t.Errorf("no limit configured, got %v", err)
+	}
+}
+
+func TestSafetyRateLimit(t *testing.T) {
+	s := newSafeDB(nil, SafetyOptions{MaxQPS: 100})
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		s.wait()
+	}
+	// 第一条立即放行，其余每条间隔 10ms
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+		t.Errorf("expected queries to be spaced out, took %s", elapsed)
+	}
+}
+
+func TestSafetyConnectionSettings(t *testing.T) {
+	dsn, err := mysqlSafeDSN("user:pass@tcp(db:3306)/erp", SafetyOptions{ReadOnly: true, QueryTimeout: 30 * time.Second}, "transaction_read_only")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dsn, "transaction_read_only=1") || !strings.Contains(dsn, "max_execution_time=30000") {
+		t.Errorf("expected session variables in DSN, got %s", dsn)
+	}
+	if dsn, _ := mysqlSafeDSN("user:pass@tcp(db:3306)/erp", SafetyOptions{}, "transaction_read_only"); dsn != "user:pass@tcp(db:3306)/erp" {
+		t.Errorf("DSN should be unchanged without safety options, got %s", dsn)
+	}
+
+	init := sqlServerSessionInit(SafetyOptions{LockTimeout: 5 * time.Second, ReadUncommitted: true})
+	if !strings.Contains(init, "SET LOCK_TIMEOUT 5000") || !strings.Contains(init, "READ UNCOMMITTED") {
+		t.Errorf("unexpected session init %q", init)
+	}
+}
+
+// fakeDB 测试用数据库：每条查询交给函数处理，返回结果行
+type fakeDB func(ctx context.Context, query string, args []driver.NamedValue) ([][]driver.Value, error)
+
+func (f fakeDB) open() *sql.DB { return sql.OpenDB(f) }
+
+func (f fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ respond fakeDB }
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.respond(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct{ rows [][]driver.Value }
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return []string{"v"}
+	}
+	return make([]string, len(r.rows[0]))
+}
+func (r *fakeRows) Close() error { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSafetyQueryTimeout(t *testing.T) {
+	// 查询语句为 "slow" 时等到上下文结束，其余查询返回一行
+	db := fakeDB(func(ctx context.Context, query string, _ []driver.NamedValue) ([][]driver.Value, error) {
+		if query == "slow" {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return [][]driver.Value{{int64(1)}}, nil
+	}).open()
+	s := newSafeDB(db, SafetyOptions{QueryTimeout: 20 * time.Millisecond})
+
+	var v int
+	if err := s.QueryRow("fast").Scan(&v); err != nil || v != 1 {
+		t.Fatalf("expected one row, got %d, %v", v, err)
+	}
+	if err := s.QueryRow("slow").Scan(&v); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected QueryRow to time out, got %v", err)
+	}
+	if len(s.SkipRecords()) != 1 {
+		t.Errorf("expected QueryRow timeout to be recorded, got %+v", s.SkipRecords())
+	}
+	if _, err := s.Query("slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected Query to time out, got %v", err)
+	}
+	rows, err := s.Query("fast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+
+	records := s.SkipRecords()
+	if len(records) != 1 || records[0].Operation != OpQuery || !strings.Contains(records[0].Reason, "slow") {
+		t.Errorf("expected one timeout record for the slow query, got %+v", records)
+	}
+}
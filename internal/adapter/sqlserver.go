@@ -3,24 +3,106 @@ package adapter
 import (
 	"database/sql"
 	"fmt"
-	_ "github.com/denisenkom/go-mssqldb"
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/denisenkom/go-mssqldb/msdsn"
 )
 
 // SQLServerAdapter SQL Server 适配器
 type SQLServerAdapter struct {
-	db *sql.DB
+	db      *safeDB
+	hint    string   // 取数查询的表提示，如 WITH (NOLOCK)
+	schemas []string // 只扫描这些 schema，为空时扫描全部
+	filter  *TableFilter
 }
 
 // NewSQLServerAdapter 创建 SQL Server 适配器
 func NewSQLServerAdapter(connStr string) (*SQLServerAdapter, error) {
-	db, err := sql.Open("sqlserver", connStr)
+	return NewSQLServerAdapterWithOptions(connStr, SafetyOptions{})
+}
+
+// NewSQLServerAdapterWithOptions 按安全选项创建 SQL Server 适配器。
+// 锁超时和隔离级别在每个会话建立（及连接池复用重置）时设置；
+// 只读时声明 ApplicationIntent=ReadOnly，可用性组会路由到只读副本
+func NewSQLServerAdapterWithOptions(connStr string, opts SafetyOptions) (*SQLServerAdapter, error) {
+	cfg, _, err := msdsn.Parse(connStr)
 	if err != nil {
 		return nil, err
 	}
+	// ApplicationIntent=ReadOnly 要求连接字符串指定数据库
+	if opts.ReadOnly && cfg.Database != "" {
+		cfg.ReadOnlyIntent = true
+	}
+	connector := mssql.NewConnectorConfig(cfg)
+	connector.SessionInitSQL = sqlServerSessionInit(opts)
+	
+	db := sql.OpenDB(connector)
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
-	return &SQLServerAdapter{db: db}, nil
+	a := &SQLServerAdapter{db: newSafeDB(db, opts)}
+	if opts.ReadUncommitted {
+		a.hint = " WITH (NOLOCK)"
+	}
+	return a, nil
+}
+
+// sqlServerSessionInit 会话初始化语句
+func sqlServerSessionInit(opts SafetyOptions) string {
+	var init string
+	if opts.LockTimeout > 0 {
+		init += fmt.Sprintf("SET LOCK_TIMEOUT %d; ", opts.LockTimeout.Milliseconds())
+	}
+	if opts.ReadUncommitted {
+		init += "SET TRANSACTION ISOLATION LEVEL READ UNCOMMITTED; "
+	}
+	return init
+}
+
+// SkipRecords 安全模式跳过的操作
+func (a *SQLServerAdapter) SkipRecords() []SkipRecord {
+	return a.db.SkipRecords()
+}
+
+// guard 大表取数前的检查
+func (a *SQLServerAdapter) guard(table, column, operation string, fullScan bool) error {
+	return a.db.guard(table, column, operation, fullScan, a.EstimateRowCount)
+}
+
+// SetSchemas 限定扫描的 schema
+func (a *SQLServerAdapter) SetSchemas(schemas []string) {
+	a.schemas = schemas
+}
+
+// schemaFilter 生成按 schema 过滤的 AND 条件，expr 为 schema 名表达式；未限定 schema 时返回空条件
+func (a *SQLServerAdapter) schemaFilter(expr string) (string, []interface{}) {
+	if len(a.schemas) == 0 {
+		return "", nil
+	}
+	in, args := inList(a.schemas, func(i int) string { return fmt.Sprintf("@p%d", i+1) })
+	return fmt.Sprintf("AND %s IN (%s)", expr, in), args
+}
+
+// defaultSchema 不带 schema 前缀的表名默认属于 dbo
+const sqlServerDefaultSchema = "dbo"
+
+// table 引用表名，如 [dbo].[Customer]
+func (a *SQLServerAdapter) table(ref string) string {
+	return SQLServerDialect.Table(ref, sqlServerDefaultSchema)
+}
+
+// SetTableFilter 设置表过滤规则
+func (a *SQLServerAdapter) SetTableFilter(filter *TableFilter) {
+	a.filter = filter
+}
+
+// listTables 列出要扫描的表（已应用表过滤）
+func (a *SQLServerAdapter) listTables() ([]Table, error) {
+	tables, err := a.getTables()
+	if err != nil {
+		return nil, err
+	}
+	return a.filter.Select(tables, a.getForeignKeys)
 }
 
 // IntrospectSchema 获取元数据
@@ -28,7 +110,7 @@ func (a *SQLServerAdapter) IntrospectSchema() (*SchemaMetadata, error) {
 	meta := &SchemaMetadata{}
 	
 	// 获取表列表
-	tables, err := a.getTables()
+	tables, err := a.listTables()
 	if err != nil {
 		return nil, err
 	}
@@ -49,19 +131,20 @@ func (a *SQLServerAdapter) IntrospectSchema() (*SchemaMetadata, error) {
 	if err != nil {
 		return nil, err
 	}
-	meta.Indexes = indexes
+	meta.Indexes = a.filter.Indexes(indexes)
 	
 	return meta, nil
 }
 
 func (a *SQLServerAdapter) getTables() ([]Table, error) {
-	query := `
+	filter, args := a.schemaFilter("TABLE_SCHEMA")
+	query := fmt.Sprintf(`
 		SELECT TABLE_SCHEMA, TABLE_NAME
 		FROM INFORMATION_SCHEMA.TABLES
-		WHERE TABLE_TYPE = 'BASE TABLE'
+		WHERE TABLE_TYPE = 'BASE TABLE' %s
 		ORDER BY TABLE_SCHEMA, TABLE_NAME
-	`
-	rows, err := a.db.Query(query)
+	`, filter)
+	rows, err := a.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -75,9 +158,60 @@ func (a *SQLServerAdapter) getTables() ([]Table, error) {
 		}
 		tables = append(tables, t)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	
+	a.fillTableActivity(tables)
 	return tables, nil
 }
 
+// fillTableActivity 补充行数和最后修改时间。sys.dm_db_index_usage_stats 需要 VIEW SERVER STATE 权限，
+// 且实例重启后清空，取不到时退回只查行数；两者都失败也不影响扫描
+func (a *SQLServerAdapter) fillTableActivity(tables []Table) {
+	queries := []string{`
+		SELECT SCHEMA_NAME(t.schema_id), t.name,
+			(SELECT SUM(p.rows) FROM sys.partitions p WHERE p.object_id = t.object_id AND p.index_id IN (0,1)),
+			(SELECT CONVERT(varchar(19), MAX(u.last_user_update), 120) FROM sys.dm_db_index_usage_stats u
+				WHERE u.database_id = DB_ID() AND u.object_id = t.object_id)
+		FROM sys.tables t
+	`, `
+		SELECT SCHEMA_NAME(t.schema_id), t.name,
+			(SELECT SUM(p.rows) FROM sys.partitions p WHERE p.object_id = t.object_id AND p.index_id IN (0,1)),
+			NULL
+		FROM sys.tables t
+	`}
+	
+	index := make(map[string]*Table, len(tables))
+	for i := range tables {
+		index[tables[i].QualifiedName()] = &tables[i]
+	}
+	
+	for _, query := range queries {
+		rows, err := a.db.Query(query)
+		if err != nil {
+			continue
+		}
+		for rows.Next() {
+			var schemaName, tableName string
+			var count sql.NullInt64
+			var updated sql.NullString
+			if err := rows.Scan(&schemaName, &tableName, &count, &updated); err != nil {
+				continue
+			}
+			if t, ok := index[QualifyName(schemaName, tableName)]; ok {
+				t.RowCount = count.Int64
+				t.LastModified = parseCatalogTime(updated)
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err == nil {
+			return
+		}
+	}
+}
+
 func (a *SQLServerAdapter) getColumns(schema, table string) ([]Column, error) {
 	query := `
 		SELECT 
@@ -120,8 +254,10 @@ func (a *SQLServerAdapter) getColumns(schema, table string) ([]Column, error) {
 }
 
 func (a *SQLServerAdapter) getIndexes() ([]Index, error) {
-	query := `
+	filter, args := a.schemaFilter("SCHEMA_NAME(t.schema_id)")
+	query := fmt.Sprintf(`
 		SELECT 
+			SCHEMA_NAME(t.schema_id) as TABLE_SCHEMA,
 			t.name as TABLE_NAME,
 			i.name as INDEX_NAME,
 			c.name as COLUMN_NAME,
@@ -130,10 +266,10 @@ func (a *SQLServerAdapter) getIndexes() ([]Index, error) {
 		JOIN sys.index_columns ic ON i.object_id = ic.object_id AND i.index_id = ic.index_id
 		JOIN sys.columns c ON ic.object_id = c.object_id AND ic.column_id = c.column_id
 		JOIN sys.tables t ON i.object_id = t.object_id
-		WHERE i.is_primary_key = 0
-		ORDER BY t.name, i.name, ic.key_ordinal
-	`
-	rows, err := a.db.Query(query)
+		WHERE i.is_primary_key = 0 %s
+		ORDER BY TABLE_SCHEMA, t.name, i.name, ic.key_ordinal
+	`, filter)
+	rows, err := a.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -141,11 +277,12 @@ func (a *SQLServerAdapter) getIndexes() ([]Index, error) {
 	
 	indexMap := make(map[string]*Index)
 	for rows.Next() {
-		var tableName, indexName, columnName string
+		var schemaName, tableName, indexName, columnName string
 		var isUnique bool
-		if err := rows.Scan(&tableName, &indexName, &columnName, &isUnique); err != nil {
+		if err := rows.Scan(&schemaName, &tableName, &indexName, &columnName, &isUnique); err != nil {
 			return nil, err
 		}
+		tableName = QualifyName(schemaName, tableName)
 		
 		key := tableName + "." + indexName
 		if idx, exists := indexMap[key]; exists {
@@ -172,11 +309,10 @@ func (a *SQLServerAdapter) EstimateRowCount(table string) (int64, error) {
 	query := `
 		SELECT SUM(p.rows) 
 		FROM sys.partitions p
-		JOIN sys.tables t ON p.object_id = t.object_id
-		WHERE t.name = @p1 AND p.index_id IN (0,1)
+		WHERE p.object_id = OBJECT_ID(@p1) AND p.index_id IN (0,1)
 	`
 	var count sql.NullInt64
-	err := a.db.QueryRow(query, table).Scan(&count)
+	err := a.db.QueryRow(query, a.table(table)).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -188,16 +324,21 @@ func (a *SQLServerAdapter) EstimateRowCount(table string) (int64, error) {
 
 // SampleColumnStats 采样列统计
 func (a *SQLServerAdapter) SampleColumnStats(table, column string, sampleSize int) (*ColumnStats, error) {
-	stats := &ColumnStats{}
+	if err := a.guard(table, column, OpColumnStats, false); err != nil {
+		return nil, err
+	}
+	stats := &ColumnStats{SampleMethod: SampleTableSample}
+	
+	col := SQLServerDialect.Quote(column)
 	
 	// 总行数和NULL计数
 	query := fmt.Sprintf(`
 		SELECT 
 			COUNT(*) as total,
-			SUM(CASE WHEN [%s] IS NULL THEN 1 ELSE 0 END) as nulls,
-			COUNT(DISTINCT [%s]) as distincts
-		FROM [%s] TABLESAMPLE (%d ROWS)
-	`, column, column, table, sampleSize)
+			SUM(CASE WHEN %s IS NULL THEN 1 ELSE 0 END) as nulls,
+			COUNT(DISTINCT %s) as distincts
+		FROM %s TABLESAMPLE (%d ROWS)%s
+	`, col, col, a.table(table), sampleSize, a.hint)
 	
 	err := a.db.QueryRow(query).Scan(&stats.TotalRows, &stats.NullCount, &stats.DistinctCount)
 	if err != nil {
@@ -206,12 +347,12 @@ func (a *SQLServerAdapter) SampleColumnStats(table, column string, sampleSize in
 	
 	// TopN值
 	topQuery := fmt.Sprintf(`
-		SELECT TOP 10 [%s], COUNT(*) as cnt
-		FROM [%s] TABLESAMPLE (%d ROWS)
-		WHERE [%s] IS NOT NULL
-		GROUP BY [%s]
+		SELECT TOP 10 %s, COUNT(*) as cnt
+		FROM %s TABLESAMPLE (%d ROWS)%s
+		WHERE %s IS NOT NULL
+		GROUP BY %s
 		ORDER BY cnt DESC
-	`, column, table, sampleSize, column, column)
+	`, col, a.table(table), sampleSize, a.hint, col, col)
 	
 	rows, err := a.db.Query(topQuery)
 	if err != nil {
@@ -237,11 +378,10 @@ func (a *SQLServerAdapter) GetPrimaryKeys(table string) ([]string, error) {
 		FROM sys.indexes i
 		JOIN sys.index_columns ic ON i.object_id = ic.object_id AND i.index_id = ic.index_id
 		JOIN sys.columns c ON ic.object_id = c.object_id AND ic.column_id = c.column_id
-		JOIN sys.tables t ON i.object_id = t.object_id
-		WHERE t.name = @p1 AND i.is_primary_key = 1
+		WHERE i.object_id = OBJECT_ID(@p1) AND i.is_primary_key = 1
 		ORDER BY ic.key_ordinal
 	`
-	rows, err := a.db.Query(query, table)
+	rows, err := a.db.Query(query, a.table(table))
 	if err != nil {
 		return nil, err
 	}
@@ -258,18 +398,35 @@ func (a *SQLServerAdapter) GetPrimaryKeys(table string) ([]string, error) {
 	return keys, nil
 }
 
-// GetForeignKeys 获取外键约束
+// GetForeignKeys 获取外键约束（设置了表过滤时只返回两端都在扫描范围内的外键）
 func (a *SQLServerAdapter) GetForeignKeys() ([]ForeignKey, error) {
-	query := `
+	fks, err := a.getForeignKeys()
+	if err != nil || a.filter == nil {
+		return fks, err
+	}
+	if !a.filter.Selected() {
+		if _, err := a.listTables(); err != nil {
+			return nil, err
+		}
+	}
+	return a.filter.ForeignKeys(fks), nil
+}
+
+func (a *SQLServerAdapter) getForeignKeys() ([]ForeignKey, error) {
+	filter, args := a.schemaFilter("OBJECT_SCHEMA_NAME(fk.parent_object_id)")
+	query := fmt.Sprintf(`
 		SELECT 
+			OBJECT_SCHEMA_NAME(fk.parent_object_id) as from_schema,
 			OBJECT_NAME(fk.parent_object_id) as from_table,
 			COL_NAME(fkc.parent_object_id, fkc.parent_column_id) as from_column,
+			OBJECT_SCHEMA_NAME(fk.referenced_object_id) as to_schema,
 			OBJECT_NAME(fk.referenced_object_id) as to_table,
 			COL_NAME(fkc.referenced_object_id, fkc.referenced_column_id) as to_column
 		FROM sys.foreign_keys fk
 		JOIN sys.foreign_key_columns fkc ON fk.object_id = fkc.constraint_object_id
-	`
-	rows, err := a.db.Query(query)
+		WHERE 1 = 1 %s
+	`, filter)
+	rows, err := a.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -278,14 +435,57 @@ func (a *SQLServerAdapter) GetForeignKeys() ([]ForeignKey, error) {
 	var fks []ForeignKey
 	for rows.Next() {
 		var fk ForeignKey
-		if err := rows.Scan(&fk.FromTable, &fk.FromColumn, &fk.ToTable, &fk.ToColumn); err != nil {
+		var fromSchema, toSchema string
+		if err := rows.Scan(&fromSchema, &fk.FromTable, &fk.FromColumn, &toSchema, &fk.ToTable, &fk.ToColumn); err != nil {
 			return nil, err
 		}
+		fk.FromTable = QualifyName(fromSchema, fk.FromTable)
+		fk.ToTable = QualifyName(toSchema, fk.ToTable)
 		fks = append(fks, fk)
 	}
 	return fks, nil
 }
 
+// SampleValues 采样读取列的原始取值
+func (a *SQLServerAdapter) SampleValues(table, column string, sampleSize int) ([]sql.NullString, error) {
+	if err := a.guard(table, column, OpSampleValues, false); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+		SELECT TOP %d %s
+		FROM %s TABLESAMPLE (%d ROWS)%s
+	`, sampleSize, SQLServerDialect.Quote(column), a.table(table), sampleSize, a.hint)
+	
+	return scanValues(a.db, query)
+}
+
+// GetColumnRange 获取列的最小值和最大值
+func (a *SQLServerAdapter) GetColumnRange(table, column string) (min, max sql.NullString, err error) {
+	if err = a.guard(table, column, OpColumnRange, true); err != nil {
+		return
+	}
+	col := SQLServerDialect.Quote(column)
+	query := fmt.Sprintf(`SELECT MIN(%s), MAX(%s) FROM %s%s`, col, col, a.table(table), a.hint)
+	err = a.db.QueryRow(query).Scan(&min, &max)
+	return
+}
+
+// GetValuePairs 读取码表的 键→含义 对
+func (a *SQLServerAdapter) GetValuePairs(table, keyColumn, valueColumn string, limit int) ([]ValuePair, error) {
+	if err := a.guard(table, keyColumn, OpValuePairs, true); err != nil {
+		return nil, err
+	}
+	key := SQLServerDialect.Quote(keyColumn)
+	query := fmt.Sprintf(`
+		SELECT TOP %d %s, %s
+		FROM %s%s
+		WHERE %s IS NOT NULL
+		ORDER BY %s
+	`, limit, key, SQLServerDialect.Quote(valueColumn), a.table(table), a.hint, key, key)
+	
+	return scanValuePairs(a.db, query)
+}
+
 // Close 关闭连接
 func (a *SQLServerAdapter) Close() error {
 	return a.db.Close()
@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AuditLog 发给 AI 服务商的请求记录（JSON Lines，每次请求一行）。
+// 记录的是经过隐私策略处理、实际发出的消息，重试的每一次都会记录。
+// 可以由使用不同服务商的多个客户端共用，所有方法对 nil 安全
+type AuditLog struct {
+	mu    sync.Mutex
+	file  *os.File
+	path  string
+	count int
+}
+
+// auditEntry 审计日志的一行
+type auditEntry struct {
+	Time     time.Time `json:"time"`
+	Provider string    `json:"provider"`
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Bytes    int       `json:"bytes"` // 消息内容的字节数
+}
+
+// OpenAuditLog 打开审计日志，追加写入
+func OpenAuditLog(path string) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{file: file, path: path}, nil
+}
+
+// Path 日志文件路径
+func (a *AuditLog) Path() string {
+	if a == nil {
+		return ""
+	}
+	return a.path
+}
+
+// Count 本次运行记录的请求数
+func (a *AuditLog) Count() int {
+	if a == nil {
+		return 0
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.count
+}
+
+// Close 关闭日志文件
+func (a *AuditLog) Close() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
+
+// record 写入一次请求
+func (a *AuditLog) record(provider, model string, messages []Message) error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry := auditEntry{Time: time.Now(), Provider: provider, Model: model, Messages: messages}
+	for _, m := range messages {
+		entry.Bytes += len(m.Content)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := a.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	a.count++
+	return nil
+}
+
+// auditTransport 记录每次实际发出的请求，位于调用链最内层，紧挨服务商。
+// 服务商和模型随客户端记录，共用的审计日志中各条目标注的是实际发送的服务商
+type auditTransport struct {
+	next     Transport
+	log      *AuditLog
+	provider string
+	model    string
+}
+
+// Chat 先记录请求再发送。记录失败时不发送，避免出现审计日志之外的请求
+func (t *auditTransport) Chat(messages []Message) (string, error) {
+	if err := t.log.record(t.provider, t.model, messages); err != nil {
+		return "", fmt.Errorf("写入 AI 审计日志失败: %v", err)
+	}
+	return t.next.Chat(messages)
+}
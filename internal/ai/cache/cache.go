@@ -0,0 +1,224 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTTL 缓存条目的默认有效期
+const DefaultTTL = 30 * 24 * time.Hour
+
+// entry 缓存文件的内容
+type entry struct {
+	CreatedAt time.Time       `json:"created_at"`
+	Kind      string          `json:"kind"` // 调用类型，如 batch_explain
+	Value     json.RawMessage `json:"value"`
+}
+
+// Store AI 响应缓存：按内容寻址，每个条目一个文件（<dir>/<key 前两位>/<key>.json），
+// 可以在多次扫描、多个进程之间共享。所有方法对 nil 安全，未启用缓存时传 nil 即可
+type Store struct {
+	dir string
+	ttl time.Duration // 0 表示永不过期
+
+	mu     sync.Mutex
+	hits   int
+	misses int
+}
+
+// Stats 缓存目录的统计
+type Stats struct {
+	Entries int
+	Bytes   int64
+	Expired int
+	Kinds   map[string]int
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+// DefaultDir 默认缓存目录：用户缓存目录下的 schema-analyzer/ai
+func DefaultDir() string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(".schema-analyzer", "ai-cache")
+	}
+	return filepath.Join(base, "schema-analyzer", "ai")
+}
+
+// Open 打开（必要时创建）缓存目录
+func Open(dir string, ttl time.Duration) (*Store, error) {
+	if dir == "" {
+		dir = DefaultDir()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir, ttl: ttl}, nil
+}
+
+// Key 由多个部分生成缓存 key（服务商、模型、prompt 版本、输入内容等）
+func Key(parts ...string) string {
+	h := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(h[:])
+}
+
+// Dir 缓存目录
+func (s *Store) Dir() string {
+	if s == nil {
+		return ""
+	}
+	return s.dir
+}
+
+// Get 读取未过期的条目到 v，不存在或已过期时返回 false
+func (s *Store) Get(key string, v interface{}) bool {
+	if s == nil {
+		return false
+	}
+	ok := s.get(key, v)
+	s.mu.Lock()
+	if ok {
+		s.hits++
+	} else {
+		s.misses++
+	}
+	s.mu.Unlock()
+	return ok
+}
+
+func (s *Store) get(key string, v interface{}) bool {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return false
+	}
+	var e entry
+	if json.Unmarshal(data, &e) != nil || s.expired(e.CreatedAt) {
+		return false
+	}
+	return json.Unmarshal(e.Value, v) == nil
+}
+
+// Put 写入条目。先写临时文件再改名，并发写同一个 key 时不会读到半个文件
+func (s *Store) Put(key, kind string, v interface{}) error {
+	if s == nil {
+		return nil
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry{CreatedAt: time.Now(), Kind: kind, Value: value})
+	if err != nil {
+		return err
+	}
+
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Usage 本次运行的命中和未命中次数
+func (s *Store) Usage() (hits, misses int) {
+	if s == nil {
+		return 0, 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits, s.misses
+}
+
+// Stats 统计缓存目录中的条目
+func (s *Store) Stats() (*Stats, error) {
+	stats := &Stats{Kinds: make(map[string]int)}
+	err := s.walk(func(path string, info os.FileInfo, e *entry) error {
+		stats.Entries++
+		stats.Bytes += info.Size()
+		if e == nil {
+			stats.Expired++ // 损坏的条目按过期处理
+			return nil
+		}
+		if s.expired(e.CreatedAt) {
+			stats.Expired++
+		}
+		stats.Kinds[e.Kind]++
+		if stats.Oldest.IsZero() || e.CreatedAt.Before(stats.Oldest) {
+			stats.Oldest = e.CreatedAt
+		}
+		if e.CreatedAt.After(stats.Newest) {
+			stats.Newest = e.CreatedAt
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// Prune 删除过期和损坏的条目，all 为 true 时清空缓存。返回删除的条目数和释放的字节数
+func (s *Store) Prune(all bool) (removed int, freed int64, err error) {
+	err = s.walk(func(path string, info os.FileInfo, e *entry) error {
+		if !all && e != nil && !s.expired(e.CreatedAt) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+	return removed, freed, err
+}
+
+// walk 遍历缓存条目，无法解析的条目 e 为 nil
+func (s *Store) walk(fn func(path string, info os.FileInfo, e *entry) error) error {
+	if s == nil {
+		return nil
+	}
+	return filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var e entry
+		if json.Unmarshal(data, &e) != nil {
+			return fn(path, info, nil)
+		}
+		return fn(path, info, &e)
+	})
+}
+
+// path 条目的文件路径，按 key 前两位分目录避免单个目录文件过多
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key+".json")
+}
+
+// expired 条目是否已过期
+func (s *Store) expired(createdAt time.Time) bool {
+	return s.ttl > 0 && time.Since(createdAt) > s.ttl
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreGetPut(t *testing.T) {
+	store, err := Open(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := Key("dashscope", "qwen-plus", "v1", "Department.cDepCode")
+	var value map[string]string
+	if store.Get(key, &value) {
+		t.Fatal("expected miss on empty cache")
+	}
+	if err := store.Put(key, "explain_field", map[string]string{"chinese_name": "部门编码"}); err != nil {
+		t.Fatal(err)
+	}
+	if !store.Get(key, &value) || value["chinese_name"] != "部门编码" {
+		t.Errorf("expected hit, got %v", value)
+	}
+	if hits, misses := store.Usage(); hits != 1 || misses != 1 {
+		t.Errorf("expected 1 hit and 1 miss, got %d/%d", hits, misses)
+	}
+	if Key("dashscope", "qwen-max", "v1", "Department.cDepCode") == key {
+		t.Error("expected different models to produce different keys")
+	}
+
+	var disabled *Store
+	if disabled.Get(key, &value) || disabled.Put(key, "x", 1) != nil {
+		t.Error("nil store should be a no-op")
+	}
+}
+
+func TestStoreExpireAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh, stale := Key("fresh"), Key("stale")
+	store.Put(fresh, "batch_explain", 1)
+	store.Put(stale, "table_meaning", 2)
+
+	// 把一个条目改成两小时前写入
+	path := filepath.Join(dir, stale[:2], stale+".json")
+	data, _ := json.Marshal(entry{CreatedAt: time.Now().Add(-2 * time.Hour), Kind: "table_meaning", Value: json.RawMessage("2")})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var v int
+	if store.Get(stale, &v) {
+		t.Error("expected expired entry to miss")
+	}
+	stats, err := store.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 2 || stats.Expired != 1 || stats.Kinds["batch_explain"] != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	removed, _, err := store.Prune(false)
+	if err != nil || removed != 1 {
+		t.Fatalf("expected 1 expired entry removed, got %d, %v", removed, err)
+	}
+	if !store.Get(fresh, &v) || v != 1 {
+		t.Error("expected fresh entry to survive prune")
+	}
+	if removed, _, _ := store.Prune(true); removed != 1 {
+		t.Errorf("expected prune --all to remove the remaining entry, got %d", removed)
+	}
+}
@@ -0,0 +1,151 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/ai/cache"
+)
+
+// CachedClient 带持久缓存的客户端。缓存 key 由服务商、模型、prompt 版本和输入内容决定，
+// 任何一项变化都不会命中旧结果；调用失败的结果不缓存
+type CachedClient struct {
+	client Client
+	cache  *cache.Store
+	scope  []string // 服务商、模型、prompt 版本
+}
+
+// NewCachedClient 为 client 加上缓存
+func NewCachedClient(client Client, store *cache.Store, provider, model, promptVersion string) *CachedClient {
+	return &CachedClient{client: client, cache: store, scope: []string{provider, model, promptVersion}}
+}
+
+// ContextLength 底层客户端的上下文长度，没有限制时返回 0
+func (c *CachedClient) ContextLength() int {
+	if limited, ok := c.client.(interface{ ContextLength() int }); ok {
+		return limited.ContextLength()
+	}
+	return 0
+}
+
+// key 调用类型和输入内容对应的缓存 key
+func (c *CachedClient) key(kind string, input ...interface{}) string {
+	data, _ := json.Marshal(input)
+	parts := append([]string{}, c.scope...)
+	return cache.Key(append(parts, kind, string(data))...)
+}
+
+// put 写入缓存，失败只提示
+func (c *CachedClient) put(key, kind string, v interface{}) {
+	if err := c.cache.Put(key, kind, v); err != nil {
+		fmt.Printf("    [AI] 写入缓存失败: %v\n", err)
+	}
+}
+
+// ExplainStandardField 解释标准字段
+func (c *CachedClient) ExplainStandardField(tableName, columnName, dataType string) (*FieldExplanation, error) {
+	key := c.key("explain_field", tableName, columnName, dataType)
+	var explanation FieldExplanation
+	if c.cache.Get(key, &explanation) {
+		return &explanation, nil
+	}
+	result, err := c.client.ExplainStandardField(tableName, columnName, dataType)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, "explain_field", result)
+	return result, nil
+}
+
+// InferCustomField 推断自定义字段。prompt 中没有表名，同名字段和关联关系相同时共用缓存
+func (c *CachedClient) InferCustomField(tableName, columnName string, relatedFields []RelatedField) (*FieldExplanation, error) {
+	key := c.key("infer_custom_field", columnName, relatedFields)
+	var explanation FieldExplanation
+	if c.cache.Get(key, &explanation) {
+		explanation.TableName = tableName
+		return &explanation, nil
+	}
+	result, err := c.client.InferCustomField(tableName, columnName, relatedFields)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, "infer_custom_field", result)
+	return result, nil
+}
+
+// BatchExplain 批量解释。按字段缓存，批次组成变化（如调整了表过滤）时已解释过的字段仍能命中，
+// 只把未命中的字段发给模型
+func (c *CachedClient) BatchExplain(fields []FieldContext) (map[string]*FieldExplanation, error) {
+	result := make(map[string]*FieldExplanation)
+	keys := make(map[string]string)
+	var missing []FieldContext
+	for _, field := range fields {
+		// 统计比例和样例每次采样都有波动，key 只取证据中稳定的部分
+		stable := field
+		stable.Evidence = field.Evidence.stable()
+		key := c.key("batch_explain", stable)
+		var explanation FieldExplanation
+		if c.cache.Get(key, &explanation) {
+			result[FieldKey(field.TableName, field.ColumnName)] = &explanation
+			continue
+		}
+		keys[FieldKey(field.TableName, field.ColumnName)] = key
+		missing = append(missing, field)
+	}
+	if len(missing) == 0 {
+		fmt.Printf("    [AI] %d 个字段全部命中缓存\n", len(fields))
+		return result, nil
+	}
+
+	fresh, err := c.client.BatchExplain(missing)
+	if err != nil {
+		return nil, err
+	}
+	for fieldKey, explanation := range fresh {
+		if key, ok := keys[fieldKey]; ok {
+			c.put(key, "batch_explain", explanation)
+		}
+		result[fieldKey] = explanation
+	}
+	return result, nil
+}
+
+// AnalyzeTableMeaning 分析表的意义
+func (c *CachedClient) AnalyzeTableMeaning(tableName string, columns []adapter.Column) (*TableExplanation, error) {
+	key := c.key("table_meaning", tableName, columns)
+	var explanation TableExplanation
+	if c.cache.Get(key, &explanation) {
+		return &explanation, nil
+	}
+	result, err := c.client.AnalyzeTableMeaning(tableName, columns)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, "table_meaning", result)
+	return result, nil
+}
+
+// AnalyzeTableRelationships 分析表之间的关系
+func (c *CachedClient) AnalyzeTableRelationships(tables []adapter.Table) ([]TableRelationship, error) {
+	// 行数、修改时间等每次扫描都会变化，key 只取表名和列结构。
+	// 表名带 schema 前缀：结果中的表名带前缀，结构相同的其他 schema 不能共用
+	type tableShape struct {
+		Name    string
+		Columns []adapter.Column
+	}
+	shapes := make([]tableShape, len(tables))
+	for i, table := range tables {
+		shapes[i] = tableShape{Name: table.QualifiedName(), Columns: table.Columns}
+	}
+	key := c.key("table_relationships", shapes)
+	var relationships []TableRelationship
+	if c.cache.Get(key, &relationships) {
+		return relationships, nil
+	}
+	result, err := c.client.AnalyzeTableRelationships(tables)
+	if err != nil {
+		return result, err // 部分失败时结果不完整，不缓存
+	}
+	c.put(key, "table_relationships", result)
+	return result, nil
+}
@@ -0,0 +1,119 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/ai/cache"
+	"schema-analyzer/internal/ai/prompt"
+)
+
+func TestCachedClientBatchExplain(t *testing.T) {
+	store, err := cache.Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &scriptedTransport{responses: []string{
+		`[{"table_name": "Department", "column_name": "cDepCode", "chinese_name": "部门编码", "confidence": 0.9}]`,
+		`[{"table_name": "Person", "column_name": "cPersonCode", "chinese_name": "人员编码", "confidence": 0.9}]`,
+	}}
+	inner := NewPromptClient(transport, nil)
+	client := NewCachedClient(inner, store, ProviderDashScope, DefaultDashScopeModel, prompt.Default().Version())
+
+	department := FieldContext{TableName: "Department", ColumnName: "cDepCode", DataType: "varchar"}
+	person := FieldContext{TableName: "Person", ColumnName: "cPersonCode", DataType: "varchar"}
+	if _, err := client.BatchExplain([]FieldContext{department}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第二批只有未缓存的字段发给模型
+	explanations, err := client.BatchExplain([]FieldContext{department, person})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.requests) != 2 {
+		t.Fatalf("expected 2 model calls, got %d", len(transport.requests))
+	}
+	if prompt := transport.requests[1][1].Content; strings.Contains(prompt, "cDepCode") {
+		t.Errorf("expected cached field to be left out of the prompt:\n%s", prompt)
+	}
+	if explanations[FieldKey("Department", "cDepCode")] == nil || explanations[FieldKey("Person", "cPersonCode")] == nil {
+		t.Errorf("expected cached and fresh explanations to be merged, got %+v", explanations)
+	}
+
+	// 同一个缓存目录，prompt 版本不同时不命中
+	other := NewCachedClient(inner, store, ProviderDashScope, DefaultDashScopeModel, "other-version")
+	transport.responses = append(transport.responses, `[{"table_name": "Department", "column_name": "cDepCode", "chinese_name": "部门编码", "confidence": 0.9}]`)
+	if _, err := other.BatchExplain([]FieldContext{department}); err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.requests) != 3 {
+		t.Errorf("expected a different prompt version to miss the cache, got %d calls", len(transport.requests))
+	}
+}
+
+func TestBatchExplainEvidence(t *testing.T) {
+	store, err := cache.Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &scriptedTransport{responses: []string{
+		`[{"table_name": "Customer", "column_name": "cCusPhone", "chinese_name": "客户电话", "confidence": 0.9}]`,
+	}}
+	client := NewCachedClient(NewPromptClient(transport, nil), store, ProviderDashScope, DefaultDashScopeModel, prompt.Default().Version())
+
+	field := FieldContext{TableName: "Customer", ColumnName: "cCusPhone", DataType: "varchar", Evidence: &FieldEvidence{
+		SampleRows: 1000, NullRatio: 0.1, DistinctRate: 0.85,
+		Patterns:  []string{"numeric 75%", "text 25%"},
+		Relations: []RelatedColumn{{Table: "Dispatch", Column: "cPhone", Kind: "外键", Incoming: true}},
+	}}
+	if _, err := client.BatchExplain([]FieldContext{field}); err != nil {
+		t.Fatal(err)
+	}
+	request := transport.requests[0][1].Content
+	for _, want := range []string{"数据证据（Customer）", "空值 10%", "numeric 75%", "Dispatch.cPhone"} {
+		if !strings.Contains(request, want) {
+			t.Errorf("expected prompt to contain %q:\n%s", want, request)
+		}
+	}
+
+	// 重新采样后比例有波动，模式和关联不变时仍命中缓存
+	resampled := field
+	resampled.Evidence = &FieldEvidence{SampleRows: 1000, NullRatio: 0.12, DistinctRate: 0.83,
+		Patterns: []string{"numeric 70%", "text 30%"}, Relations: field.Evidence.Relations}
+	if _, err := client.BatchExplain([]FieldContext{resampled}); err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.requests) != 1 {
+		t.Errorf("expected resampled evidence to hit the cache, got %d calls", len(transport.requests))
+	}
+}
+
+func TestCachedClientRelationshipsPerSchema(t *testing.T) {
+	store, err := cache.Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &scriptedTransport{responses: []string{
+		`[{"from_table": "erp.Orders", "to_table": "erp.Customer", "relation_type": "one_to_many", "confidence": 0.9}]`,
+		`[{"from_table": "crm.Orders", "to_table": "crm.Customer", "relation_type": "one_to_many", "confidence": 0.9}]`,
+	}}
+	client := NewCachedClient(NewPromptClient(transport, nil), store, ProviderDashScope, DefaultDashScopeModel, prompt.Default().Version())
+
+	tables := func(schema string) []adapter.Table {
+		id := []adapter.Column{{Name: "ID", IsPrimaryKey: true}}
+		return []adapter.Table{{Schema: schema, Name: "Orders", Columns: id}, {Schema: schema, Name: "Customer", Columns: id}}
+	}
+	if _, err := client.AnalyzeTableRelationships(tables("erp")); err != nil {
+		t.Fatal(err)
+	}
+	// 结构相同的另一个 schema 不能命中缓存，否则得到的是 erp 的表名
+	relationships, err := client.AnalyzeTableRelationships(tables("crm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.requests) != 2 || len(relationships) != 1 || relationships[0].FromTable != "crm.Orders" {
+		t.Errorf("expected a separate request for crm, got %d requests and %+v", len(transport.requests), relationships)
+	}
+}
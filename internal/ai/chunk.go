@@ -0,0 +1,78 @@
+package ai
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultPromptTokens 单次请求 prompt 的默认 token 上限（本地模型按上下文长度缩小）
+const DefaultPromptTokens = 6000
+
+// tableModule 表所属的模块：表名中第一个下划线之前的前缀（如 SO_SOMain 属于 SO），没有前缀时为空
+func tableModule(name string) string {
+	if i := strings.Index(name, "_"); i > 0 {
+		return strings.ToUpper(name[:i])
+	}
+	return ""
+}
+
+// summaryTokens 一个表摘要在 prompt 中的 token 估算
+func summaryTokens(s tableSummary) int {
+	return estimateTokens(s.Name+strings.Join(s.PrimaryKeys, ", ")+strings.Join(s.Columns, ", ")) + 8
+}
+
+// relationshipChunks 把表按模块装箱，每组 prompt 的估算不超过 limit（overhead 为 prompt 固定部分）。
+// 同一模块的表尽量放在同一组，模块本身超出上限时才拆开
+func relationshipChunks(summaries []tableSummary, overhead, limit int) [][]tableSummary {
+	budget := limit - overhead
+	total := 0
+	for _, s := range summaries {
+		total += summaryTokens(s)
+	}
+	if total <= budget || len(summaries) <= 1 {
+		return [][]tableSummary{summaries}
+	}
+
+	// 按模块分组，模块按名称排序，组内保持原有顺序
+	modules := make(map[string][]tableSummary)
+	var names []string
+	for _, s := range summaries {
+		module := s.module
+		if _, ok := modules[module]; !ok {
+			names = append(names, module)
+		}
+		modules[module] = append(modules[module], s)
+	}
+	sort.Strings(names)
+
+	var chunks [][]tableSummary
+	var current []tableSummary
+	used := 0
+	flush := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, current)
+			current, used = nil, 0
+		}
+	}
+	for _, name := range names {
+		group := modules[name]
+		size := 0
+		for _, s := range group {
+			size += summaryTokens(s)
+		}
+		// 当前组放不下整个模块时另起一组
+		if used+size > budget && size <= budget {
+			flush()
+		}
+		for _, s := range group {
+			tokens := summaryTokens(s)
+			if used+tokens > budget {
+				flush()
+			}
+			current = append(current, s)
+			used += tokens
+		}
+	}
+	flush()
+	return chunks
+}
@@ -1,12 +1,12 @@
 package ai
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/ai/prompt"
+	"strings"
 )
 
 // Client AI 客户端接口
@@ -15,9 +15,9 @@ type Client interface {
 	ExplainStandardField(tableName, columnName, dataType string) (*FieldExplanation, error)
 	
 	// InferCustomField 推断自定义字段（基于关联关系）
-	InferCustomField(columnName string, relatedFields []RelatedField) (*FieldExplanation, error)
+	InferCustomField(tableName, columnName string, relatedFields []RelatedField) (*FieldExplanation, error)
 	
-	// BatchExplain 批量解释（提高效率）
+	// BatchExplain 批量解释（提高效率），结果以 FieldKey(表, 列) 为 key
 	BatchExplain(fields []FieldContext) (map[string]*FieldExplanation, error)
 	
 	// AnalyzeTableMeaning 分析表的意义
@@ -29,6 +29,7 @@ type Client interface {
 
 // FieldExplanation 字段解释
 type FieldExplanation struct {
+	TableName    string  `json:"table_name,omitempty"`
 	ColumnName   string  `json:"column_name"`
 	ChineseName  string  `json:"chinese_name"`   // 中文名称
 	Description  string  `json:"description"`    // 详细说明
@@ -37,12 +38,20 @@ type FieldExplanation struct {
 	Source       string  `json:"source"`         // 来源：ai_standard/ai_inferred/relation
 }
 
+// AllTables 批量解释中表示同名字段在所有表中含义相同的 table_name
+const AllTables = "*"
+
+// FieldKey 批量解释结果的 key：表.列
+func FieldKey(tableName, columnName string) string {
+	return tableName + "." + columnName
+}
+
 // FieldContext 字段上下文
 type FieldContext struct {
 	TableName  string
 	ColumnName string
 	DataType   string
-	Stats      *adapter.ColumnStats
+	Evidence   *FieldEvidence // 数据证据，nil 时只按字段名解释
 }
 
 // RelatedField 关联字段（用于推断自定义字段）
@@ -72,342 +81,467 @@ type TableRelationship struct {
 	Confidence     float64 `json:"confidence"`       // 置信度
 }
 
-// AlibabaClient 阿里云通义千问客户端
-type AlibabaClient struct {
-	apiKey    string
-	endpoint  string
-	model     string
-	httpClient *http.Client
+// Message 对话消息
+type Message struct {
+	Role    string `json:"role"` // system / user / assistant
+	Content string `json:"content"`
 }
 
-// NewAlibabaClient 创建阿里云 AI 客户端
-func NewAlibabaClient(apiKey string) *AlibabaClient {
-	return &AlibabaClient{
-		apiKey:   apiKey,
-		endpoint: "https://dashscope.aliyuncs.com/api/v1/services/aigc/text-generation/generation",
-		model:    "qwen-plus", // 或 qwen-turbo, qwen-max
-		httpClient: &http.Client{},
-	}
+// Transport 服务商的传输层：发送一组对话消息，返回模型输出的文本。
+// prompt 的构造和响应解析由 promptClient 统一完成，新增服务商只需实现 Transport
+type Transport interface {
+	Chat(messages []Message) (string, error)
 }
 
-// ExplainStandardField 解释 U8 标准字段
-func (c *AlibabaClient) ExplainStandardField(tableName, columnName, dataType string) (*FieldExplanation, error) {
-	// 构建 prompt
-	prompt := fmt.Sprintf(`你是用友 U8 ERP 系统的数据库专家。请解释以下字段：
-
-表名: %s
-字段名: %s
-数据类型: %s
-
-请以 JSON 格式返回：
-{
-  "chinese_name": "字段的中文名称（5字以内）",
-  "description": "字段的详细说明（20字以内）",
-  "business_meaning": "业务含义（30字以内）",
-  "confidence": 0.95
+// NewPromptClient 基于任意 Transport 创建 Client，prompts 为 nil 时使用内置模板和 U8 领域
+func NewPromptClient(transport Transport, prompts *prompt.Set) Client {
+	c := newPromptClient(transport)
+	c.SetPrompts(prompts)
+	return c
 }
 
-注意：
-1. 只返回 JSON，不要其他文字
-2. 如果不确定，confidence 设为 0.5 以下
-3. 基于 U8 标准字段命名规范`, tableName, columnName, dataType)
-
-	response, err := c.callAPI(prompt)
-	if err != nil {
-		return nil, err
-	}
+// promptClient 基于 Transport 实现 Client，各服务商共用同一套 prompt 和响应解析
+type promptClient struct {
+	transport Transport
+	prompts   *prompt.Set
+	maxPrompt int // 单次请求 prompt 的 token 上限，0 使用 DefaultPromptTokens
+}
 
-	var explanation FieldExplanation
-	if err := json.Unmarshal([]byte(response), &explanation); err != nil {
-		return nil, fmt.Errorf("解析 AI 响应失败: %v", err)
+// messagesLength 消息的总字符数
+func messagesLength(messages []Message) int {
+	n := 0
+	for _, m := range messages {
+		n += len([]rune(m.Content))
 	}
+	return n
+}
 
-	explanation.ColumnName = columnName
-	explanation.Source = "ai_standard"
-	
-	return &explanation, nil
+func newPromptClient(transport Transport) *promptClient {
+	return &promptClient{transport: transport, prompts: prompt.Default()}
 }
 
-// InferCustomField 推断自定义字段（cFree1-10, cDefine1-37）
-func (c *AlibabaClient) InferCustomField(columnName string, relatedFields []RelatedField) (*FieldExplanation, error) {
-	// 构建关联信息
-	relationsDesc := ""
-	for _, rf := range relatedFields {
-		relationsDesc += fmt.Sprintf("- 与 %s.%s (%s) 关联，置信度 %.2f\n", 
-			rf.TableName, rf.ColumnName, rf.ChineseName, rf.Confidence)
+// SetPrompts 设置 prompt 模板和业务领域，nil 时保持不变
+func (c *promptClient) SetPrompts(prompts *prompt.Set) {
+	if prompts != nil {
+		c.prompts = prompts
 	}
+}
 
-	prompt := fmt.Sprintf(`你是用友 U8 ERP 系统的数据库专家。
+// Prompts 当前使用的 prompt 模板
+func (c *promptClient) Prompts() *prompt.Set {
+	return c.prompts
+}
 
-字段 %s 是一个自定义字段（cFree 或 cDefine），需要基于关联关系推断其含义。
+// setTransport 替换传输层（如加上重试和限流）
+func (c *promptClient) setTransport(transport Transport) {
+	c.transport = transport
+}
 
-已知关联关系：
-%s
+// setPromptLimit 设置单次请求 prompt 的 token 上限
+func (c *promptClient) setPromptLimit(tokens int) {
+	c.maxPrompt = tokens
+}
 
-请基于这些关联关系，推断该字段的业务含义，以 JSON 格式返回：
-{
-  "chinese_name": "推断的中文名称（5字以内）",
-  "description": "推断的说明（20字以内）",
-  "business_meaning": "基于关联关系的业务含义（30字以内）",
-  "confidence": 0.75
+// promptLimit 单次请求 prompt 的 token 上限
+func (c *promptClient) promptLimit() int {
+	if c.maxPrompt > 0 {
+		return c.maxPrompt
+	}
+	return DefaultPromptTokens
 }
 
-注意：
-1. 只返回 JSON
-2. confidence 应该低于标准字段（0.5-0.8）
-3. 说明中要提到"基于关联推断"`, columnName, relationsDesc)
+// conversation 一次对话，保留历史消息，响应无效时可以带着校验错误重问
+type conversation struct {
+	client   *promptClient
+	messages []Message
+}
 
-	response, err := c.callAPI(prompt)
+// ask 渲染模板并调用模型，开始一次对话
+func (c *promptClient) ask(name string, data map[string]interface{}) (*conversation, string, error) {
+	text, err := c.prompts.Render(name, data)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-
-	var explanation FieldExplanation
-	if err := json.Unmarshal([]byte(response), &explanation); err != nil {
-		return nil, fmt.Errorf("解析 AI 响应失败: %v", err)
+	conv := &conversation{client: c, messages: []Message{
+		{Role: "system", Content: c.prompts.System()},
+		{Role: "user", Content: text},
+	}}
+	response, err := c.transport.Chat(conv.messages)
+	if err != nil {
+		return nil, "", err
 	}
-
-	explanation.ColumnName = columnName
-	explanation.Source = "ai_inferred"
-	
-	return &explanation, nil
+	conv.messages = append(conv.messages, Message{Role: "assistant", Content: response})
+	return conv, response, nil
 }
 
-// BatchExplain 批量解释（提高效率）
-func (c *AlibabaClient) BatchExplain(fields []FieldContext) (map[string]*FieldExplanation, error) {
-	// 构建批量 prompt
-	fieldsDesc := ""
-	for i, f := range fields {
-		fieldsDesc += fmt.Sprintf("%d. 表: %s, 字段: %s, 类型: %s\n", 
-			i+1, f.TableName, f.ColumnName, f.DataType)
+// repair 把校验发现的问题告诉模型，要求重新返回 expect 描述的内容
+func (conv *conversation) repair(problems []string, expect string) (string, error) {
+	fmt.Printf("    [AI] 响应有 %d 处问题，重新请求...\n", len(problems))
+	text, err := conv.client.prompts.Render(prompt.Repair, map[string]interface{}{
+		"Problems": problems,
+		"Expect":   expect,
+	})
+	if err != nil {
+		return "", err
 	}
+	conv.messages = append(conv.messages, Message{Role: "user", Content: text})
+	response, err := conv.client.transport.Chat(conv.messages)
+	if err != nil {
+		return "", err
+	}
+	conv.messages = append(conv.messages, Message{Role: "assistant", Content: response})
+	return response, nil
+}
 
-	prompt := fmt.Sprintf(`你是用友 U8 ERP 系统的数据库专家。请批量解释以下字段：
-
-%s
-
-请以 JSON 数组格式返回，每个字段一个对象：
-[
-  {
-    "column_name": "字段名",
-    "chinese_name": "中文名称",
-    "description": "说明",
-    "business_meaning": "业务含义",
-    "confidence": 0.95
-  }
-]
-
-只返回 JSON 数组，不要其他文字。`, fieldsDesc)
-
-	response, err := c.callAPI(prompt)
+// explainField 请求单个字段的解释，解析或校验失败时重问
+func (c *promptClient) explainField(name string, data map[string]interface{}, tableName, columnName, source string) (*FieldExplanation, error) {
+	conv, response, err := c.ask(name, data)
 	if err != nil {
 		return nil, err
 	}
 
-	var explanations []FieldExplanation
-	if err := json.Unmarshal([]byte(response), &explanations); err != nil {
-		return nil, fmt.Errorf("解析 AI 响应失败: %v", err)
+	for attempt := 0; ; attempt++ {
+		var explanation FieldExplanation
+		problem := decodeObject(response, &explanation)
+		if problem == nil {
+			problem = validateField(&explanation)
+		}
+		if problem == nil {
+			explanation.TableName = tableName
+			explanation.ColumnName = columnName
+			explanation.Source = source
+			return &explanation, nil
+		}
+		if attempt >= maxRepairs {
+			return nil, fmt.Errorf("解析 AI 响应失败: %v", problem)
+		}
+		if response, err = conv.repair([]string{problem.Error()}, "一个 JSON 对象"); err != nil {
+			return nil, err
+		}
 	}
+}
 
-	result := make(map[string]*FieldExplanation)
-	for i := range explanations {
-		explanations[i].Source = "ai_standard"
-		result[explanations[i].ColumnName] = &explanations[i]
-	}
+// ExplainStandardField 解释标准字段
+func (c *promptClient) ExplainStandardField(tableName, columnName, dataType string) (*FieldExplanation, error) {
+	return c.explainField(prompt.ExplainField, map[string]interface{}{
+		"Table":    tableName,
+		"Column":   columnName,
+		"DataType": dataType,
+	}, tableName, columnName, "ai_standard")
+}
 
-	return result, nil
+// InferCustomField 推断自定义字段（如 U8 的 cFree1-10, cDefine1-37）
+func (c *promptClient) InferCustomField(tableName, columnName string, relatedFields []RelatedField) (*FieldExplanation, error) {
+	return c.explainField(prompt.InferCustomField, map[string]interface{}{
+		"Column":  columnName,
+		"Related": relatedFields,
+	}, tableName, columnName, "ai_inferred")
 }
 
-// callAPI 调用阿里云 API
-func (c *AlibabaClient) callAPI(prompt string) (string, error) {
-	fmt.Printf("    [AI] 调用 API，prompt 长度: %d 字符...\n", len(prompt))
-	
-	requestBody := map[string]interface{}{
-		"model": c.model,
-		"input": map[string]interface{}{
-			"messages": []map[string]string{
-				{
-					"role":    "system",
-					"content": "你是用友 U8 ERP 系统的数据库专家，精通 U8 的表结构和字段命名规范。",
-				},
-				{
-					"role":    "user",
-					"content": prompt,
-				},
-			},
-		},
-		"parameters": map[string]interface{}{
-			"result_format": "message",
-		},
-	}
+// fieldGroup 批次中的同名字段，prompt 中只列出一次
+type fieldGroup struct {
+	ColumnName string
+	DataType   string // 各表类型不同时以 / 分隔
+	Tables     []string
+	Evidence   []tableEvidence // 有数据证据的表
+}
 
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", err
-	}
+// tableEvidence 同名字段在某个表中的数据证据
+type tableEvidence struct {
+	Table    string
+	Evidence *FieldEvidence
+}
 
-	req, err := http.NewRequest("POST", c.endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", err
+// groupFields 按列名分组，保持字段首次出现的顺序
+func groupFields(fields []FieldContext) []fieldGroup {
+	var groups []fieldGroup
+	index := make(map[string]int)
+	for _, field := range fields {
+		i, ok := index[field.ColumnName]
+		if !ok {
+			i = len(groups)
+			index[field.ColumnName] = i
+			groups = append(groups, fieldGroup{ColumnName: field.ColumnName, DataType: field.DataType})
+		} else if !containsString(strings.Split(groups[i].DataType, "/"), field.DataType) {
+			groups[i].DataType += "/" + field.DataType
+		}
+		groups[i].Tables = append(groups[i].Tables, field.TableName)
+		if field.Evidence != nil {
+			groups[i].Evidence = append(groups[i].Evidence, tableEvidence{Table: field.TableName, Evidence: field.Evidence})
+		}
 	}
+	return groups
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
-	fmt.Printf("    [AI] 发送请求...\n")
-	resp, err := c.httpClient.Do(req)
+// BatchExplain 批量解释（提高效率）。
+// 同名字段只列一次，模型返回通用解释（table_name 为 "*"），含义因表而异时再返回各表的解释。
+// 逐项校验，有效的字段直接采用；无效或缺少的字段带着校验错误重问，仍失败的字段不返回
+func (c *promptClient) BatchExplain(fields []FieldContext) (map[string]*FieldExplanation, error) {
+	conv, response, err := c.ask(prompt.BatchExplain, map[string]interface{}{
+		"Fields": fields,
+		"Groups": groupFields(fields),
+	})
 	if err != nil {
-		fmt.Printf("    [AI] 请求失败: %v\n", err)
-		return "", err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("    [AI] 读取响应失败: %v\n", err)
-		return "", err
+	tablesOf := make(map[string]map[string]bool) // 列 -> 所在表
+	for _, field := range fields {
+		if tablesOf[field.ColumnName] == nil {
+			tablesOf[field.ColumnName] = make(map[string]bool)
+		}
+		tablesOf[field.ColumnName][field.TableName] = true
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("    [AI] API 返回错误: %s, 响应: %s\n", resp.Status, string(body))
-		return "", fmt.Errorf("API 调用失败: %s, 响应: %s", resp.Status, string(body))
-	}
+	shared := make(map[string]*FieldExplanation)   // 列 -> 通用解释
+	specific := make(map[string]*FieldExplanation) // 表.列 -> 该表的解释
+	var result map[string]*FieldExplanation
+	var problems []string
+	for attempt := 0; ; attempt++ {
+		problems = problems[:0]
+		items, err := decodeArray(response)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		for i, item := range items {
+			var explanation FieldExplanation
+			if err := json.Unmarshal(item, &explanation); err != nil {
+				problems = append(problems, fmt.Sprintf("第 %d 项格式不符: %v", i+1, err))
+				continue
+			}
+			tables := tablesOf[explanation.ColumnName]
+			if tables == nil {
+				problems = append(problems, fmt.Sprintf("第 %d 项的字段 %q 不在请求中", i+1, explanation.ColumnName))
+				continue
+			}
+			// 未填写表名时视为通用解释
+			if explanation.TableName == "" {
+				explanation.TableName = AllTables
+			}
+			if explanation.TableName != AllTables && !tables[explanation.TableName] {
+				problems = append(problems, fmt.Sprintf("第 %d 项: 表 %q 中没有请求字段 %s", i+1, explanation.TableName, explanation.ColumnName))
+				continue
+			}
+			if err := validateField(&explanation); err != nil {
+				problems = append(problems, fmt.Sprintf("表 %s 字段 %s: %v", explanation.TableName, explanation.ColumnName, err))
+				continue
+			}
+			if explanation.TableName == AllTables {
+				shared[explanation.ColumnName] = &explanation
+			} else {
+				specific[FieldKey(explanation.TableName, explanation.ColumnName)] = &explanation
+			}
+		}
 
-	fmt.Printf("    [AI] 解析响应...\n")
-	// 解析响应
-	var apiResp struct {
-		Output struct {
-			Choices []struct {
-				Message struct {
-					Content string `json:"content"`
-				} `json:"message"`
-			} `json:"choices"`
-		} `json:"output"`
-	}
+		result = make(map[string]*FieldExplanation)
+		for _, field := range fields {
+			key := FieldKey(field.TableName, field.ColumnName)
+			exp := specific[key]
+			if exp == nil {
+				exp = shared[field.ColumnName]
+			}
+			if exp == nil {
+				problems = append(problems, fmt.Sprintf("缺少表 %s 的字段 %s", field.TableName, field.ColumnName))
+				continue
+			}
+			copied := *exp
+			copied.TableName = field.TableName
+			copied.Source = "ai_standard"
+			result[key] = &copied
+		}
 
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		fmt.Printf("    [AI] 解析响应失败: %v\n", err)
-		return "", fmt.Errorf("解析响应失败: %v", err)
+		if len(problems) == 0 || attempt >= maxRepairs {
+			break
+		}
+		if response, err = conv.repair(problems, "有问题或缺少的字段（JSON 数组，已正确的字段不用重复）"); err != nil {
+			break
+		}
 	}
 
-	if len(apiResp.Output.Choices) == 0 {
-		fmt.Printf("    [AI] API 返回空响应\n")
-		return "", fmt.Errorf("API 返回空响应")
+	if len(result) == 0 && len(problems) > 0 {
+		return nil, fmt.Errorf("解析 AI 响应失败: %s", strings.Join(problems, "; "))
 	}
-
-	fmt.Printf("    [AI] 成功获取响应，长度: %d 字符\n", len(apiResp.Output.Choices[0].Message.Content))
-	return apiResp.Output.Choices[0].Message.Content, nil
+	if len(problems) > 0 {
+		fmt.Printf("    [AI] %d/%d 个字段的解释无效，已跳过\n", len(fields)-len(result), len(fields))
+	}
+	return result, nil
 }
 
 // AnalyzeTableMeaning 分析表的意义
-func (c *AlibabaClient) AnalyzeTableMeaning(tableName string, columns []adapter.Column) (*TableExplanation, error) {
+func (c *promptClient) AnalyzeTableMeaning(tableName string, columns []adapter.Column) (*TableExplanation, error) {
 	fmt.Printf("    [AI] 分析表 %s 的意义...\n", tableName)
-	
-	// 构建列信息
-	columnsDesc := ""
-	for _, col := range columns {
-		pkMark := ""
-		if col.IsPrimaryKey {
-			pkMark = " [PK]"
-		}
-		columnsDesc += fmt.Sprintf("- %s%s: %s(%d)\n", col.Name, pkMark, col.DataType, col.Length)
-	}
-	
-	prompt := fmt.Sprintf(`你是数据库架构专家。请分析以下表的意义：
 
-表名: %s
-
-列结构:
-%s
-
-请以 JSON 格式返回：
-{
-  "chinese_name": "表的中文名称",
-  "description": "表的详细说明",
-  "business_meaning": "表的业务含义",
-  "confidence": 0.95
-}
-
-注意：
-1. 只返回 JSON，不要其他文字
-2. 基于表名和列结构推断表的用途`, tableName, columnsDesc)
-
-	response, err := c.callAPI(prompt)
+	conv, response, err := c.ask(prompt.TableMeaning, map[string]interface{}{
+		"Table":   tableName,
+		"Columns": columns,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var explanation TableExplanation
-	if err := json.Unmarshal([]byte(response), &explanation); err != nil {
-		return nil, fmt.Errorf("解析 AI 响应失败: %v", err)
+	for attempt := 0; ; attempt++ {
+		var explanation TableExplanation
+		problem := decodeObject(response, &explanation)
+		if problem == nil {
+			problem = validateTable(&explanation)
+		}
+		if problem == nil {
+			explanation.TableName = tableName
+			return &explanation, nil
+		}
+		if attempt >= maxRepairs {
+			return nil, fmt.Errorf("解析 AI 响应失败: %v", problem)
+		}
+		if response, err = conv.repair([]string{problem.Error()}, "一个 JSON 对象"); err != nil {
+			return nil, err
+		}
 	}
+}
 
-	explanation.TableName = tableName
-	return &explanation, nil
+// tableSummary 表关系分析中每个表的摘要：主键和前 5 个非主键列。
+// Name 为带 schema 前缀的表名，AI 返回的关系与图中的表节点一致
+type tableSummary struct {
+	Name        string
+	PrimaryKeys []string
+	Columns     []string
+
+	module string // 所属模块，用于分组
 }
 
-// AnalyzeTableRelationships 分析表之间的关系
-func (c *AlibabaClient) AnalyzeTableRelationships(tables []adapter.Table) ([]TableRelationship, error) {
+// AnalyzeTableRelationships 分析表之间的关系。prompt 超出 token 上限时按模块（表名前缀）分组分析，
+// 跨组的关系不会被发现
+func (c *promptClient) AnalyzeTableRelationships(tables []adapter.Table) ([]TableRelationship, error) {
 	fmt.Printf("    [AI] 分析 %d 个表之间的关系...\n", len(tables))
-	
-	// 构建表信息
-	tablesDesc := ""
+
+	summaries := make([]tableSummary, 0, len(tables))
 	for _, table := range tables {
-		tablesDesc += fmt.Sprintf("表: %s\n", table.Name)
-		
-		// 列出主键
-		pkColumns := []string{}
+		summary := tableSummary{Name: table.QualifiedName(), module: tableModule(table.Name)}
 		for _, col := range table.Columns {
 			if col.IsPrimaryKey {
-				pkColumns = append(pkColumns, col.Name)
+				summary.PrimaryKeys = append(summary.PrimaryKeys, col.Name)
+			} else if len(summary.Columns) < 5 {
+				summary.Columns = append(summary.Columns, col.Name)
 			}
 		}
-		if len(pkColumns) > 0 {
-			tablesDesc += fmt.Sprintf("  主键: %s\n", fmt.Sprintf("%v", pkColumns))
-		}
-		
-		// 列出一些重要列（前5个非主键列）
-		otherColumns := []string{}
-		for _, col := range table.Columns {
-			if !col.IsPrimaryKey && len(otherColumns) < 5 {
-				otherColumns = append(otherColumns, col.Name)
+		summaries = append(summaries, summary)
+	}
+
+	overhead := estimateTokens(c.prompts.System())
+	if empty, err := c.prompts.Render(prompt.TableRelationships, map[string]interface{}{"Tables": []tableSummary{}}); err == nil {
+		overhead += estimateTokens(empty)
+	}
+	chunks := relationshipChunks(summaries, overhead, c.promptLimit())
+	if len(chunks) == 1 {
+		return c.relationshipsOf(summaries)
+	}
+
+	fmt.Printf("    [AI] 表较多，按模块分 %d 组分析关系\n", len(chunks))
+	var relationships []TableRelationship
+	seen := make(map[string]bool)
+	var lastErr error
+	failed := 0
+	for i, chunk := range chunks {
+		result, err := c.relationshipsOf(chunk)
+		if err != nil {
+			fmt.Printf("    [AI] 第 %d/%d 组关系分析失败: %v\n", i+1, len(chunks), err)
+			lastErr = err
+			if errors.Is(err, ErrBudgetExceeded) {
+				failed += len(chunks) - i // 剩下的组不再发送
+				break
 			}
+			failed++
+			continue
 		}
-		if len(otherColumns) > 0 {
-			tablesDesc += fmt.Sprintf("  重要列: %s\n", fmt.Sprintf("%v", otherColumns))
+		for _, rel := range result {
+			key := rel.FromTable + "->" + rel.ToTable
+			if !seen[key] {
+				seen[key] = true
+				relationships = append(relationships, rel)
+			}
 		}
-		tablesDesc += "\n"
 	}
-	
-	prompt := fmt.Sprintf(`你是数据库架构专家。请分析以下表之间的关系：
-
-%s
-
-请基于表名和列结构推断表之间的关系，以 JSON 数组格式返回：
-[
-  {
-    "from_table": "表名1",
-    "to_table": "表名2", 
-    "relation_type": "one_to_many/many_to_many/one_to_one",
-    "description": "关系描述",
-    "confidence": 0.85
-  }
-]
-
-注意：
-1. 只返回 JSON 数组，不要其他文字
-2. 基于命名相似度和列结构推断关系
-3. 如果没有明显关系，返回空数组`, tablesDesc)
-
-	response, err := c.callAPI(prompt)
+	if lastErr == nil {
+		return relationships, nil
+	}
+	if len(relationships) == 0 {
+		return nil, lastErr
+	}
+	return relationships, &PartialError{Failed: failed, Total: len(chunks), Err: lastErr}
+}
+
+// PartialError 分组请求中有组失败。同时返回的结果只覆盖成功的组，可以使用，但不能当作完整结果缓存或保存
+type PartialError struct {
+	Failed int   // 失败或未发送的组数
+	Total  int   // 总组数
+	Err    error // 最后一次失败的错误
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%d/%d 组失败: %v", e.Failed, e.Total, e.Err)
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// relationshipsOf 一次请求分析一组表之间的关系，无效的关系丢弃，有问题时重问
+func (c *promptClient) relationshipsOf(summaries []tableSummary) ([]TableRelationship, error) {
+	names := make(map[string]bool, len(summaries))
+	for _, summary := range summaries {
+		names[summary.Name] = true
+	}
+
+	conv, response, err := c.ask(prompt.TableRelationships, map[string]interface{}{
+		"Tables": summaries,
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	var relationships []TableRelationship
-	if err := json.Unmarshal([]byte(response), &relationships); err != nil {
-		return nil, fmt.Errorf("解析 AI 响应失败: %v", err)
+	for attempt := 0; ; attempt++ {
+		var problems []string
+		items, err := decodeArray(response)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		for i, item := range items {
+			var rel TableRelationship
+			if err := json.Unmarshal(item, &rel); err != nil {
+				problems = append(problems, fmt.Sprintf("第 %d 项格式不符: %v", i+1, err))
+				continue
+			}
+			if err := validateRelationship(&rel, names); err != nil {
+				problems = append(problems, fmt.Sprintf("第 %d 项: %v", i+1, err))
+				continue
+			}
+			relationships = append(relationships, rel)
+		}
+
+		if len(problems) == 0 {
+			return relationships, nil
+		}
+		if attempt >= maxRepairs {
+			if len(relationships) == 0 {
+				return nil, fmt.Errorf("解析 AI 响应失败: %s", strings.Join(problems, "; "))
+			}
+			return relationships, nil
+		}
+		if response, err = conv.repair(problems, "有问题的关系（JSON 数组，已正确的关系不用重复，无法修正的可以省略）"); err != nil {
+			if len(relationships) == 0 {
+				return nil, err
+			}
+			return relationships, nil
+		}
 	}
+}
 
-	return relationships, nil
+// containsString 切片中是否包含 s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
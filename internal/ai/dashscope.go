@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// AlibabaClient 阿里云通义千问客户端
+type AlibabaClient struct {
+	*promptClient
+	apiKey     string
+	endpoint   string
+	model      string
+	httpClient *http.Client
+}
+
+// NewAlibabaClient 创建阿里云 AI 客户端
+func NewAlibabaClient(apiKey string) *AlibabaClient {
+	c := &AlibabaClient{
+		apiKey:     apiKey,
+		endpoint:   "https://dashscope.aliyuncs.com/api/v1/services/aigc/text-generation/generation",
+		model:      DefaultDashScopeModel,
+		httpClient: &http.Client{},
+	}
+	c.promptClient = newPromptClient(c)
+	return c
+}
+
+// SetModel 设置模型（如 qwen-turbo、qwen-max）
+func (c *AlibabaClient) SetModel(model string) {
+	c.model = model
+}
+
+// SetTimeout 设置单次请求的超时
+func (c *AlibabaClient) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// Model 使用的模型
+func (c *AlibabaClient) Model() string {
+	return c.model
+}
+
+// Chat 调用阿里云 API
+func (c *AlibabaClient) Chat(messages []Message) (string, error) {
+	fmt.Printf("    [AI] 调用 API，prompt 长度: %d 字符...\n", messagesLength(messages))
+
+	requestBody := map[string]interface{}{
+		"model": c.model,
+		"input": map[string]interface{}{
+			"messages": messages,
+		},
+		"parameters": map[string]interface{}{
+			"result_format": "message",
+		},
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", c.endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	fmt.Printf("    [AI] 发送请求...\n")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		fmt.Printf("    [AI] 请求失败: %v\n", err)
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("    [AI] 读取响应失败: %v\n", err)
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("    [AI] API 返回错误: %s, 响应: %s\n", resp.Status, string(body))
+		return "", newAPIError(resp, "响应: "+string(body))
+	}
+
+	fmt.Printf("    [AI] 解析响应...\n")
+	// 解析响应
+	var apiResp struct {
+		Output struct {
+			Choices []struct {
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+			} `json:"choices"`
+		} `json:"output"`
+	}
+
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		fmt.Printf("    [AI] 解析响应失败: %v\n", err)
+		return "", fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	if len(apiResp.Output.Choices) == 0 {
+		fmt.Printf("    [AI] API 返回空响应\n")
+		return "", fmt.Errorf("API 返回空响应")
+	}
+
+	fmt.Printf("    [AI] 成功获取响应，长度: %d 字符\n", len(apiResp.Output.Choices[0].Message.Content))
+	return apiResp.Output.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,89 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldEvidence 字段的数据证据：采样统计、取值模式和已推断的关联关系。
+// Samples 是原始取值，由 PrivateClient 按隐私策略处理后才会进入 prompt
+type FieldEvidence struct {
+	SampleRows   int64           `json:"sample_rows"`
+	NullRatio    float64         `json:"null_ratio"`
+	DistinctRate float64         `json:"distinct_rate"`
+	Patterns     []string        `json:"patterns,omitempty"` // 高频取值的模式及占比，如 "numeric 80%"
+	MinLength    int             `json:"min_length,omitempty"`
+	MaxLength    int             `json:"max_length,omitempty"`
+	Samples      []string        `json:"samples,omitempty"`   // 高频取值
+	Sensitive    []string        `json:"sensitive,omitempty"` // 样例中识别到的个人信息类型，此时不发送样例
+	Relations    []RelatedColumn `json:"relations,omitempty"`
+}
+
+// RelatedColumn 字段参与的一条关系
+type RelatedColumn struct {
+	Table    string `json:"table"`
+	Column   string `json:"column"`
+	Kind     string `json:"kind"`               // 外键 / 码表 / 推断
+	Incoming bool   `json:"incoming,omitempty"` // true 表示被对方引用
+}
+
+// String 关系说明，如 "引用 dbo.Customer.cCusCode（外键）"
+func (r RelatedColumn) String() string {
+	if r.Incoming {
+		return fmt.Sprintf("被 %s.%s 引用（%s）", r.Table, r.Column, r.Kind)
+	}
+	return fmt.Sprintf("引用 %s.%s（%s）", r.Table, r.Column, r.Kind)
+}
+
+// Summary 一行证据摘要，用于 prompt
+func (e *FieldEvidence) Summary() string {
+	if e == nil {
+		return ""
+	}
+	var parts []string
+	if e.SampleRows > 0 {
+		parts = append(parts, fmt.Sprintf("采样 %d 行，空值 %.0f%%，不同值 %.0f%%", e.SampleRows, e.NullRatio*100, e.DistinctRate*100))
+	}
+	if len(e.Patterns) > 0 {
+		parts = append(parts, "取值模式 "+strings.Join(e.Patterns, "、"))
+	}
+	if e.MaxLength > 0 {
+		if e.MinLength == e.MaxLength {
+			parts = append(parts, fmt.Sprintf("长度 %d", e.MaxLength))
+		} else {
+			parts = append(parts, fmt.Sprintf("长度 %d-%d", e.MinLength, e.MaxLength))
+		}
+	}
+	if len(e.Samples) > 0 {
+		parts = append(parts, "样例（已脱敏）"+strings.Join(e.Samples, ", "))
+	}
+	if len(e.Sensitive) > 0 {
+		parts = append(parts, "取值含"+strings.Join(e.Sensitive, "、"))
+	}
+	for _, r := range e.Relations {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, "；")
+}
+
+// stable 去掉每次采样都会波动的比例和样例，只保留模式和关联，用于缓存 key
+func (e *FieldEvidence) stable() *FieldEvidence {
+	if e == nil {
+		return nil
+	}
+	return &FieldEvidence{Patterns: patternNames(e.Patterns), Sensitive: e.Sensitive, Relations: e.Relations}
+}
+
+// patternNames 去掉模式的占比，如 "numeric 80%" -> "numeric"
+func patternNames(patterns []string) []string {
+	if len(patterns) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		if fields := strings.Fields(p); len(fields) > 0 {
+			names = append(names, fields[0])
+		}
+	}
+	return names
+}
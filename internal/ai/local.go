@@ -0,0 +1,232 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 本地模型服务的默认值
+const (
+	DefaultOllamaURL    = "http://localhost:11434"
+	DefaultOllamaModel  = "qwen2.5:7b"
+	DefaultLlamaCppURL  = "http://localhost:8080"
+	DefaultLocalContext = 8192 // 未指定时请求的上下文长度（不超过模型支持的最大值）
+
+	// outputReserve 为模型输出预留的 token 数
+	outputReserve = 1024
+)
+
+// LocalClient 本地模型客户端（Ollama 或 llama.cpp server），数据不离开内网。
+// 请求使用 JSON 输出模式，发送前检查 prompt 是否超出上下文长度，避免服务端静默截断
+type LocalClient struct {
+	*promptClient
+	kind          string // ollama / llamacpp
+	baseURL       string
+	model         string
+	contextLength int // 请求使用的上下文长度
+	httpClient    *http.Client
+	chat          *OpenAIClient // llama.cpp 使用其 OpenAI 兼容接口
+}
+
+// NewLocalClient 创建本地模型客户端并探测模型的上下文长度。
+// contextLength 为 0 时取模型支持的最大值与 DefaultLocalContext 中较小的一个
+func NewLocalClient(kind, baseURL, model string, contextLength int) (*LocalClient, error) {
+	c := &LocalClient{
+		kind:       kind,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		httpClient: &http.Client{},
+	}
+	c.promptClient = newPromptClient(c)
+
+	var maxContext int
+	var err error
+	switch kind {
+	case ProviderOllama:
+		if c.baseURL == "" {
+			c.baseURL = DefaultOllamaURL
+		}
+		if c.model == "" {
+			c.model = DefaultOllamaModel
+		}
+		maxContext, err = c.ollamaContextLength()
+	case ProviderLlamaCpp:
+		if c.baseURL == "" {
+			c.baseURL = DefaultLlamaCppURL
+		}
+		// llama.cpp 启动时加载一个模型，model 字段只用于日志
+		c.chat = NewOpenAIClient(c.baseURL+"/v1", "", c.model)
+		c.chat.jsonMode = true
+		maxContext, err = c.llamaCppContextLength()
+	default:
+		return nil, fmt.Errorf("不支持的本地模型服务: %s", kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("连接本地模型服务 %s 失败: %v", c.baseURL, err)
+	}
+
+	c.contextLength = contextLength
+	if c.contextLength <= 0 {
+		c.contextLength = DefaultLocalContext
+	}
+	// llama.cpp 的上下文长度在启动时固定；Ollama 不能超过模型训练时的长度
+	if maxContext > 0 && (c.contextLength > maxContext || kind == ProviderLlamaCpp) {
+		c.contextLength = maxContext
+	}
+	return c, nil
+}
+
+// ContextLength 请求使用的上下文长度（token）
+func (c *LocalClient) ContextLength() int {
+	return c.contextLength
+}
+
+// ollamaContextLength 从 /api/show 读取模型的最大上下文长度（model_info 中的 <架构>.context_length）
+func (c *LocalClient) ollamaContextLength() (int, error) {
+	var show struct {
+		ModelInfo map[string]interface{} `json:"model_info"`
+	}
+	status, err := c.postJSON(c.baseURL+"/api/show", map[string]string{"model": c.model, "name": c.model}, &show, 30*time.Second)
+	if status == http.StatusNotFound {
+		return 0, fmt.Errorf("模型 %s 不存在，请先执行 ollama pull %s", c.model, c.model)
+	}
+	if err != nil {
+		return 0, err
+	}
+	for key, value := range show.ModelInfo {
+		if n, ok := value.(float64); ok && strings.HasSuffix(key, ".context_length") {
+			return int(n), nil
+		}
+	}
+	return 0, nil
+}
+
+// llamaCppContextLength 从 /props 读取服务启动时设置的上下文长度
+func (c *LocalClient) llamaCppContextLength() (int, error) {
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Get(c.baseURL + "/props")
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// 旧版本没有 /props，不影响使用
+		return 0, nil
+	}
+	var props struct {
+		NCtx     int `json:"n_ctx"`
+		Settings struct {
+			NCtx int `json:"n_ctx"`
+		} `json:"default_generation_settings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&props); err != nil {
+		return 0, nil
+	}
+	if props.Settings.NCtx > 0 {
+		return props.Settings.NCtx, nil
+	}
+	return props.NCtx, nil
+}
+
+// SetTimeout 设置单次请求的超时（本地模型生成较慢，可适当调大）
+func (c *LocalClient) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+	if c.chat != nil {
+		c.chat.SetTimeout(timeout)
+	}
+}
+
+// Model 使用的模型
+func (c *LocalClient) Model() string {
+	return c.model
+}
+
+// Chat 调用本地模型
+func (c *LocalClient) Chat(messages []Message) (string, error) {
+	tokens := 0
+	for _, m := range messages {
+		tokens += estimateTokens(m.Content)
+	}
+	if tokens+outputReserve > c.contextLength {
+		return "", fmt.Errorf("prompt 约 %d tokens，超出上下文长度 %d（可用 --ai-context 调大或减少批次大小）", tokens, c.contextLength)
+	}
+
+	var content string
+	if c.kind == ProviderLlamaCpp {
+		var err error
+		if content, err = c.chat.Chat(messages); err != nil {
+			return "", err
+		}
+	} else {
+		fmt.Printf("    [AI] 调用本地模型 %s，prompt 约 %d tokens...\n", c.model, tokens)
+		requestBody := map[string]interface{}{
+			"model":    c.model,
+			"messages": messages,
+			"stream":   false,
+			"format":   "json",
+			"options":  map[string]interface{}{"num_ctx": c.contextLength},
+		}
+		var chatResp struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		}
+		if _, err := c.postJSON(c.baseURL+"/api/chat", requestBody, &chatResp, 0); err != nil {
+			return "", err
+		}
+		content = chatResp.Message.Content
+	}
+	return content, nil
+}
+
+// postJSON 发送 JSON 请求并解析响应，返回 HTTP 状态码
+func (c *LocalClient) postJSON(url string, body, out interface{}, timeout time.Duration) (int, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+	client := c.httpClient
+	if timeout > 0 {
+		client = &http.Client{Timeout: timeout}
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error != "" {
+			return resp.StatusCode, newAPIError(resp, apiErr.Error)
+		}
+		return resp.StatusCode, newAPIError(resp, "响应: "+string(raw))
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("解析响应失败: %v", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// estimateTokens 粗略估算 token 数：ASCII 约 4 个字符一个 token，汉字等约 1 个字符一个 token
+func estimateTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < 128 {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return ascii/4 + other + 1
+}
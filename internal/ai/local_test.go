@@ -0,0 +1,73 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLocalClientOllama(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/show":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"model_info": map[string]interface{}{"general.architecture": "qwen2", "qwen2.context_length": 4096},
+			})
+		case "/api/chat":
+			var req struct {
+				Format  string                 `json:"format"`
+				Stream  bool                   `json:"stream"`
+				Options map[string]interface{} `json:"options"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Format != "json" || req.Stream || req.Options["num_ctx"] != float64(4096) {
+				t.Errorf("unexpected chat request %+v", req)
+			}
+			// JSON 模式下模型把数组包在对象里返回
+			content := `{"fields": [{"column_name": "cDepCode", "chinese_name": "部门编码", "confidence": 0.9}]}`
+			json.NewEncoder(w).Encode(map[string]interface{}{"message": map[string]string{"role": "assistant", "content": content}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{Provider: ProviderOllama, BaseURL: server.URL, Model: "qwen2.5:7b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := client.(*PrivateClient).client.(*LocalClient)
+	if local.ContextLength() != 4096 {
+		t.Errorf("expected context length capped at model maximum 4096, got %d", local.ContextLength())
+	}
+	if size := BatchSize(client, 50); size >= 50 || size < minBatchSize {
+		t.Errorf("expected a smaller batch for a 4096-token context, got %d", size)
+	}
+
+	explanations, err := client.BatchExplain([]FieldContext{{TableName: "Department", ColumnName: "cDepCode", DataType: "varchar"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := explanations[FieldKey("Department", "cDepCode")]; exp == nil || exp.ChineseName != "部门编码" {
+		t.Errorf("unexpected explanations %+v", explanations)
+	}
+
+	if _, err := local.Chat([]Message{{Role: "user", Content: strings.Repeat("字段", 4096)}}); err == nil {
+		t.Error("expected prompt exceeding the context length to be rejected")
+	}
+}
+
+func TestLocalClientMissingModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "model 'llama3' not found"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(Config{Provider: ProviderOllama, BaseURL: server.URL, Model: "llama3"})
+	if err == nil || !strings.Contains(err.Error(), "ollama pull llama3") {
+		t.Errorf("expected a hint to pull the model, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIClient OpenAI 兼容接口（/v1/chat/completions）客户端，
+// 适用于 OpenAI、Azure OpenAI 网关、DeepSeek、vLLM、One API 等兼容该协议的服务
+type OpenAIClient struct {
+	*promptClient
+	apiKey     string
+	baseURL    string
+	model      string
+	jsonMode   bool // 请求 JSON 输出（response_format: json_object）
+	httpClient *http.Client
+}
+
+// NewOpenAIClient 创建 OpenAI 兼容客户端。baseURL 为包含版本号的接口地址（如 https://api.openai.com/v1），
+// apiKey 为空时不发送 Authorization 头（内网网关可能不需要）
+func NewOpenAIClient(baseURL, apiKey, model string) *OpenAIClient {
+	c := &OpenAIClient{
+		apiKey:     apiKey,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		httpClient: &http.Client{},
+	}
+	c.promptClient = newPromptClient(c)
+	return c
+}
+
+// SetTimeout 设置单次请求的超时
+func (c *OpenAIClient) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// Model 使用的模型
+func (c *OpenAIClient) Model() string {
+	return c.model
+}
+
+// Chat 调用 chat/completions 接口
+func (c *OpenAIClient) Chat(messages []Message) (string, error) {
+	fmt.Printf("    [AI] 调用 %s，prompt 长度: %d 字符...\n", c.model, messagesLength(messages))
+
+	requestBody := map[string]interface{}{
+		"model":    c.model,
+		"messages": messages,
+	}
+	if c.jsonMode {
+		requestBody["response_format"] = map[string]string{"type": "json_object"}
+	}
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var apiResp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		if json.Unmarshal(body, &apiResp) == nil && apiResp.Error != nil {
+			return "", newAPIError(resp, apiResp.Error.Message)
+		}
+		return "", newAPIError(resp, "响应: "+string(body))
+	}
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return "", fmt.Errorf("解析响应失败: %v", err)
+	}
+	if len(apiResp.Choices) == 0 {
+		return "", fmt.Errorf("API 返回空响应")
+	}
+	return apiResp.Choices[0].Message.Content, nil
+}
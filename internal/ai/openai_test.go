@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+		var req struct {
+			Model    string              `json:"model"`
+			Messages []map[string]string `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Model != "deepseek-chat" || len(req.Messages) != 2 {
+			t.Errorf("unexpected request %+v", req)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": `{"chinese_name": "部门编码", "confidence": 0.9}`}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{Provider: ProviderOpenAI, BaseURL: server.URL + "/v1/", Model: "deepseek-chat", APIKey: "sk-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	explanation, err := client.ExplainStandardField("Department", "cDepCode", "varchar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explanation.ChineseName != "部门编码" || explanation.ColumnName != "cDepCode" {
+		t.Errorf("unexpected explanation %+v", explanation)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	if _, err := NewClient(Config{}); err != ErrMissingAPIKey {
+		t.Errorf("dashscope without key: expected ErrMissingAPIKey, got %v", err)
+	}
+	if _, err := NewClient(Config{Provider: ProviderOpenAI}); err != ErrMissingAPIKey {
+		t.Errorf("official OpenAI endpoint without key: expected ErrMissingAPIKey, got %v", err)
+	}
+	if _, err := NewClient(Config{Provider: ProviderOpenAI, BaseURL: "http://gateway.local/v1"}); err != nil {
+		t.Errorf("self-hosted gateway should not require a key, got %v", err)
+	}
+	if _, err := NewClient(Config{Provider: "unknown", APIKey: "x"}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
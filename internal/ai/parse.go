@@ -0,0 +1,151 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxRepairs 响应无效时带着校验错误重问的最大次数
+const maxRepairs = 1
+
+// 表关系的合法类型
+var relationTypes = map[string]bool{
+	"one_to_many":  true,
+	"many_to_one":  true,
+	"many_to_many": true,
+	"one_to_one":   true,
+}
+
+// extractJSON 从模型输出中取出第一个完整的 JSON 值。
+// 容忍 ```json 代码块、前后的说明文字，以及 JSON 之后的多余内容
+func extractJSON(text string) (json.RawMessage, error) {
+	text = stripFences(text)
+	for i := 0; i < len(text); i++ {
+		if text[i] != '{' && text[i] != '[' {
+			continue
+		}
+		var raw json.RawMessage
+		if err := json.NewDecoder(strings.NewReader(text[i:])).Decode(&raw); err == nil {
+			return raw, nil
+		}
+	}
+	return nil, fmt.Errorf("响应中没有有效的 JSON: %s", abbreviate(text, 200))
+}
+
+// stripFences 有 ``` 代码块时只保留第一个代码块的内容
+func stripFences(text string) string {
+	start := strings.Index(text, "```")
+	if start < 0 {
+		return text
+	}
+	body := text[start+3:]
+	// 跳过语言标记（如 json）
+	if nl := strings.IndexByte(body, '\n'); nl >= 0 && !strings.ContainsAny(body[:nl], "{[") {
+		body = body[nl+1:]
+	}
+	if end := strings.Index(body, "```"); end >= 0 {
+		body = body[:end]
+	}
+	return body
+}
+
+// decodeObject 解析 JSON 对象
+func decodeObject(text string, out interface{}) error {
+	raw, err := extractJSON(text)
+	if err != nil {
+		return err
+	}
+	if raw[0] != '{' {
+		return fmt.Errorf("应返回 JSON 对象，实际返回: %s", abbreviate(string(raw), 200))
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("JSON 格式不符: %v", err)
+	}
+	return nil
+}
+
+// decodeArray 解析 JSON 数组，返回各元素的原始 JSON，由调用方逐项解析和校验。
+// 模型把数组包在对象里（如 {"fields": [...]}）或只返回一个对象时也能处理
+func decodeArray(text string) ([]json.RawMessage, error) {
+	raw, err := extractJSON(text)
+	if err != nil {
+		return nil, err
+	}
+	if raw[0] == '{' {
+		raw = unwrapArray(raw)
+		if raw[0] == '{' {
+			return []json.RawMessage{raw}, nil
+		}
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("JSON 格式不符: %v", err)
+	}
+	return items, nil
+}
+
+// unwrapArray JSON 模式只能输出对象，要求返回数组时模型通常会包一层（如 {"fields": [...]}），
+// 对象只有一个数组字段时取出该数组
+func unwrapArray(raw json.RawMessage) json.RawMessage {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(raw, &obj) != nil || len(obj) != 1 {
+		return raw
+	}
+	for _, value := range obj {
+		if trimmed := bytes.TrimSpace(value); len(trimmed) > 0 && trimmed[0] == '[' {
+			return trimmed
+		}
+	}
+	return raw
+}
+
+// validateField 校验字段解释
+func validateField(e *FieldExplanation) error {
+	if strings.TrimSpace(e.ChineseName) == "" {
+		return fmt.Errorf("缺少 chinese_name")
+	}
+	return validateConfidence(e.Confidence)
+}
+
+// validateTable 校验表解释
+func validateTable(e *TableExplanation) error {
+	if strings.TrimSpace(e.ChineseName) == "" {
+		return fmt.Errorf("缺少 chinese_name")
+	}
+	return validateConfidence(e.Confidence)
+}
+
+// validateRelationship 校验表关系，tables 为请求中的表名
+func validateRelationship(r *TableRelationship, tables map[string]bool) error {
+	if r.FromTable == "" || r.ToTable == "" {
+		return fmt.Errorf("缺少 from_table 或 to_table")
+	}
+	for _, name := range []string{r.FromTable, r.ToTable} {
+		if !tables[name] {
+			return fmt.Errorf("表 %s 不在请求中", name)
+		}
+	}
+	if !relationTypes[r.RelationType] {
+		return fmt.Errorf("relation_type %q 无效（应为 one_to_many/many_to_many/one_to_one）", r.RelationType)
+	}
+	return validateConfidence(r.Confidence)
+}
+
+// validateConfidence 置信度应在 0 到 1 之间
+func validateConfidence(confidence float64) error {
+	if confidence < 0 || confidence > 1 {
+		return fmt.Errorf("confidence %v 不在 0 到 1 之间", confidence)
+	}
+	return nil
+}
+
+// abbreviate 截断过长的文本用于错误信息
+func abbreviate(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,138 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+// scriptedTransport 按顺序返回预设的响应，并记录每次请求的消息
+type scriptedTransport struct {
+	responses []string
+	requests  [][]Message
+}
+
+func (t *scriptedTransport) Chat(messages []Message) (string, error) {
+	t.requests = append(t.requests, append([]Message(nil), messages...))
+	response := t.responses[0]
+	t.responses = t.responses[1:]
+	return response, nil
+}
+
+func TestExtractJSON(t *testing.T) {
+	cases := map[string]string{
+		"```json\n[{\"a\": 1}]\n```":              `[{"a": 1}]`,
+		"以下是结果：\n{\"a\": \"[x]\"}\n希望对你有帮助":       `{"a": "[x]"}`,
+		"[注] 解释如下 [{\"a\": 1}, {\"a\": 2}] 共 2 项": `[{"a": 1}, {"a": 2}]`,
+		"```\n{\"a\": {\"b\": \"}\"}}\n``` 以上":    `{"a": {"b": "}"}}`,
+		"{\"fields\": [{\"a\": 1}]}":              `{"fields": [{"a": 1}]}`,
+	}
+	for input, want := range cases {
+		raw, err := extractJSON(input)
+		if err != nil {
+			t.Errorf("%q: %v", input, err)
+			continue
+		}
+		if string(raw) != want {
+			t.Errorf("%q: expected %s, got %s", input, want, raw)
+		}
+	}
+	if _, err := extractJSON("抱歉，我无法回答"); err == nil {
+		t.Error("expected error when response has no JSON")
+	}
+
+	items, err := decodeArray(`{"fields": [{"a": 1}, {"a": 2}]}`)
+	if err != nil || len(items) != 2 {
+		t.Errorf("expected wrapped array to be unwrapped, got %d items, %v", len(items), err)
+	}
+}
+
+func TestBatchExplainPartialAndRepair(t *testing.T) {
+	transport := &scriptedTransport{responses: []string{
+		"好的，结果如下：\n```json\n[" +
+			`{"column_name": "cDepCode", "chinese_name": "部门编码", "confidence": 0.9},` +
+			`{"column_name": "cDepName", "chinese_name": "", "confidence": 0.9},` +
+			`{"column_name": "cOther", "chinese_name": "其他", "confidence": 0.9}` +
+			"]\n```",
+		`[{"column_name": "cDepName", "chinese_name": "部门名称", "confidence": 0.8}]`,
+	}}
+	client := NewPromptClient(transport, nil)
+
+	explanations, err := client.BatchExplain([]FieldContext{
+		{TableName: "Department", ColumnName: "cDepCode", DataType: "varchar"},
+		{TableName: "Department", ColumnName: "cDepName", DataType: "varchar"},
+		{TableName: "Department", ColumnName: "dDepBeginDate", DataType: "datetime"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.requests) != 2 {
+		t.Fatalf("expected one re-ask, got %d requests", len(transport.requests))
+	}
+	repair := transport.requests[1]
+	if len(repair) != 4 || repair[2].Role != "assistant" {
+		t.Fatalf("expected re-ask to carry the previous answer, got %+v", repair)
+	}
+	for _, problem := range []string{"cDepName: 缺少 chinese_name", `"cOther" 不在请求中`, "缺少表 Department 的字段 dDepBeginDate"} {
+		if !strings.Contains(repair[3].Content, problem) {
+			t.Errorf("expected re-ask to mention %q:\n%s", problem, repair[3].Content)
+		}
+	}
+
+	if explanations[FieldKey("Department", "cDepCode")] == nil || explanations[FieldKey("Department", "cDepName")] == nil ||
+		explanations[FieldKey("Department", "cDepName")].ChineseName != "部门名称" {
+		t.Errorf("expected valid and repaired fields to be accepted, got %+v", explanations)
+	}
+	if len(explanations) != 2 {
+		t.Errorf("expected unrequested and still-missing fields to be dropped, got %+v", explanations)
+	}
+}
+
+func TestExplainFieldRepairFails(t *testing.T) {
+	transport := &scriptedTransport{responses: []string{"字段含义不明确", `{"chinese_name": "编码", "confidence": 95}`}}
+	client := NewPromptClient(transport, nil)
+
+	if _, err := client.ExplainStandardField("Department", "cDepCode", "varchar"); err == nil || !strings.Contains(err.Error(), "confidence") {
+		t.Errorf("expected validation error after the re-ask, got %v", err)
+	}
+}
+
+func TestBatchExplainSameColumnInSeveralTables(t *testing.T) {
+	transport := &scriptedTransport{responses: []string{`[
+		{"table_name": "*", "column_name": "cInvCode", "chinese_name": "存货编码", "confidence": 0.95},
+		{"table_name": "SO_SODetails", "column_name": "cInvCode", "chinese_name": "销售存货编码", "confidence": 0.9},
+		{"table_name": "Inventory", "column_name": "cInvName", "chinese_name": "存货名称", "confidence": 0.95}
+	]`}}
+	client := NewPromptClient(transport, nil)
+
+	explanations, err := client.BatchExplain([]FieldContext{
+		{TableName: "Inventory", ColumnName: "cInvCode", DataType: "varchar"},
+		{TableName: "SO_SODetails", ColumnName: "cInvCode", DataType: "varchar"},
+		{TableName: "PU_AppVouchs", ColumnName: "cInvCode", DataType: "nvarchar"},
+		{TableName: "Inventory", ColumnName: "cInvName", DataType: "varchar"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.requests) != 1 {
+		t.Errorf("expected no re-ask, got %d requests", len(transport.requests))
+	}
+	prompt := transport.requests[0][1].Content
+	if strings.Count(prompt, "字段: cInvCode") != 1 || !strings.Contains(prompt, "类型: varchar/nvarchar, 所在表: Inventory, SO_SODetails, PU_AppVouchs") {
+		t.Errorf("expected cInvCode to be listed once with all its tables:\n%s", prompt)
+	}
+
+	want := map[string]string{
+		"Inventory.cInvCode":    "存货编码",
+		"SO_SODetails.cInvCode": "销售存货编码",
+		"PU_AppVouchs.cInvCode": "存货编码",
+		"Inventory.cInvName":    "存货名称",
+	}
+	for key, name := range want {
+		if exp := explanations[key]; exp == nil || exp.ChineseName != name {
+			t.Errorf("%s: expected %s, got %+v", key, name, exp)
+		}
+	}
+	if exp := explanations["PU_AppVouchs.cInvCode"]; exp != nil && exp.TableName != "PU_AppVouchs" {
+		t.Errorf("expected shared explanation to be copied per table, got table %s", exp.TableName)
+	}
+}
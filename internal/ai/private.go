@@ -0,0 +1,198 @@
+package ai
+
+import (
+	"errors"
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/ai/redact"
+)
+
+// ErrWithheld 隐私策略禁止发送的表或字段，调用方应跳过它，不算失败
+var ErrWithheld = errors.New("隐私策略禁止发送")
+
+// PrivateClient 在调用 AI 之前统一应用隐私策略：去掉禁止发送的表和列，替换需要隐藏的名称，
+// 处理样例值，并把 AI 结果中的代号换回真实名称。NewClient 创建的客户端总是以它为最外层
+type PrivateClient struct {
+	client Client
+	policy *redact.Policy
+}
+
+// NewPrivateClient 为 client 加上隐私策略，policy 为 nil 时使用默认策略
+func NewPrivateClient(client Client, policy *redact.Policy) *PrivateClient {
+	if policy == nil {
+		policy = redact.DefaultPolicy()
+	}
+	return &PrivateClient{client: client, policy: policy}
+}
+
+// ContextLength 底层客户端的上下文长度，没有限制时返回 0
+func (c *PrivateClient) ContextLength() int {
+	if limited, ok := c.client.(interface{ ContextLength() int }); ok {
+		return limited.ContextLength()
+	}
+	return 0
+}
+
+// restoreField 把字段解释换回真实的表名、列名
+func (c *PrivateClient) restoreField(exp *FieldExplanation, tableName, columnName string) *FieldExplanation {
+	restored := *exp
+	restored.TableName = tableName
+	restored.ColumnName = columnName
+	restored.ChineseName = c.policy.Restore(exp.ChineseName)
+	restored.Description = c.policy.Restore(exp.Description)
+	restored.BusinessMeaning = c.policy.Restore(exp.BusinessMeaning)
+	return &restored
+}
+
+// ExplainStandardField 解释标准字段
+func (c *PrivateClient) ExplainStandardField(tableName, columnName, dataType string) (*FieldExplanation, error) {
+	if c.policy.WithholdColumn(tableName, columnName) {
+		return nil, ErrWithheld
+	}
+	result, err := c.client.ExplainStandardField(c.policy.Table(tableName), c.policy.Column(tableName, columnName), dataType)
+	if err != nil {
+		return nil, err
+	}
+	return c.restoreField(result, tableName, columnName), nil
+}
+
+// InferCustomField 推断自定义字段，禁止发送的关联字段不会出现在 prompt 中
+func (c *PrivateClient) InferCustomField(tableName, columnName string, relatedFields []RelatedField) (*FieldExplanation, error) {
+	if c.policy.WithholdColumn(tableName, columnName) {
+		return nil, ErrWithheld
+	}
+	var related []RelatedField
+	for _, field := range relatedFields {
+		if c.policy.WithholdColumn(field.TableName, field.ColumnName) {
+			continue
+		}
+		field.ColumnName = c.policy.Column(field.TableName, field.ColumnName)
+		field.TableName = c.policy.Table(field.TableName)
+		related = append(related, field)
+	}
+	result, err := c.client.InferCustomField(c.policy.Table(tableName), c.policy.Column(tableName, columnName), related)
+	if err != nil {
+		return nil, err
+	}
+	return c.restoreField(result, tableName, columnName), nil
+}
+
+// BatchExplain 批量解释。禁止发送的字段不请求，结果中没有它们
+func (c *PrivateClient) BatchExplain(fields []FieldContext) (map[string]*FieldExplanation, error) {
+	original := make(map[string]FieldContext) // 发送的 FieldKey -> 原字段
+	var sent []FieldContext
+	for _, field := range fields {
+		if c.policy.WithholdColumn(field.TableName, field.ColumnName) {
+			continue
+		}
+		redacted := FieldContext{
+			TableName:  c.policy.Table(field.TableName),
+			ColumnName: c.policy.Column(field.TableName, field.ColumnName),
+			DataType:   field.DataType,
+			Evidence:   c.evidence(field.Evidence),
+		}
+		original[FieldKey(redacted.TableName, redacted.ColumnName)] = field
+		sent = append(sent, redacted)
+	}
+	if len(sent) == 0 {
+		return map[string]*FieldExplanation{}, nil
+	}
+
+	results, err := c.client.BatchExplain(sent)
+	if err != nil {
+		return nil, err
+	}
+	restored := make(map[string]*FieldExplanation, len(results))
+	for key, exp := range results {
+		field, ok := original[key]
+		if !ok {
+			continue
+		}
+		restored[FieldKey(field.TableName, field.ColumnName)] = c.restoreField(exp, field.TableName, field.ColumnName)
+	}
+	return restored, nil
+}
+
+// evidence 按策略处理字段证据：样例值脱敏或哈希（含个人信息时不发送），关联字段同样过滤和替换名称
+func (c *PrivateClient) evidence(e *FieldEvidence) *FieldEvidence {
+	if e == nil {
+		return nil
+	}
+	redacted := *e
+	redacted.Samples, redacted.Sensitive = c.policy.Sample(e.Samples)
+	redacted.Relations = nil
+	for _, r := range e.Relations {
+		if c.policy.WithholdColumn(r.Table, r.Column) {
+			continue
+		}
+		r.Column = c.policy.Column(r.Table, r.Column)
+		r.Table = c.policy.Table(r.Table)
+		redacted.Relations = append(redacted.Relations, r)
+	}
+	return &redacted
+}
+
+// AnalyzeTableMeaning 分析表的意义。列的默认值属于数据，不发送
+func (c *PrivateClient) AnalyzeTableMeaning(tableName string, columns []adapter.Column) (*TableExplanation, error) {
+	if c.policy.WithholdTable(tableName) {
+		return nil, ErrWithheld
+	}
+	result, err := c.client.AnalyzeTableMeaning(c.policy.Table(tableName), c.columns(tableName, columns))
+	if err != nil {
+		return nil, err
+	}
+	restored := *result
+	restored.TableName = tableName
+	restored.ChineseName = c.policy.Restore(result.ChineseName)
+	restored.Description = c.policy.Restore(result.Description)
+	restored.BusinessMeaning = c.policy.Restore(result.BusinessMeaning)
+	return &restored, nil
+}
+
+// columns 去掉禁止发送的列，替换列名，清除默认值
+func (c *PrivateClient) columns(tableName string, columns []adapter.Column) []adapter.Column {
+	var result []adapter.Column
+	for _, col := range columns {
+		if c.policy.WithholdColumn(tableName, col.Name) {
+			continue
+		}
+		col.Name = c.policy.Column(tableName, col.Name)
+		col.DefaultValue.String, col.DefaultValue.Valid = "", false
+		result = append(result, col)
+	}
+	return result
+}
+
+// AnalyzeTableRelationships 分析表之间的关系，禁止发送的表不参与分析
+func (c *PrivateClient) AnalyzeTableRelationships(tables []adapter.Table) ([]TableRelationship, error) {
+	names := make(map[string]string) // 发送的表名 -> 原表名（带 schema 前缀，与表节点一致）
+	var sent []adapter.Table
+	for _, table := range tables {
+		qualified := table.QualifiedName()
+		if c.policy.WithholdTable(qualified) {
+			continue
+		}
+		redacted := table
+		if alias := c.policy.Table(qualified); alias != qualified {
+			redacted.Schema, redacted.Name = "", alias
+		}
+		redacted.Columns = c.columns(qualified, table.Columns)
+		names[redacted.QualifiedName()] = qualified
+		sent = append(sent, redacted)
+	}
+	if len(sent) == 0 {
+		return nil, nil
+	}
+
+	// 部分失败时结果和错误一起返回
+	results, err := c.client.AnalyzeTableRelationships(sent)
+	for i := range results {
+		if name, ok := names[results[i].FromTable]; ok {
+			results[i].FromTable = name
+		}
+		if name, ok := names[results[i].ToTable]; ok {
+			results[i].ToTable = name
+		}
+		results[i].Description = c.policy.Restore(results[i].Description)
+	}
+	return results, err
+}
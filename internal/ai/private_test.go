@@ -0,0 +1,186 @@
+package ai
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/ai/redact"
+)
+
+func TestPrivateClientBatchExplain(t *testing.T) {
+	policy := &redact.Policy{
+		NeverSendColumns:   []string{"cIDCard"},
+		PseudonymizeTables: []string{"Salary"},
+	}
+	if err := policy.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	alias := policy.Table("Salary")
+	transport := &scriptedTransport{responses: []string{
+		`[{"table_name": "` + alias + `", "column_name": "iAmount", "chinese_name": "金额", "description": "` + alias + ` 的发放金额", "confidence": 0.9},` +
+			`{"table_name": "Person", "column_name": "cPhone", "chinese_name": "电话", "confidence": 0.9}]`,
+	}}
+	audit, err := OpenAuditLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewPrivateClient(NewPromptClient(&auditTransport{next: transport, log: audit}, nil), policy)
+
+	explanations, err := client.BatchExplain([]FieldContext{
+		{TableName: "Salary", ColumnName: "iAmount", DataType: "decimal"},
+		{TableName: "Person", ColumnName: "cIDCard", DataType: "varchar"},
+		{TableName: "Person", ColumnName: "cPhone", DataType: "varchar", Evidence: &FieldEvidence{
+			SampleRows: 100, Samples: []string{"13800138000"},
+			Relations: []RelatedColumn{{Table: "Person", Column: "cIDCard", Kind: "推断"}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := transport.requests[0][1].Content
+	for _, secret := range []string{"Salary", "cIDCard", "13800138000"} {
+		if strings.Contains(request, secret) {
+			t.Errorf("expected %q to stay out of the prompt:\n%s", secret, request)
+		}
+	}
+	if !strings.Contains(request, alias) || !strings.Contains(request, "取值含手机号") {
+		t.Errorf("expected pseudonym and PII note in the prompt:\n%s", request)
+	}
+
+	exp := explanations[FieldKey("Salary", "iAmount")]
+	if exp == nil || exp.TableName != "Salary" || exp.Description != "Salary 的发放金额" {
+		t.Errorf("expected pseudonym to be restored, got %+v", exp)
+	}
+	if explanations[FieldKey("Person", "cIDCard")] != nil || explanations[FieldKey("Person", "cPhone")] == nil {
+		t.Errorf("unexpected explanations %+v", explanations)
+	}
+
+	// 审计日志记录的就是发出的消息
+	audit.Close()
+	file, err := os.Open(audit.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	var entries []auditEntry
+	for scanner.Scan() {
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 1 || audit.Count() != 1 || entries[0].Messages[1].Content != request {
+		t.Errorf("expected one audit entry matching the request, got %d", len(entries))
+	}
+}
+
+func TestPrivateClientWithholdTable(t *testing.T) {
+	policy := &redact.Policy{NeverSendTables: []string{"HR_*"}}
+	if err := policy.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	transport := &scriptedTransport{}
+	client := NewPrivateClient(NewPromptClient(transport, nil), policy)
+	if _, err := client.AnalyzeTableMeaning("dbo.HR_Salary", nil); err != ErrWithheld {
+		t.Errorf("expected ErrWithheld, got %v", err)
+	}
+	if len(transport.requests) != 0 {
+		t.Error("expected no request for a never-send table")
+	}
+}
+
+func TestPrivateClientRelationshipsAcrossSchemas(t *testing.T) {
+	policy := &redact.Policy{PseudonymizeTables: []string{"*.Orders"}}
+	if err := policy.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	sales, archive := policy.Table("sales.Orders"), policy.Table("archive.Orders")
+	if sales == archive {
+		t.Fatalf("expected distinct pseudonyms for same-named tables, got %s", sales)
+	}
+	transport := &scriptedTransport{responses: []string{
+		`[{"from_table": "` + archive + `", "to_table": "` + sales + `", "relation_type": "one_to_one", "description": "` + archive + ` 归档 ` + sales + `", "confidence": 0.8},` +
+			`{"from_table": "sales.Customer", "to_table": "` + sales + `", "relation_type": "one_to_many", "confidence": 0.9}]`,
+	}}
+	client := NewPrivateClient(NewPromptClient(transport, nil), policy)
+
+	id := []adapter.Column{{Name: "ID", IsPrimaryKey: true}}
+	relationships, err := client.AnalyzeTableRelationships([]adapter.Table{
+		{Schema: "sales", Name: "Orders", Columns: id},
+		{Schema: "archive", Name: "Orders", Columns: id},
+		{Schema: "sales", Name: "Customer", Columns: id},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request := transport.requests[0][1].Content; strings.Contains(request, "Orders") {
+		t.Errorf("expected pseudonymized tables to stay out of the prompt:\n%s", request)
+	}
+	if len(relationships) != 2 {
+		t.Fatalf("expected 2 relationships, got %+v", relationships)
+	}
+	if r := relationships[0]; r.FromTable != "archive.Orders" || r.ToTable != "sales.Orders" || r.Description != "archive.Orders 归档 sales.Orders" {
+		t.Errorf("expected schema-qualified names to be restored, got %+v", r)
+	}
+	if r := relationships[1]; r.FromTable != "sales.Customer" || r.ToTable != "sales.Orders" {
+		t.Errorf("expected schema-qualified names to be restored, got %+v", r)
+	}
+}
+
+func TestPrivateClientWithholdQualifiedColumn(t *testing.T) {
+	policy := &redact.Policy{NeverSendColumns: []string{"Person.cDefine1"}}
+	if err := policy.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	transport := &scriptedTransport{}
+	client := NewPrivateClient(NewPromptClient(transport, nil), policy)
+	related := []RelatedField{{TableName: "Customer", ColumnName: "cCusCode", Confidence: 0.9}}
+	if _, err := client.InferCustomField("Person", "cDefine1", related); err != ErrWithheld {
+		t.Errorf("expected ErrWithheld, got %v", err)
+	}
+	if len(transport.requests) != 0 {
+		t.Error("expected no request for a never-send column")
+	}
+}
+
+func TestAuditLogSharedAcrossProviders(t *testing.T) {
+	audit, err := OpenAuditLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dashscope := &auditTransport{next: &scriptedTransport{responses: []string{"[]"}}, log: audit, provider: ProviderDashScope, model: "qwen-plus"}
+	ollama := &auditTransport{next: &scriptedTransport{responses: []string{"[]"}}, log: audit, provider: ProviderOllama, model: "qwen2.5"}
+	for _, transport := range []Transport{dashscope, ollama} {
+		if _, err := transport.Chat([]Message{{Role: "user", Content: "hi"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	audit.Close()
+
+	data, err := os.ReadFile(audit.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(lines))
+	}
+	for i, want := range []string{ProviderDashScope, ProviderOllama} {
+		var entry auditEntry
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Provider != want {
+			t.Errorf("entry %d: expected provider %s, got %s", i, want, entry.Provider)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Domain 业务领域：决定 prompt 中的专家身份和命名规范提示
+type Domain struct {
+	Name         string `json:"name"`
+	Title        string `json:"title"`         // 显示名称
+	System       string `json:"system"`        // 系统消息
+	Expert       string `json:"expert"`        // prompt 开头的专家身份
+	Conventions  string `json:"conventions"`   // 字段命名规范提示，可为空
+	CustomFields string `json:"custom_fields"` // 自定义字段的说明，可为空
+	Tables       string `json:"tables"`        // 表命名或常见实体提示，可为空
+}
+
+// 内置领域
+var domains = map[string]Domain{
+	"u8": {
+		Name:         "u8",
+		Title:        "用友 U8 ERP",
+		System:       "你是用友 U8 ERP 系统的数据库专家，精通 U8 的表结构和字段命名规范。",
+		Expert:       "你是用友 U8 ERP 系统的数据库专家",
+		Conventions:  "基于 U8 标准字段命名规范（类型前缀 c 字符、i 整数、d 日期、b 布尔、f 浮点，如 cInvCode 为存货编码）",
+		CustomFields: "cFree 或 cDefine",
+		Tables:       "U8 表名多为模块缩写加实体，如 SO_SOMain 为销售订单主表、PU_AppVouchs 为请购单子表",
+	},
+	"generic": {
+		Name:        "generic",
+		Title:       "通用业务系统（OLTP）",
+		System:      "你是企业业务系统的数据库专家，熟悉常见的表结构设计和字段命名习惯。",
+		Expert:      "你是企业业务系统的数据库专家",
+		Conventions: "字段多为 snake_case 或 camelCase 命名，以 _id / Id 结尾的通常是外键，created_at、updated_at 等为审计字段",
+	},
+	"ecommerce": {
+		Name:        "ecommerce",
+		Title:       "电商系统",
+		System:      "你是电商系统的数据库专家，熟悉商品、订单、支付、物流、会员、营销等业务的表结构设计。",
+		Expert:      "你是电商系统的数据库专家",
+		Conventions: "注意区分 SPU（商品）与 SKU（规格）、订单与子订单、原价与实付金额，金额字段多以分为单位",
+		Tables:      "常见实体有商品、SKU、订单、订单明细、购物车、支付流水、物流单、会员、优惠券、库存",
+	},
+}
+
+// Domains 内置领域名称
+func Domains() []string {
+	names := make([]string, 0, len(domains))
+	for name := range domains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetDomain 按名称获取内置领域
+func GetDomain(name string) (Domain, bool) {
+	d, ok := domains[strings.ToLower(name)]
+	return d, ok
+}
+
+// LoadDomain 按名称获取内置领域，或从 JSON 文件加载自定义领域（未填写的字段沿用 generic）
+func LoadDomain(nameOrFile string) (Domain, error) {
+	if d, ok := GetDomain(nameOrFile); ok {
+		return d, nil
+	}
+	data, err := os.ReadFile(nameOrFile)
+	if err != nil {
+		return Domain{}, fmt.Errorf("未知领域 %s（内置 %s，或指定 JSON 文件）: %v", nameOrFile, strings.Join(Domains(), "/"), err)
+	}
+
+	d := domains["generic"]
+	d.Name = "custom"
+	if err := json.Unmarshal(data, &d); err != nil {
+		return Domain{}, fmt.Errorf("解析领域文件 %s 失败: %v", nameOrFile, err)
+	}
+	if d.Title == "" {
+		d.Title = d.Name
+	}
+	return d, nil
+}
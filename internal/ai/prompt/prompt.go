@@ -0,0 +1,139 @@
+package prompt
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// 模板名称
+const (
+	System             = "system"
+	ExplainField       = "explain_field"
+	InferCustomField   = "infer_custom_field"
+	BatchExplain       = "batch_explain"
+	TableMeaning       = "table_meaning"
+	TableRelationships = "table_relationships"
+	Repair             = "repair" // 响应无效时的重问
+)
+
+//go:embed templates/*.tmpl
+var defaults embed.FS
+
+var funcs = template.FuncMap{
+	"inc":  func(i int) int { return i + 1 },
+	"join": strings.Join,
+}
+
+// Set 一套 prompt 模板和业务领域。各服务商共用同一套 Set
+type Set struct {
+	domain    Domain
+	templates map[string]*template.Template
+	sources   map[string]string
+}
+
+// Default 内置模板加 U8 领域
+func Default() *Set {
+	d, _ := GetDomain("u8")
+	s, err := Load("", d)
+	if err != nil {
+		panic(err) // 内置模板有误，属于编译期问题
+	}
+	return s
+}
+
+// Load 加载内置模板，dir 不为空时用其中同名的 <模板名>.tmpl 文件覆盖
+func Load(dir string, domain Domain) (*Set, error) {
+	s := &Set{domain: domain, templates: make(map[string]*template.Template), sources: make(map[string]string)}
+
+	entries, err := defaults.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		data, err := defaults.ReadFile("templates/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		s.sources[strings.TrimSuffix(entry.Name(), ".tmpl")] = string(data)
+	}
+
+	if dir != "" {
+		for name := range s.sources {
+			data, err := os.ReadFile(filepath.Join(dir, name+".tmpl"))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			s.sources[name] = string(data)
+		}
+	}
+
+	for name, source := range s.sources {
+		t, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("解析模板 %s 失败: %v", name, err)
+		}
+		s.templates[name] = t
+	}
+	return s, nil
+}
+
+// Domain 当前领域
+func (s *Set) Domain() Domain {
+	return s.domain
+}
+
+// Render 渲染模板，data 中自动加入 Domain
+func (s *Set) Render(name string, data map[string]interface{}) (string, error) {
+	t, ok := s.templates[name]
+	if !ok {
+		return "", fmt.Errorf("模板 %s 不存在", name)
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["Domain"] = s.domain
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染模板 %s 失败: %v", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// System 系统消息
+func (s *Set) System() string {
+	text, err := s.Render(System, nil)
+	if err != nil || text == "" {
+		return s.domain.System
+	}
+	return text
+}
+
+// Version 模板和领域内容的摘要，模板或领域变化时改变（可用于缓存失效）
+func (s *Set) Version() string {
+	names := make([]string, 0, len(s.sources))
+	for name := range s.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha1.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\x00", name, s.sources[name])
+	}
+	domain, _ := json.Marshal(s.domain)
+	h.Write(domain)
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,99 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type group struct {
+	ColumnName string
+	DataType   string
+	Tables     []string
+	Evidence   []evidence
+}
+
+type evidence struct {
+	Table    string
+	Evidence summary
+}
+
+type summary string
+
+func (s summary) Summary() string { return string(s) }
+
+func TestRenderDomains(t *testing.T) {
+	data := map[string]interface{}{"Groups": []group{
+		{ColumnName: "cDepCode", DataType: "varchar", Tables: []string{"Department", "Person"}},
+		{ColumnName: "cPersonCode", DataType: "varchar", Tables: []string{"Person"},
+			Evidence: []evidence{{Table: "Person", Evidence: "空值 0%"}}},
+	}}
+
+	u8 := Default()
+	text, err := u8.Render(BatchExplain, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "用友 U8") || !strings.Contains(text, "1. 字段: cDepCode, 类型: varchar, 所在表: Department, Person") {
+		t.Errorf("unexpected U8 prompt:\n%s", text)
+	}
+	if !strings.Contains(text, "   数据证据（Person）: 空值 0%\n") {
+		t.Errorf("expected evidence line under cPersonCode:\n%s", text)
+	}
+
+	generic, _ := GetDomain("generic")
+	set, err := Load("", generic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err = set.Render(BatchExplain, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(text, "U8") || strings.Contains(set.System(), "U8") {
+		t.Errorf("generic prompt should not mention U8:\n%s", text)
+	}
+	if set.Version() == u8.Version() {
+		t.Error("expected different versions for different domains")
+	}
+}
+
+func TestOverrideAndCustomDomain(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "table_meaning.tmpl"), []byte("{{.Domain.Expert}}：表 {{.Table}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	domainFile := filepath.Join(dir, "mes.json")
+	if err := os.WriteFile(domainFile, []byte(`{"name": "mes", "expert": "你是制造执行系统的数据库专家"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	domain, err := LoadDomain(domainFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domain.Name != "mes" || domain.System == "" {
+		t.Errorf("expected custom domain to fall back to generic fields, got %+v", domain)
+	}
+
+	set, err := Load(dir, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := set.Render(TableMeaning, map[string]interface{}{"Table": "WorkOrder"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "你是制造执行系统的数据库专家：表 WorkOrder" {
+		t.Errorf("expected overridden template, got %q", text)
+	}
+	// 未覆盖的模板仍使用内置版本
+	if text, _ := set.Render(ExplainField, map[string]interface{}{"Table": "WorkOrder", "Column": "code"}); !strings.Contains(text, "字段名: code") {
+		t.Errorf("expected built-in explain_field template, got %q", text)
+	}
+
+	if _, err := LoadDomain("no-such-domain"); err == nil {
+		t.Error("expected error for unknown domain")
+	}
+}
@@ -0,0 +1,225 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"schema-analyzer/internal/ai/cache"
+	"schema-analyzer/internal/ai/prompt"
+	"schema-analyzer/internal/ai/redact"
+	"strings"
+	"time"
+)
+
+// 支持的服务商
+const (
+	ProviderDashScope = "dashscope" // 阿里云通义千问（DashScope 原生接口）
+	ProviderOpenAI    = "openai"    // OpenAI 兼容接口
+	ProviderOllama    = "ollama"    // 本地 Ollama
+	ProviderLlamaCpp  = "llamacpp"  // 本地 llama.cpp server
+)
+
+// 各服务商的默认值
+const (
+	DefaultDashScopeModel = "qwen-plus"
+	DefaultOpenAIBaseURL  = "https://api.openai.com/v1"
+	DefaultOpenAIModel    = "gpt-4o-mini"
+)
+
+// ErrMissingAPIKey 服务商需要 API Key 但未提供
+var ErrMissingAPIKey = errors.New("未提供 API Key")
+
+// Config AI 服务商配置，空字段使用服务商的默认值
+type Config struct {
+	Provider string // 服务商，默认 dashscope
+	BaseURL  string // 接口地址（OpenAI 兼容接口）
+	Model    string // 模型
+	APIKey   string
+
+	ContextLength int // 本地模型请求的上下文长度，0 表示自动
+
+	Prompts *prompt.Set // prompt 模板和业务领域，nil 时使用内置模板和 U8 领域
+
+	Cache *cache.Store // AI 响应缓存，nil 时不缓存
+
+	Timeout    time.Duration // 单次请求超时，0 使用 DefaultTimeout
+	MaxRetries int           // 429 / 5xx / 网络错误的重试次数，0 使用 DefaultMaxRetries，负数不重试
+	RateLimit  float64       // 每秒最多发出的请求数，0 不限制
+
+	Usage *Usage // 用量统计和预算，nil 时不统计
+
+	Privacy *redact.Policy // 隐私策略，nil 时使用默认策略（样例值脱敏）
+	Audit   *AuditLog      // 审计日志，记录实际发出的请求，nil 时不记录
+}
+
+// promptedClient 内置服务商的客户端：嵌入 promptClient，自身实现 Transport
+type promptedClient interface {
+	Client
+	Transport
+	SetPrompts(prompts *prompt.Set)
+	Prompts() *prompt.Set
+	Model() string
+	SetTimeout(timeout time.Duration)
+	setTransport(transport Transport)
+	setPromptLimit(tokens int)
+}
+
+// Providers 支持的服务商列表
+func Providers() []string {
+	return []string{ProviderDashScope, ProviderOpenAI, ProviderOllama, ProviderLlamaCpp}
+}
+
+// IsLocal 是否为本地模型服务（不需要 API Key，数据不出内网）
+func IsLocal(provider string) bool {
+	provider = strings.ToLower(provider)
+	return provider == ProviderOllama || provider == ProviderLlamaCpp
+}
+
+// APIKeyEnv 服务商 API Key 对应的环境变量，本地模型返回空
+func APIKeyEnv(provider string) string {
+	switch strings.ToLower(provider) {
+	case ProviderOllama, ProviderLlamaCpp:
+		return ""
+	case ProviderOpenAI:
+		return "OPENAI_API_KEY"
+	default:
+		return "DASHSCOPE_API_KEY"
+	}
+}
+
+// DefaultConcurrency 服务商默认的并发调用数：本地模型通常一次只处理一个请求，云端服务为 4
+func DefaultConcurrency(provider string) int {
+	if IsLocal(provider) {
+		return 1
+	}
+	return 4
+}
+
+// providerName 规范化的服务商名称，空值为 dashscope
+func providerName(provider string) string {
+	if provider == "" {
+		return ProviderDashScope
+	}
+	return strings.ToLower(provider)
+}
+
+// NewClient 按配置创建 AI 客户端
+func NewClient(cfg Config) (Client, error) {
+	client, err := newProviderClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client.SetPrompts(cfg.Prompts)
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	client.SetTimeout(timeout)
+	retries := cfg.MaxRetries
+	if retries == 0 {
+		retries = DefaultMaxRetries
+	} else if retries < 0 {
+		retries = 0
+	}
+	// 调用链：限流和重试 -> 用量统计和预算 -> 审计日志 -> 服务商
+	var transport Transport = client
+	if cfg.Audit != nil {
+		transport = &auditTransport{next: transport, log: cfg.Audit, provider: providerName(cfg.Provider), model: client.Model()}
+	}
+	if cfg.Usage != nil {
+		cfg.Usage.bind(providerName(cfg.Provider), client.Model())
+		transport = &meteredTransport{next: transport, usage: cfg.Usage}
+	}
+	client.setTransport(&retryTransport{
+		next:       transport,
+		limiter:    NewRateLimiter(cfg.RateLimit, 1),
+		maxRetries: retries,
+		baseDelay:  retryBaseDelay,
+	})
+
+	// 本地模型的 prompt 还要给输出留出空间
+	if limited, ok := client.(interface{ ContextLength() int }); ok && limited.ContextLength() > 0 {
+		limit := limited.ContextLength() - 2*outputReserve
+		if limit > DefaultPromptTokens {
+			limit = DefaultPromptTokens
+		}
+		if limit < outputReserve {
+			limit = outputReserve
+		}
+		client.setPromptLimit(limit)
+	}
+
+	// 隐私策略在最外层，缓存 key 和缓存内容都是处理后的数据
+	var result Client = client
+	if cfg.Cache != nil {
+		provider := providerName(cfg.Provider)
+		// llama.cpp 不指定模型时以接口地址区分
+		model := client.Model()
+		if model == "" {
+			model = cfg.BaseURL
+		}
+		result = NewCachedClient(client, cfg.Cache, provider, model, client.Prompts().Version())
+	}
+	return NewPrivateClient(result, cfg.Privacy), nil
+}
+
+// newProviderClient 创建服务商的传输层客户端
+func newProviderClient(cfg Config) (promptedClient, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", ProviderDashScope:
+		if cfg.APIKey == "" {
+			return nil, ErrMissingAPIKey
+		}
+		c := NewAlibabaClient(cfg.APIKey)
+		if cfg.Model != "" {
+			c.SetModel(cfg.Model)
+		}
+		return c, nil
+
+	case ProviderOpenAI:
+		baseURL, model := cfg.BaseURL, cfg.Model
+		if baseURL == "" {
+			// 官方接口必须有 Key，自建网关可以不需要
+			if cfg.APIKey == "" {
+				return nil, ErrMissingAPIKey
+			}
+			baseURL = DefaultOpenAIBaseURL
+		}
+		if model == "" {
+			model = DefaultOpenAIModel
+		}
+		return NewOpenAIClient(baseURL, cfg.APIKey, model), nil
+
+	case ProviderOllama, ProviderLlamaCpp:
+		c, err := NewLocalClient(strings.ToLower(cfg.Provider), cfg.BaseURL, cfg.Model, cfg.ContextLength)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("不支持的 AI 服务商: %s（可选 %s）", cfg.Provider, strings.Join(Providers(), "/"))
+}
+
+// 字段批量解释的 token 估算
+const (
+	batchPromptTokens   = 600 // prompt 固定部分
+	batchTokensPerField = 160 // 每个字段的输入（含数据证据）和输出
+	minBatchSize        = 5
+)
+
+// BatchSize 根据客户端的上下文长度确定字段批次大小，不超过 max。
+// 只有声明了上下文长度的客户端（本地模型）会被缩小
+func BatchSize(client Client, max int) int {
+	limited, ok := client.(interface{ ContextLength() int })
+	if !ok || limited.ContextLength() <= 0 {
+		return max
+	}
+	size := (limited.ContextLength() - outputReserve - batchPromptTokens) / batchTokensPerField
+	if size < minBatchSize {
+		size = minBatchSize
+	}
+	if size > max {
+		size = max
+	}
+	return size
+}
@@ -0,0 +1,99 @@
+package redact
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// Pseudonyms 名称代号对照表。代号由本地密钥和名称计算（T_ / C_ 加 8 位十六进制），
+// 同一个对照表文件多次扫描得到相同的代号；对照表只保存在本地，用于把 AI 结果换回真实名称
+type Pseudonyms struct {
+	Key   string            `json:"key"`   // 计算代号的密钥，不发送
+	Names map[string]string `json:"names"` // 代号 -> 真实名称
+
+	mu   sync.Mutex
+	file string
+}
+
+// pseudonymPattern 文本中的代号
+var pseudonymPattern = regexp.MustCompile(`\b[TC]_[0-9a-f]{8}\b`)
+
+// LoadPseudonyms 读取代号对照表，文件不存在或 filename 为空时创建新的密钥
+func LoadPseudonyms(filename string) (*Pseudonyms, error) {
+	p := &Pseudonyms{Names: make(map[string]string), file: filename}
+	if filename != "" {
+		data, err := os.ReadFile(filename)
+		if err == nil {
+			if err := json.Unmarshal(data, p); err != nil {
+				return nil, err
+			}
+			if p.Names == nil {
+				p.Names = make(map[string]string)
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if p.Key == "" {
+		key := make([]byte, 16)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		p.Key = hex.EncodeToString(key)
+	}
+	return p, nil
+}
+
+// name 名称的代号，prefix 为 T（表）或 C（列）
+func (p *Pseudonyms) name(prefix, real string) string {
+	alias := prefix + "_" + keyedHash(p.Key, prefix+":"+real, 8)
+	p.mu.Lock()
+	p.Names[alias] = real
+	p.mu.Unlock()
+	return alias
+}
+
+// hash 样例值的加盐哈希，相同取值得到相同结果
+func (p *Pseudonyms) hash(value string) string {
+	return "#" + keyedHash(p.Key, "V:"+value, 8)
+}
+
+// Real 代号对应的真实名称
+func (p *Pseudonyms) Real(alias string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	real, ok := p.Names[alias]
+	return real, ok
+}
+
+// restore 把文本中的代号换回真实名称
+func (p *Pseudonyms) restore(text string) string {
+	return pseudonymPattern.ReplaceAllStringFunc(text, func(alias string) string {
+		if real, ok := p.Real(alias); ok {
+			return real
+		}
+		return alias
+	})
+}
+
+// Save 保存对照表（没有文件名或没有代号时不保存）。文件包含密钥，只应保存在本地
+func (p *Pseudonyms) Save() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.file == "" || len(p.Names) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(p.file, data, 0600)
+}
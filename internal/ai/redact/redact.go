@@ -0,0 +1,295 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 样例值的处理方式
+const (
+	ValuesMask = "mask" // 保留首尾字符和分隔符，其余替换为 *（默认）
+	ValuesHash = "hash" // 替换为加盐哈希，只能看出取值是否相同
+	ValuesDrop = "drop" // 不发送样例
+)
+
+// Policy 发送给 AI 服务商之前的数据处理策略。
+// 表名模式为 glob（不区分大小写，如 HR_*、dbo.Salary*），以 re: 开头的为正则表达式，同时匹配表名和带 schema 前缀的表名；
+// 列名模式匹配 列、表.列 和 schema.表.列
+type Policy struct {
+	NeverSendTables     []string `json:"never_send_tables"`    // 这些表的任何信息都不发送
+	NeverSendColumns    []string `json:"never_send_columns"`   // 这些列的任何信息都不发送
+	PseudonymizeTables  []string `json:"pseudonymize_tables"`  // 表名替换为代号
+	PseudonymizeColumns []string `json:"pseudonymize_columns"` // 列名替换为代号
+	Values              string   `json:"values"`               // 样例值处理：mask / hash / drop
+	MappingFile         string   `json:"mapping_file"`         // 代号对照表文件，为空时不保存
+
+	neverTables   []pattern
+	neverColumns  []pattern
+	pseudoTables  []pattern
+	pseudoColumns []pattern
+	names         *Pseudonyms
+}
+
+// pattern 编译后的名称模式
+type pattern struct {
+	glob  string
+	regex *regexp.Regexp
+}
+
+// DefaultPolicy 默认策略：所有表和列照常发送，样例值脱敏
+func DefaultPolicy() *Policy {
+	p := &Policy{}
+	if err := p.Compile(); err != nil {
+		panic(err) // 空策略不会出错
+	}
+	return p
+}
+
+// LoadPolicy 读取策略文件（JSON）。未编译，补充命令行参数后调用 Compile
+func LoadPolicy(filename string) (*Policy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	p := &Policy{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("解析隐私策略 %s 失败: %v", filename, err)
+	}
+	return p, nil
+}
+
+// Compile 编译名称模式并加载代号对照表
+func (p *Policy) Compile() error {
+	switch p.Values {
+	case "":
+		p.Values = ValuesMask
+	case ValuesMask, ValuesHash, ValuesDrop:
+	default:
+		return fmt.Errorf("无效的样例值处理方式 %q（可选 mask/hash/drop）", p.Values)
+	}
+	var err error
+	if p.neverTables, err = compile(p.NeverSendTables); err != nil {
+		return err
+	}
+	if p.neverColumns, err = compile(p.NeverSendColumns); err != nil {
+		return err
+	}
+	if p.pseudoTables, err = compile(p.PseudonymizeTables); err != nil {
+		return err
+	}
+	if p.pseudoColumns, err = compile(p.PseudonymizeColumns); err != nil {
+		return err
+	}
+	p.names, err = LoadPseudonyms(p.MappingFile)
+	return err
+}
+
+func compile(patterns []string) ([]pattern, error) {
+	var result []pattern
+	for _, raw := range patterns {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if strings.HasPrefix(raw, "re:") {
+			re, err := regexp.Compile(strings.TrimPrefix(raw, "re:"))
+			if err != nil {
+				return nil, fmt.Errorf("无效的正则表达式 %q: %v", raw, err)
+			}
+			result = append(result, pattern{regex: re})
+			continue
+		}
+		glob := strings.ToLower(raw)
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("无效的通配符模式 %q: %v", raw, err)
+		}
+		result = append(result, pattern{glob: glob})
+	}
+	return result, nil
+}
+
+// matchAny 任一模式匹配任一名称
+func matchAny(patterns []pattern, names ...string) bool {
+	for _, p := range patterns {
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			if p.regex != nil {
+				if p.regex.MatchString(name) {
+					return true
+				}
+			} else if ok, _ := path.Match(p.glob, strings.ToLower(name)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tableNames 表名的各种写法：带 schema 前缀和不带
+func tableNames(table string) []string {
+	names := []string{table}
+	if i := strings.Index(table, "."); i > 0 {
+		names = append(names, table[i+1:])
+	}
+	return names
+}
+
+// columnNames 列名的各种写法：列、表.列、schema.表.列
+func columnNames(table, column string) []string {
+	names := []string{column}
+	for _, t := range tableNames(table) {
+		if t != "" {
+			names = append(names, t+"."+column)
+		}
+	}
+	return names
+}
+
+// WithholdTable 表是否不能发送
+func (p *Policy) WithholdTable(table string) bool {
+	return matchAny(p.neverTables, tableNames(table)...)
+}
+
+// WithholdColumn 列是否不能发送（所在表不能发送时列也不能发送）。table 为空时只按列名匹配
+func (p *Policy) WithholdColumn(table, column string) bool {
+	if table != "" && p.WithholdTable(table) {
+		return true
+	}
+	return matchAny(p.neverColumns, columnNames(table, column)...)
+}
+
+// Table 发送时使用的表名：需要替换时返回代号
+func (p *Policy) Table(table string) string {
+	if table == "" || !matchAny(p.pseudoTables, tableNames(table)...) {
+		return table
+	}
+	return p.names.name("T", table)
+}
+
+// Column 发送时使用的列名：需要替换时返回代号。同名列在各表中使用同一个代号
+func (p *Policy) Column(table, column string) string {
+	if !matchAny(p.pseudoColumns, columnNames(table, column)...) {
+		return column
+	}
+	return p.names.name("C", column)
+}
+
+// Restore 把文本中的代号换回真实名称，用于 AI 返回的结果
+func (p *Policy) Restore(text string) string {
+	return p.names.restore(text)
+}
+
+// SaveMapping 保存代号对照表（MappingFile 为空时不保存）
+func (p *Policy) SaveMapping() error {
+	return p.names.Save()
+}
+
+// Sample 处理一组样例值。含个人信息时不发送任何样例，返回识别到的信息类型
+func (p *Policy) Sample(values []string) (redacted []string, pii []string) {
+	seen := make(map[string]bool)
+	for _, v := range values {
+		if kind := DetectPII(v); kind != "" && !seen[kind] {
+			seen[kind] = true
+			pii = append(pii, kind)
+		}
+	}
+	if len(pii) > 0 || p.Values == ValuesDrop {
+		return nil, pii
+	}
+	for _, v := range values {
+		if p.Values == ValuesHash {
+			redacted = append(redacted, p.names.hash(v))
+		} else {
+			redacted = append(redacted, Mask(v))
+		}
+	}
+	return redacted, nil
+}
+
+// 个人信息的识别规则
+var piiRules = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{"邮箱", regexp.MustCompile(`^[\w.+-]+@[\w-]+(\.[\w-]+)+$`)},
+	{"手机号", regexp.MustCompile(`^(\+?86[- ]?)?1[3-9]\d{9}$`)},
+	{"身份证号", regexp.MustCompile(`^\d{6}(18|19|20)\d{2}(0[1-9]|1[0-2])(0[1-9]|[12]\d|3[01])\d{3}[\dXx]$`)},
+	{"IP 地址", regexp.MustCompile(`^(\d{1,3}\.){3}\d{1,3}$`)},
+}
+
+// DetectPII 识别取值中的个人信息（邮箱、手机号、身份证号、银行卡号、IP 地址），返回类型，不是时返回空
+func DetectPII(value string) string {
+	value = strings.TrimSpace(value)
+	for _, rule := range piiRules {
+		if rule.re.MatchString(value) {
+			return rule.kind
+		}
+	}
+	if isCardNumber(value) {
+		return "银行卡号"
+	}
+	return ""
+}
+
+// isCardNumber 16-19 位数字且通过 Luhn 校验
+func isCardNumber(value string) bool {
+	if len(value) < 16 || len(value) > 19 {
+		return false
+	}
+	sum := 0
+	for i := len(value) - 1; i >= 0; i-- {
+		c := value[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if (len(value)-1-i)%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// Mask 脱敏取值：保留首尾字符和分隔符（- / : . @ 空格等），其余字母数字替换为 *，
+// 例如 2023-01-05 -> 2***-**-*5。不超过 2 个字符的取值全部替换
+func Mask(value string) string {
+	runes := []rune(value)
+	truncated := len(runes) > 32
+	if truncated {
+		runes = runes[:32]
+	}
+	var sb strings.Builder
+	for i, r := range runes {
+		keep := len(runes) > 2 && (i == 0 || (i == len(runes)-1 && !truncated))
+		if keep || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('*')
+		}
+	}
+	if truncated {
+		sb.WriteString("…")
+	}
+	return sb.String()
+}
+
+// keyedHash 以 key 为密钥的 HMAC-SHA256，取前 n 个十六进制字符
+func keyedHash(key, value string, n int) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:n]
+}
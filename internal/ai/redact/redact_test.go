@@ -0,0 +1,96 @@
+package redact
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicyNames(t *testing.T) {
+	p := &Policy{
+		NeverSendTables:     []string{"HR_*"},
+		NeverSendColumns:    []string{"*.cIDCard", "re:(?i)password"},
+		PseudonymizeTables:  []string{"dbo.Salary"},
+		PseudonymizeColumns: []string{"Salary.iAmount"},
+		MappingFile:         filepath.Join(t.TempDir(), "names.json"),
+	}
+	if err := p.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !p.WithholdTable("dbo.HR_Person") || p.WithholdTable("dbo.Person") {
+		t.Error("unexpected never-send table match")
+	}
+	if !p.WithholdColumn("dbo.Person", "cIDCard") || !p.WithholdColumn("Person", "cUserPassword") {
+		t.Error("expected never-send columns to match")
+	}
+	if !p.WithholdColumn("dbo.HR_Person", "cName") {
+		t.Error("expected columns of a never-send table to be withheld")
+	}
+
+	alias := p.Table("dbo.Salary")
+	if alias == "dbo.Salary" || p.Table("dbo.Salary") != alias || p.Table("dbo.Person") != "dbo.Person" {
+		t.Errorf("unexpected table pseudonym %q", alias)
+	}
+	column := p.Column("dbo.Salary", "iAmount")
+	if column == "iAmount" || p.Column("dbo.Person", "iAmount") != "iAmount" {
+		t.Errorf("unexpected column pseudonym %q", column)
+	}
+	if got := p.Restore(alias + "." + column + " 的金额"); got != "dbo.Salary.iAmount 的金额" {
+		t.Errorf("unexpected restored text %q", got)
+	}
+
+	// 同一个对照表文件再次加载，代号不变
+	if err := p.SaveMapping(); err != nil {
+		t.Fatal(err)
+	}
+	again := &Policy{PseudonymizeTables: p.PseudonymizeTables, MappingFile: p.MappingFile}
+	if err := again.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if again.Table("dbo.Salary") != alias {
+		t.Error("expected the saved mapping to give the same pseudonym")
+	}
+
+	if err := (&Policy{Values: "plain"}).Compile(); err == nil {
+		t.Error("expected invalid value mode to be rejected")
+	}
+}
+
+func TestSample(t *testing.T) {
+	p := DefaultPolicy()
+	values, pii := p.Sample([]string{"2023-01-05", "AB"})
+	if len(pii) != 0 || len(values) != 2 || values[0] != "2***-**-*5" || values[1] != "**" {
+		t.Errorf("unexpected masked values %v %v", values, pii)
+	}
+
+	values, pii = p.Sample([]string{"13800138000", "zhang@example.cn", "0101"})
+	if len(values) != 0 || len(pii) != 2 || pii[0] != "手机号" || pii[1] != "邮箱" {
+		t.Errorf("expected PII to suppress all samples, got %v %v", values, pii)
+	}
+
+	hashed := &Policy{Values: ValuesHash}
+	if err := hashed.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	values, _ = hashed.Sample([]string{"0101", "0101", "0102"})
+	if values[0] != values[1] || values[0] == values[2] || values[0] == "0101" {
+		t.Errorf("unexpected hashed values %v", values)
+	}
+}
+
+func TestDetectPII(t *testing.T) {
+	cases := map[string]string{
+		"110105199003071234": "身份证号",
+		"4111111111111111":   "银行卡号",
+		"+86 13912345678":    "手机号",
+		"192.168.1.10":       "IP 地址",
+		"4111111111111112":   "",
+		"SO20230105001":      "",
+		"张三丰":                "",
+	}
+	for value, want := range cases {
+		if got := DetectPII(value); got != want {
+			t.Errorf("DetectPII(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 调用控制的默认值
+const (
+	DefaultTimeout    = 120 * time.Second // 单次请求超时
+	DefaultMaxRetries = 3                 // 429 / 5xx / 网络错误的最大重试次数
+
+	retryBaseDelay = time.Second      // 第一次重试前的等待，之后每次翻倍
+	retryMaxDelay  = 30 * time.Second // 单次等待上限
+)
+
+// APIError 服务商返回的非 200 响应
+type APIError struct {
+	StatusCode int
+	Status     string
+	Message    string
+	RetryAfter time.Duration // 响应头 Retry-After，0 表示未提供
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API 调用失败: %s, %s", e.Status, e.Message)
+}
+
+// newAPIError 由响应和错误信息创建 APIError
+func newAPIError(resp *http.Response, message string) *APIError {
+	e := &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Message: message}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return e
+}
+
+// Retryable 错误是否值得重试：限流（429）、服务端错误（5xx）和网络错误（含超时）
+func Retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// RateLimiter 令牌桶限流：平均每秒放行 rate 个请求，允许 burst 个突发。nil 表示不限制
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter 创建限流器，rate <= 0 时返回 nil（不限制）
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait 等待一个令牌
+func (l *RateLimiter) Wait() {
+	if l == nil {
+		return
+	}
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
+
+// retryTransport 在服务商传输层外加限流和重试
+type retryTransport struct {
+	next       Transport
+	limiter    *RateLimiter
+	maxRetries int
+	baseDelay  time.Duration
+}
+
+// Chat 限流后调用，遇到可重试的错误按指数退避重试
+func (t *retryTransport) Chat(messages []Message) (string, error) {
+	for attempt := 0; ; attempt++ {
+		t.limiter.Wait()
+		content, err := t.next.Chat(messages)
+		if err == nil || attempt >= t.maxRetries || !Retryable(err) {
+			return content, err
+		}
+		delay := t.backoff(attempt, err)
+		fmt.Printf("    [AI] %v，%s 后重试（%d/%d）\n", err, delay.Round(time.Millisecond), attempt+1, t.maxRetries)
+		time.Sleep(delay)
+	}
+}
+
+// backoff 第 attempt 次重试前的等待：优先使用 Retry-After，否则指数退避加随机抖动
+func (t *retryTransport) backoff(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > retryMaxDelay {
+			return retryMaxDelay
+		}
+		return apiErr.RetryAfter
+	}
+	delay := t.baseDelay << uint(attempt)
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	// 抖动：在 [delay/2, delay) 之间随机，避免并发请求同时重试
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
@@ -0,0 +1,86 @@
+package ai
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyTransport 前 failures 次返回 err，之后成功
+type flakyTransport struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (t *flakyTransport) Chat(messages []Message) (string, error) {
+	t.calls++
+	if t.calls <= t.failures {
+		return "", t.err
+	}
+	return "ok", nil
+}
+
+func TestRetryTransport(t *testing.T) {
+	throttled := &APIError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests"}
+	flaky := &flakyTransport{failures: 2, err: throttled}
+	transport := &retryTransport{next: flaky, maxRetries: 3, baseDelay: time.Millisecond}
+	if content, err := transport.Chat(nil); err != nil || content != "ok" || flaky.calls != 3 {
+		t.Errorf("expected success on the third call, got %q, %v after %d calls", content, err, flaky.calls)
+	}
+
+	// 4xx（除 429）不重试
+	badRequest := &flakyTransport{failures: 5, err: &APIError{StatusCode: http.StatusBadRequest, Status: "400 Bad Request"}}
+	transport = &retryTransport{next: badRequest, maxRetries: 3, baseDelay: time.Millisecond}
+	if _, err := transport.Chat(nil); err == nil || badRequest.calls != 1 {
+		t.Errorf("expected 400 to fail without retry, got %v after %d calls", err, badRequest.calls)
+	}
+
+	// 重试次数用完后返回最后的错误
+	down := &flakyTransport{failures: 5, err: &APIError{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}}
+	transport = &retryTransport{next: down, maxRetries: 2, baseDelay: time.Millisecond}
+	var apiErr *APIError
+	if _, err := transport.Chat(nil); !errors.As(err, &apiErr) || down.calls != 3 {
+		t.Errorf("expected 502 after 3 calls, got %v after %d calls", err, down.calls)
+	}
+}
+
+func TestRetryAfterAndStatus(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"message": "rate limit exceeded"}}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(server.URL, "sk-test", "gpt-4o-mini")
+	_, err := client.Chat([]Message{{Role: "user", Content: "hi"}})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RetryAfter != 7*time.Second || !Retryable(err) {
+		t.Fatalf("expected retryable 429 with Retry-After, got %#v", err)
+	}
+	transport := &retryTransport{baseDelay: time.Second}
+	if delay := transport.backoff(0, err); delay != 7*time.Second {
+		t.Errorf("expected Retry-After to be honoured, got %s", delay)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(50, 1)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		limiter.Wait()
+	}
+	// 第一个令牌立即可用，之后每 20ms 一个
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected 4 requests at 50/s to take at least 60ms, took %s", elapsed)
+	}
+	if NewRateLimiter(0, 1) != nil {
+		t.Error("expected no limiter for rate 0")
+	}
+}
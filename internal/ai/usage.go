@@ -0,0 +1,221 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded 本次请求会超出 token 或费用上限。AI 增强应在此停止，已有结果照常输出
+var ErrBudgetExceeded = errors.New("超出 AI 用量上限")
+
+// Pricing 每千 token 的价格
+type Pricing struct {
+	Input    float64 `json:"input"`
+	Output   float64 `json:"output"`
+	Currency string  `json:"currency"` // ¥ / $
+}
+
+// 常用模型的参考价格（每千 token，以服务商公布的价格为准，可用 --ai-price-input / --ai-price-output 覆盖）
+var prices = map[string]Pricing{
+	"qwen-turbo":    {Input: 0.0003, Output: 0.0006, Currency: "¥"},
+	"qwen-plus":     {Input: 0.0008, Output: 0.002, Currency: "¥"},
+	"qwen-max":      {Input: 0.0024, Output: 0.0096, Currency: "¥"},
+	"deepseek-chat": {Input: 0.002, Output: 0.008, Currency: "¥"},
+	"gpt-4o-mini":   {Input: 0.00015, Output: 0.0006, Currency: "$"},
+	"gpt-4o":        {Input: 0.0025, Output: 0.01, Currency: "$"},
+}
+
+// LookupPricing 模型的参考价格。本地模型免费；未知模型返回 false
+func LookupPricing(provider, model string) (Pricing, bool) {
+	if IsLocal(provider) {
+		return Pricing{}, true
+	}
+	p, ok := prices[strings.ToLower(model)]
+	return p, ok
+}
+
+// Usage AI 用量统计和预算。token 数按 estimateTokens 估算（输入为发送的全部消息，输出为模型回复），
+// 费用按 Pricing 估算。所有方法对 nil 安全，nil 表示不统计、不限制
+type Usage struct {
+	mu sync.Mutex
+
+	Provider   string  `json:"provider"`
+	Model      string  `json:"model"`
+	Pricing    Pricing `json:"pricing"`
+	PriceKnown bool    `json:"price_known"` // false 时费用按 0 计
+	MaxTokens  int     `json:"max_tokens,omitempty"`
+	MaxCost    float64 `json:"max_cost,omitempty"`
+
+	Requests     int  `json:"requests"`
+	Failed       int  `json:"failed"`
+	Rejected     int  `json:"rejected"` // 因超出上限未发送的请求
+	InputTokens  int  `json:"input_tokens"`
+	OutputTokens int  `json:"output_tokens"`
+	Exhausted    bool `json:"exhausted"`
+
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+}
+
+// NewUsage 创建用量统计。maxTokens / maxCost 为 0 表示不限制
+func NewUsage(maxTokens int, maxCost float64) *Usage {
+	return &Usage{MaxTokens: maxTokens, MaxCost: maxCost, Started: time.Now()}
+}
+
+// SetPricing 设置价格，覆盖内置的参考价格
+func (u *Usage) SetPricing(p Pricing) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.Pricing, u.PriceKnown = p, true
+}
+
+// bind 记录服务商和模型，未设置价格时使用参考价格
+func (u *Usage) bind(provider, model string) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.Provider, u.Model = provider, model
+	if !u.PriceKnown {
+		u.Pricing, u.PriceKnown = LookupPricing(provider, model)
+	}
+}
+
+// reserve 发送前检查预算：已用量加上本次输入的估算超过上限时拒绝
+func (u *Usage) reserve(inputTokens int) error {
+	if u == nil {
+		return nil
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	// 一旦达到上限就不再发送，避免后续较小的请求继续消耗
+	if u.Exhausted {
+		u.Rejected++
+		return ErrBudgetExceeded
+	}
+	tokens := u.InputTokens + u.OutputTokens + inputTokens
+	cost := u.costLocked() + float64(inputTokens)/1000*u.Pricing.Input
+	if (u.MaxTokens > 0 && tokens > u.MaxTokens) || (u.MaxCost > 0 && cost > u.MaxCost) {
+		u.Rejected++
+		u.Exhausted = true
+		return ErrBudgetExceeded
+	}
+	return nil
+}
+
+// record 记录一次已发送的请求。失败的请求只计次数，不计 token
+func (u *Usage) record(inputTokens, outputTokens int, err error) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.Requests++
+	if err != nil {
+		u.Failed++
+		return
+	}
+	u.InputTokens += inputTokens
+	u.OutputTokens += outputTokens
+}
+
+// Cost 估算费用
+func (u *Usage) Cost() float64 {
+	if u == nil {
+		return 0
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.costLocked()
+}
+
+func (u *Usage) costLocked() float64 {
+	return float64(u.InputTokens)/1000*u.Pricing.Input + float64(u.OutputTokens)/1000*u.Pricing.Output
+}
+
+// Summary 一行用量摘要
+func (u *Usage) Summary() string {
+	if u == nil {
+		return ""
+	}
+	cost := u.Cost()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	text := fmt.Sprintf("%d 次请求，约 %d tokens（输入 %d，输出 %d）", u.Requests, u.InputTokens+u.OutputTokens, u.InputTokens, u.OutputTokens)
+	if u.PriceKnown {
+		text += fmt.Sprintf("，估算费用 %s%.4f", u.Pricing.Currency, cost)
+	} else {
+		text += "，模型价格未知（可用 --ai-price-input / --ai-price-output 指定）"
+	}
+	return text
+}
+
+// JSON 用量报告（JSON）
+func (u *Usage) JSON() ([]byte, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.Finished = time.Now()
+	report := struct {
+		*Usage
+		TotalTokens int     `json:"total_tokens"`
+		Cost        float64 `json:"estimated_cost"`
+	}{u, u.InputTokens + u.OutputTokens, u.costLocked()}
+	return json.MarshalIndent(report, "", "  ")
+}
+
+// Markdown 用量报告（Markdown）
+func (u *Usage) Markdown() string {
+	cost := u.Cost()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var sb strings.Builder
+	sb.WriteString("# AI 用量报告\n\n")
+	fmt.Fprintf(&sb, "- 服务商: %s\n- 模型: %s\n", u.Provider, u.Model)
+	fmt.Fprintf(&sb, "- 请求: %d 次（失败 %d，因超出上限未发送 %d）\n", u.Requests, u.Failed, u.Rejected)
+	fmt.Fprintf(&sb, "- Tokens（估算）: %d（输入 %d，输出 %d）\n", u.InputTokens+u.OutputTokens, u.InputTokens, u.OutputTokens)
+	if u.PriceKnown {
+		fmt.Fprintf(&sb, "- 估算费用: %s%.4f（每千 token 输入 %s%g，输出 %s%g）\n",
+			u.Pricing.Currency, cost, u.Pricing.Currency, u.Pricing.Input, u.Pricing.Currency, u.Pricing.Output)
+	} else {
+		sb.WriteString("- 估算费用: 模型价格未知\n")
+	}
+	if u.MaxTokens > 0 {
+		fmt.Fprintf(&sb, "- Token 上限: %d\n", u.MaxTokens)
+	}
+	if u.MaxCost > 0 {
+		fmt.Fprintf(&sb, "- 费用上限: %s%g\n", u.Pricing.Currency, u.MaxCost)
+	}
+	if u.Exhausted {
+		sb.WriteString("\n> ⚠️ 达到用量上限，AI 增强提前停止，未处理的表和字段保留算法结果。\n")
+	}
+	return sb.String()
+}
+
+// meteredTransport 统计每次请求的用量，超出上限时不再发送
+type meteredTransport struct {
+	next  Transport
+	usage *Usage
+}
+
+// Chat 检查预算后调用并记录用量
+func (t *meteredTransport) Chat(messages []Message) (string, error) {
+	input := 0
+	for _, m := range messages {
+		input += estimateTokens(m.Content)
+	}
+	if err := t.usage.reserve(input); err != nil {
+		return "", err
+	}
+	content, err := t.next.Chat(messages)
+	t.usage.record(input, estimateTokens(content), err)
+	return content, err
+}
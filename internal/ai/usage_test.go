@@ -0,0 +1,137 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/ai/cache"
+	"schema-analyzer/internal/ai/prompt"
+)
+
+func TestMeteredTransportBudget(t *testing.T) {
+	usage := NewUsage(100, 0)
+	usage.bind(ProviderDashScope, "qwen-plus")
+	transport := &meteredTransport{next: &flakyTransport{}, usage: usage}
+
+	if _, err := transport.Chat([]Message{{Role: "user", Content: strings.Repeat("字", 40)}}); err != nil {
+		t.Fatal(err)
+	}
+	if usage.Requests != 1 || usage.InputTokens != 41 || usage.OutputTokens != 1 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+	if cost := usage.Cost(); cost <= 0 {
+		t.Errorf("expected qwen-plus usage to have a cost, got %v", cost)
+	}
+
+	// 第二次请求会超过 100 tokens，不再发送；之后较小的请求也不再发送
+	if _, err := transport.Chat([]Message{{Role: "user", Content: strings.Repeat("字", 80)}}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected budget error, got %v", err)
+	}
+	if _, err := transport.Chat([]Message{{Role: "user", Content: "字"}}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected enrichment to stay stopped, got %v", err)
+	}
+	if usage.Requests != 1 || usage.Rejected != 2 || !usage.Exhausted {
+		t.Errorf("unexpected usage after exhaustion %+v", usage)
+	}
+	if md := usage.Markdown(); !strings.Contains(md, "达到用量上限") {
+		t.Errorf("expected report to mention the exhausted budget:\n%s", md)
+	}
+}
+
+// moduleTables 三个模块各 30 张表，提示词上限较小时需要分组分析关系
+func moduleTables() []adapter.Table {
+	var tables []adapter.Table
+	for _, module := range []string{"SO", "PU", "ST"} {
+		for i := 0; i < 30; i++ {
+			tables = append(tables, adapter.Table{Name: fmt.Sprintf("%s_Table%02d", module, i), Columns: []adapter.Column{
+				{Name: "ID", IsPrimaryKey: true}, {Name: "cCode"}, {Name: "cName"}, {Name: "dDate"},
+			}})
+		}
+	}
+	return tables
+}
+
+func TestRelationshipChunks(t *testing.T) {
+	tables := moduleTables()
+	transport := &scriptedTransport{}
+	for i := 0; i < 10; i++ {
+		transport.responses = append(transport.responses, `[]`)
+	}
+	client := newPromptClient(transport)
+	client.setPromptLimit(800)
+
+	if _, err := client.AnalyzeTableRelationships(tables); err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.requests) < 3 {
+		t.Fatalf("expected the tables to be split into several requests, got %d", len(transport.requests))
+	}
+	for i, request := range transport.requests {
+		text := request[0].Content + request[1].Content
+		if tokens := estimateTokens(text); tokens > 800 {
+			t.Errorf("request %d has about %d tokens, over the 800 limit", i+1, tokens)
+		}
+		// 每个模块的表不与其他模块混在同一组，除非模块本身放不下
+		modules := 0
+		for _, module := range []string{"SO_", "PU_", "ST_"} {
+			if strings.Contains(request[1].Content, module) {
+				modules++
+			}
+		}
+		if modules > 2 {
+			t.Errorf("request %d mixes %d modules", i+1, modules)
+		}
+	}
+}
+
+// budgetTransport 前 allowed 次请求正常转发，之后返回超出用量上限
+type budgetTransport struct {
+	next    Transport
+	allowed int
+}
+
+func (t *budgetTransport) Chat(messages []Message) (string, error) {
+	if t.allowed == 0 {
+		return "", ErrBudgetExceeded
+	}
+	t.allowed--
+	return t.next.Chat(messages)
+}
+
+func TestRelationshipChunksPartialFailure(t *testing.T) {
+	store, err := cache.Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scripted := &scriptedTransport{}
+	for i := 0; i < 2; i++ {
+		scripted.responses = append(scripted.responses,
+			`[{"from_table": "PU_Table01", "to_table": "PU_Table00", "relation_type": "one_to_many", "confidence": 0.9}]`)
+	}
+	transport := &budgetTransport{next: scripted, allowed: 1}
+	inner := newPromptClient(transport)
+	inner.setPromptLimit(800)
+	client := NewCachedClient(inner, store, ProviderDashScope, DefaultDashScopeModel, prompt.Default().Version())
+
+	// 第一组成功后用量耗尽：返回第一组的结果，同时报告部分失败
+	relationships, err := client.AnalyzeTableRelationships(moduleTables())
+	var partial *PartialError
+	if !errors.As(err, &partial) || !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected a partial budget failure, got %v", err)
+	}
+	if partial.Failed != partial.Total-1 || len(relationships) != 1 {
+		t.Errorf("expected the first chunk's result and the rest reported as failed, got %+v and %+v", partial, relationships)
+	}
+
+	// 不完整的结果不进缓存，再次分析时重新请求
+	transport.allowed = 1
+	if _, err := client.AnalyzeTableRelationships(moduleTables()); !errors.As(err, &partial) {
+		t.Errorf("expected the partial result not to be cached, got %v", err)
+	}
+	if len(scripted.requests) != 2 {
+		t.Errorf("expected a second request after the partial failure, got %d", len(scripted.requests))
+	}
+}
@@ -0,0 +1,293 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"schema-analyzer/internal/adapter"
+	"sort"
+)
+
+// LabeledPair 带标注的候选关系（用于标定和评估）
+type LabeledPair struct {
+	FromTable  string `json:"from_table"`
+	FromColumn string `json:"from_column"`
+	ToTable    string `json:"to_table"`
+	ToColumn   string `json:"to_column"`
+	Related    bool   `json:"related"`
+}
+
+// Key 关系键，与推断边的 ID 一致
+func (p LabeledPair) Key() string {
+	return fmt.Sprintf("%s.%s->%s.%s", p.FromTable, p.FromColumn, p.ToTable, p.ToColumn)
+}
+
+// LoadLabeledPairs 从 JSON 文件加载标注集
+func LoadLabeledPairs(path string) ([]LabeledPair, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pairs []LabeledPair
+	if err := json.Unmarshal(data, &pairs); err != nil {
+		return nil, fmt.Errorf("解析标注文件失败: %v", err)
+	}
+	return pairs, nil
+}
+
+// CalibrationSample 标定样本
+type CalibrationSample struct {
+	Pair     LabeledPair
+	Features map[string]float64 // 原始证据得分
+}
+
+// CalibrationReport 标定结果
+type CalibrationReport struct {
+	Samples   int
+	Positives int
+	Accuracy  float64
+	Precision float64
+	Recall    float64
+	F1        float64
+	Threshold float64
+}
+
+// BuildLabeledPairs 以声明的外键作为正样本，并为每个外键列挑选命名最接近的其他主键作为负样本
+func (r *RelationshipInferer) BuildLabeledPairs(meta *adapter.SchemaMetadata, fks []adapter.ForeignKey, negativesPerPositive int) []LabeledPair {
+	columns := indexColumns(meta)
+	declared := make(map[string]bool)
+	var pairs []LabeledPair
+
+	for _, fk := range fks {
+		pair := LabeledPair{
+			FromTable:  fk.FromTable,
+			FromColumn: fk.FromColumn,
+			ToTable:    fk.ToTable,
+			ToColumn:   fk.ToColumn,
+			Related:    true,
+		}
+		declared[pair.Key()] = true
+		pairs = append(pairs, pair)
+	}
+
+	positives := len(pairs)
+	for i := 0; i < positives; i++ {
+		fromCol, ok := columns[pairs[i].FromTable][pairs[i].FromColumn]
+		if !ok {
+			continue
+		}
+
+		type candidate struct {
+			pair  LabeledPair
+			score float64
+		}
+		var candidates []candidate
+		for _, table := range meta.Tables {
+			if table.QualifiedName() == pairs[i].FromTable {
+				continue
+			}
+			for _, col := range table.Columns {
+				if !col.IsPrimaryKey {
+					continue
+				}
+				neg := LabeledPair{
+					FromTable:  pairs[i].FromTable,
+					FromColumn: pairs[i].FromColumn,
+					ToTable:    table.QualifiedName(),
+					ToColumn:   col.Name,
+				}
+				if declared[neg.Key()] {
+					continue
+				}
+				// 命名和类型越接近，越是有价值的"难"负样本
+				score := r.calculateNameSimilarity(fromCol.Name, col.Name) +
+					r.calculateTableNameMatch(fromCol.Name, table.QualifiedName(), col.Name) +
+					r.calculateTypeMatch(fromCol, col)
+				candidates = append(candidates, candidate{pair: neg, score: score})
+			}
+		}
+
+		sort.SliceStable(candidates, func(a, b int) bool {
+			return candidates[a].score > candidates[b].score
+		})
+		for j := 0; j < len(candidates) && j < negativesPerPositive; j++ {
+			declared[candidates[j].pair.Key()] = true
+			pairs = append(pairs, candidates[j].pair)
+		}
+	}
+
+	return pairs
+}
+
+// CollectSamples 计算标注集中每个候选关系的原始证据得分
+func (r *RelationshipInferer) CollectSamples(meta *adapter.SchemaMetadata, pairs []LabeledPair) []CalibrationSample {
+	columns := indexColumns(meta)
+	var samples []CalibrationSample
+
+	for i, pair := range pairs {
+		fromCol, ok1 := columns[pair.FromTable][pair.FromColumn]
+		toCol, ok2 := columns[pair.ToTable][pair.ToColumn]
+		if !ok1 || !ok2 {
+			fmt.Printf("  ⚠️  跳过不存在的列: %s\n", pair.Key())
+			continue
+		}
+		if (i+1)%50 == 0 {
+			fmt.Printf("  进度: %d/%d\n", i+1, len(pairs))
+		}
+		samples = append(samples, CalibrationSample{
+			Pair:     pair,
+			Features: r.Features(pair.FromTable, fromCol, pair.ToTable, toCol),
+		})
+	}
+
+	return samples
+}
+
+// Calibrate 用逻辑回归拟合证据权重，并选取 F1 最高的置信度阈值
+// 证据采纳阈值沿用 base 模型，保证标定与推断时看到的特征一致
+func Calibrate(samples []CalibrationSample, base *ScoringModel) (*ScoringModel, *CalibrationReport, error) {
+	if len(samples) == 0 {
+		return nil, nil, fmt.Errorf("没有可用的标定样本")
+	}
+
+	positives := 0
+	for _, s := range samples {
+		if s.Pair.Related {
+			positives++
+		}
+	}
+	if positives == 0 || positives == len(samples) {
+		return nil, nil, fmt.Errorf("标定样本需要同时包含正样本和负样本")
+	}
+
+	// 特征矩阵
+	n := len(EvidenceTypes)
+	xs := make([][]float64, len(samples))
+	ys := make([]float64, len(samples))
+	for i, s := range samples {
+		accepted := AcceptedFeatures(base, s.Features)
+		xs[i] = make([]float64, n)
+		for j, evidenceType := range EvidenceTypes {
+			xs[i][j] = accepted[evidenceType]
+		}
+		if s.Pair.Related {
+			ys[i] = 1
+		}
+	}
+
+	// 类别平衡权重，避免负样本过多时模型退化
+	posWeight := float64(len(samples)) / (2 * float64(positives))
+	negWeight := float64(len(samples)) / (2 * float64(len(samples)-positives))
+
+	weights := make([]float64, n)
+	bias := 0.0
+	const (
+		epochs       = 5000
+		learningRate = 0.5
+		l2           = 0.001
+	)
+
+	for epoch := 0; epoch < epochs; epoch++ {
+		gradW := make([]float64, n)
+		gradB := 0.0
+		for i, x := range xs {
+			z := bias
+			for j := range x {
+				z += weights[j] * x[j]
+			}
+			w := negWeight
+			if ys[i] == 1 {
+				w = posWeight
+			}
+			diff := (sigmoid(z) - ys[i]) * w
+			for j := range x {
+				gradW[j] += diff * x[j]
+			}
+			gradB += diff
+		}
+		m := float64(len(xs))
+		for j := range weights {
+			weights[j] -= learningRate * (gradW[j]/m + l2*weights[j])
+		}
+		bias -= learningRate * gradB / m
+	}
+
+	model := &ScoringModel{
+		Kind:        ScorerLogistic,
+		Weights:     make(map[string]float64),
+		Bias:        bias,
+		MinEvidence: make(map[string]float64),
+	}
+	for j, evidenceType := range EvidenceTypes {
+		model.Weights[evidenceType] = weights[j]
+		model.MinEvidence[evidenceType] = base.EvidenceThreshold(evidenceType)
+	}
+
+	// 选择 F1 最高的阈值
+	var best *CalibrationReport
+	for i := 1; i < 20; i++ {
+		t := float64(i) / 20
+		model.MinScore = t
+		report := evaluateSamples(model, samples)
+		if best == nil || report.F1 > best.F1 {
+			best = report
+		}
+	}
+	model.MinScore = best.Threshold
+
+	return model, best, nil
+}
+
+// evaluateSamples 在标定样本上评估模型
+func evaluateSamples(model *ScoringModel, samples []CalibrationSample) *CalibrationReport {
+	var tp, fp, fn, tn int
+	for _, s := range samples {
+		predicted := model.Score(AcceptedFeatures(model, s.Features)) > model.Threshold()
+		switch {
+		case predicted && s.Pair.Related:
+			tp++
+		case predicted && !s.Pair.Related:
+			fp++
+		case !predicted && s.Pair.Related:
+			fn++
+		default:
+			tn++
+		}
+	}
+
+	report := &CalibrationReport{
+		Samples:   len(samples),
+		Positives: tp + fn,
+		Accuracy:  float64(tp+tn) / float64(len(samples)),
+		Threshold: model.Threshold(),
+	}
+	report.Precision, report.Recall, report.F1 = PrecisionRecallF1(tp, fp, fn)
+	return report
+}
+
+// PrecisionRecallF1 计算精确率、召回率和 F1
+func PrecisionRecallF1(tp, fp, fn int) (precision, recall, f1 float64) {
+	if tp+fp > 0 {
+		precision = float64(tp) / float64(tp+fp)
+	}
+	if tp+fn > 0 {
+		recall = float64(tp) / float64(tp+fn)
+	}
+	if precision+recall > 0 {
+		f1 = 2 * precision * recall / (precision + recall)
+	}
+	return
+}
+
+// indexColumns 构建 表名 -> 列名 -> 列 索引
+func indexColumns(meta *adapter.SchemaMetadata) map[string]map[string]adapter.Column {
+	index := make(map[string]map[string]adapter.Column)
+	for _, table := range meta.Tables {
+		cols := make(map[string]adapter.Column)
+		for _, col := range table.Columns {
+			cols[col.Name] = col
+		}
+		index[table.QualifiedName()] = cols
+	}
+	return index
+}
@@ -1,18 +1,73 @@
 package analyzer
 
 import (
+	"fmt"
 	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/graph"
+	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
 // EnumDetector 枚举/码表检测器
 type EnumDetector struct {
-	adapter adapter.DBAdapter
+	adapter    adapter.DBAdapter
+	sampleSize int
+	naming     NamingProfile
+	evidence   *EvidenceCollector             // 扫描时已收集的采样统计
+	stats      map[string]*adapter.ColumnStats // 本检测器采样的统计，表.列 -> 统计
+
+	MaxEnumValues     int   // 码表最多提取的取值数
+	InlineMinRows     int64 // 只在行数不少于该值的表中检测枚举列
+	InlineMaxDistinct int64 // 枚举列最多的不同取值数
+	InlineMaxLength   int   // 枚举列取值的最大长度
 }
 
 // NewEnumDetector 创建检测器
 func NewEnumDetector(adapter adapter.DBAdapter) *EnumDetector {
-	return &EnumDetector{adapter: adapter}
+	return &EnumDetector{
+		adapter:           adapter,
+		sampleSize:        1000,
+		naming:            U8NamingProfile,
+		MaxEnumValues:     200,
+		InlineMinRows:     1000,
+		InlineMaxDistinct: 10,
+		InlineMaxLength:   20,
+	}
+}
+
+// SetSampleSize 设置枚举列检测的采样大小
+func (e *EnumDetector) SetSampleSize(n int) {
+	if n > 0 {
+		e.sampleSize = n
+	}
+}
+
+// SetNamingProfile 设置匹配码表引用列时使用的命名规范
+func (e *EnumDetector) SetNamingProfile(profile NamingProfile) {
+	e.naming = profile
+}
+
+// SetEvidence 使用扫描时已收集的采样统计，避免再次采样，没有统计的列才查询数据库
+func (e *EnumDetector) SetEvidence(evidence *EvidenceCollector) {
+	e.evidence = evidence
+}
+
+// columnStats 列的采样统计：优先使用已收集的统计，其次是本检测器采样过的，都没有时采样一次
+func (e *EnumDetector) columnStats(table, column string) *adapter.ColumnStats {
+	if stats := e.evidence.Stats(table, column); stats != nil {
+		return stats
+	}
+	if e.stats == nil {
+		e.stats = make(map[string]*adapter.ColumnStats)
+	}
+	key := table + "." + column
+	stats, cached := e.stats[key]
+	if !cached {
+		stats, _ = e.adapter.SampleColumnStats(table, column, e.sampleSize)
+		e.stats[key] = stats
+	}
+	return stats
 }
 
 // EnumTable 枚举表
@@ -23,6 +78,17 @@ type EnumTable struct {
 	ValueColumn   string
 	Confidence    float64
 	ReferencedBy  []string // 被哪些表引用
+	Values        []graph.EnumValue // 键→含义
+}
+
+// EnumColumn 大表中的内联枚举列（如状态、类型字段）
+type EnumColumn struct {
+	Table         string
+	Column        string
+	SampledRows   int64
+	DistinctCount int64
+	Values        []graph.EnumValue // 取值→出现次数
+	Confidence    float64
 }
 
 // DetectEnumTables 检测枚举表
@@ -31,7 +97,7 @@ func (e *EnumDetector) DetectEnumTables(meta *adapter.SchemaMetadata) ([]EnumTab
 	
 	for _, table := range meta.Tables {
 		// 估算行数
-		rowCount, err := e.adapter.EstimateRowCount(table.Name)
+		rowCount, err := e.adapter.EstimateRowCount(table.QualifiedName())
 		if err != nil {
 			continue
 		}
@@ -50,11 +116,12 @@ func (e *EnumDetector) DetectEnumTables(meta *adapter.SchemaMetadata) ([]EnumTab
 		confidence := e.calculateEnumConfidence(table, rowCount, keyCol, valueCol)
 		if confidence > 0.6 {
 			enumTables = append(enumTables, EnumTable{
-				Name:        table.Name,
+				Name:        table.QualifiedName(),
 				RowCount:    rowCount,
 				KeyColumn:   keyCol,
 				ValueColumn: valueCol,
 				Confidence:  confidence,
+				Values:      e.extractValues(table.QualifiedName(), keyCol, valueCol),
 			})
 		}
 	}
@@ -62,6 +129,344 @@ func (e *EnumDetector) DetectEnumTables(meta *adapter.SchemaMetadata) ([]EnumTab
 	return enumTables, nil
 }
 
+// extractValues 提取码表的完整取值集合，没有含义列时只提取键
+func (e *EnumDetector) extractValues(table, keyCol, valueCol string) []graph.EnumValue {
+	labelCol := valueCol
+	if labelCol == "" {
+		labelCol = keyCol
+	}
+	pairs, err := e.adapter.GetValuePairs(table, keyCol, labelCol, e.MaxEnumValues)
+	if err != nil {
+		return nil
+	}
+
+	values := make([]graph.EnumValue, 0, len(pairs))
+	for _, p := range pairs {
+		v := graph.EnumValue{Key: p.Key}
+		if valueCol != "" {
+			v.Label = p.Label
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// DetectEnumColumns 根据取值分布检测大表中的内联枚举列：
+// 不同取值少、取值短、且前几个取值覆盖了几乎全部采样行
+func (e *EnumDetector) DetectEnumColumns(meta *adapter.SchemaMetadata) ([]EnumColumn, error) {
+	var enumColumns []EnumColumn
+
+	for _, table := range meta.Tables {
+		tableName := table.QualifiedName()
+		rowCount, err := e.adapter.EstimateRowCount(tableName)
+		if err != nil || rowCount < e.InlineMinRows {
+			continue
+		}
+
+		for _, col := range table.Columns {
+			if col.IsPrimaryKey || !isEnumCandidateType(col.DataType) {
+				continue
+			}
+
+			stats := e.columnStats(tableName, col.Name)
+			if stats == nil {
+				continue
+			}
+			if ec, ok := e.evaluateEnumColumn(tableName, col.Name, stats); ok {
+				enumColumns = append(enumColumns, ec)
+			}
+		}
+	}
+
+	return enumColumns, nil
+}
+
+// evaluateEnumColumn 判断采样分布是否符合枚举列特征
+func (e *EnumDetector) evaluateEnumColumn(table, column string, stats *adapter.ColumnStats) (EnumColumn, bool) {
+	nonNull := stats.TotalRows - stats.NullCount
+	if nonNull < 20 || stats.DistinctCount < 2 || stats.DistinctCount > e.InlineMaxDistinct {
+		return EnumColumn{}, false
+	}
+
+	// 不同取值占比要足够低，否则只是采样太少
+	distinctRatio := float64(stats.DistinctCount) / float64(nonNull)
+	if distinctRatio > 0.2 {
+		return EnumColumn{}, false
+	}
+
+	var covered int64
+	values := make([]graph.EnumValue, 0, len(stats.TopValues))
+	for _, vc := range stats.TopValues {
+		if utf8.RuneCountInString(vc.Value) > e.InlineMaxLength {
+			return EnumColumn{}, false
+		}
+		covered += vc.Count
+		values = append(values, graph.EnumValue{Key: vc.Value, Count: vc.Count})
+	}
+
+	// 取值稳定：前几个取值覆盖几乎全部非空行
+	coverage := float64(covered) / float64(nonNull)
+	if coverage < 0.95 {
+		return EnumColumn{}, false
+	}
+
+	confidence := 0.5
+	if stats.DistinctCount <= 5 {
+		confidence += 0.2
+	} else {
+		confidence += 0.1
+	}
+	if distinctRatio <= 0.01 {
+		confidence += 0.2
+	} else if distinctRatio <= 0.05 {
+		confidence += 0.1
+	}
+	if hasEnumNameHint(column) {
+		confidence += 0.1
+	}
+	if confidence > 1.0 {
+		confidence = 1.0
+	}
+	if confidence < 0.6 {
+		return EnumColumn{}, false
+	}
+
+	return EnumColumn{
+		Table:         table,
+		Column:        column,
+		SampledRows:   stats.TotalRows,
+		DistinctCount: stats.DistinctCount,
+		Values:        values,
+		Confidence:    confidence,
+	}, true
+}
+
+// isEnumCandidateType 枚举列通常是短字符串、整数或布尔类型
+func isEnumCandidateType(dataType string) bool {
+	switch toLower(dataType) {
+	case "char", "nchar", "varchar", "nvarchar", "tinyint", "smallint", "int", "bit", "enum":
+		return true
+	}
+	return false
+}
+
+// hasEnumNameHint 列名暗示状态/类型等枚举含义
+func hasEnumNameHint(column string) bool {
+	colLower := toLower(column)
+	for _, hint := range []string{"status", "state", "type", "flag", "kind", "class", "level", "mode", "sex", "gender"} {
+		if contains(colLower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkEnumReferences 为码表找出引用列：列名与码表键列或表名匹配、类型兼容，
+// 且采样取值基本都落在码表的键集合内。引用列会记录到 EnumTable.ReferencedBy
+func (e *EnumDetector) LinkEnumReferences(meta *adapter.SchemaMetadata, enumTables []EnumTable) []*graph.Edge {
+	return e.linkReferences(meta, meta.Tables, enumTables)
+}
+
+// LinkChangedReferences 只在变化的表中查找对码表的引用。
+// 用于增量扫描时沿用基线的码表：未变化的表对它们的引用边已随基线沿用
+func (e *EnumDetector) LinkChangedReferences(meta, changed *adapter.SchemaMetadata, enumTables []EnumTable) []*graph.Edge {
+	return e.linkReferences(meta, changed.Tables, enumTables)
+}
+
+// linkReferences 在 tables 中查找引用码表的列，码表键列的定义从 meta 中查找
+func (e *EnumDetector) linkReferences(meta *adapter.SchemaMetadata, tables []adapter.Table, enumTables []EnumTable) []*graph.Edge {
+	var edges []*graph.Edge
+	solePK := solePrimaryKeys(meta)
+
+	for i := range enumTables {
+		et := &enumTables[i]
+		if len(et.Values) == 0 {
+			continue
+		}
+		keySet := make(map[string]bool, len(et.Values))
+		for _, v := range et.Values {
+			keySet[v.Key] = true
+		}
+		keyCol, ok := findColumn(meta, et.Name, et.KeyColumn)
+		if !ok {
+			continue
+		}
+
+		for _, table := range tables {
+			tableName := table.QualifiedName()
+			if tableName == et.Name {
+				continue
+			}
+			for _, col := range table.Columns {
+				if solePK[tableName] == col.Name || !typesCompatible(col.DataType, keyCol.DataType) {
+					continue
+				}
+				nameScore := e.enumNameMatch(col.Name, et.Name, et.KeyColumn)
+				if nameScore == 0 {
+					continue
+				}
+
+				containment := enumContainment(e.columnStats(tableName, col.Name), keySet)
+				if containment < 0.9 {
+					continue
+				}
+
+				et.ReferencedBy = append(et.ReferencedBy, tableName+"."+col.Name)
+				edges = append(edges, newEnumEdge(tableName, col.Name, et, containment, nameScore))
+			}
+		}
+	}
+
+	return edges
+}
+
+// enumNameMatch 引用列名与码表的匹配程度：与键列同名 1.0，词干相同 0.8，词干是码表名前缀 0.6
+func (e *EnumDetector) enumNameMatch(column, enumTable, keyColumn string) float64 {
+	if strings.EqualFold(column, keyColumn) {
+		return 1.0
+	}
+	stem := e.naming.columnStem(column)
+	if stem == "" {
+		return 0
+	}
+	if stem == e.naming.columnStem(keyColumn) {
+		return 0.8
+	}
+	if len(stem) >= 3 && strings.HasPrefix(e.naming.tableStem(enumTable), stem) {
+		return 0.6
+	}
+	return 0
+}
+
+// enumContainment 采样取值（按出现次数加权）落在码表键集合内的比例
+func enumContainment(stats *adapter.ColumnStats, keySet map[string]bool) float64 {
+	if stats == nil {
+		return 0
+	}
+	var total, matched int64
+	for _, vc := range stats.TopValues {
+		total += vc.Count
+		if keySet[vc.Value] {
+			matched += vc.Count
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(matched) / float64(total)
+}
+
+// newEnumEdge 创建码表引用边
+func newEnumEdge(table, column string, et *EnumTable, containment, nameScore float64) *graph.Edge {
+	return &graph.Edge{
+		ID:         fmt.Sprintf("enum:%s.%s->%s.%s", table, column, et.Name, et.KeyColumn),
+		Type:       graph.EdgeTypeEnum,
+		From:       fmt.Sprintf("%s.%s", table, column),
+		To:         fmt.Sprintf("%s.%s", et.Name, et.KeyColumn),
+		Confidence: 0.7*containment + 0.3*nameScore,
+		Evidence: []graph.Evidence{
+			{
+				Type:        EvidenceContainment,
+				Score:       containment,
+				Description: "取值包含于码表键",
+				Details:     fmt.Sprintf("%.1f%% 的采样取值出现在 %s.%s 中", containment*100, et.Name, et.KeyColumn),
+			},
+			{
+				Type:        EvidenceNaming,
+				Score:       nameScore,
+				Description: "列名匹配码表",
+				Details:     fmt.Sprintf("%s ↔ %s.%s", column, et.Name, et.KeyColumn),
+			},
+		},
+		Properties: map[string]interface{}{
+			"from_table":  table,
+			"from_column": column,
+			"to_table":    et.Name,
+			"to_column":   et.KeyColumn,
+		},
+	}
+}
+
+// findColumn 在元数据中查找列定义
+func findColumn(meta *adapter.SchemaMetadata, table, column string) (adapter.Column, bool) {
+	for _, t := range meta.Tables {
+		if t.QualifiedName() != table {
+			continue
+		}
+		for _, c := range t.Columns {
+			if c.Name == column {
+				return c, true
+			}
+		}
+	}
+	return adapter.Column{}, false
+}
+
+// AddEnumReferences 把码表引用边加入图，并在引用列节点上标记所引用的码表
+func AddEnumReferences(g *graph.SchemaGraph, edges []*graph.Edge) {
+	for _, edge := range edges {
+		g.AddEdge(edge)
+		if node := g.GetNode(edge.From); node != nil {
+			node.Properties["enum_table"] = edge.Properties["to_table"]
+		}
+	}
+}
+
+// CarriedEnumTables 增量扫描时从图中取回沿用基线的码表，须在 AnnotateEnums 之前调用
+// （此时带码表标记的只有沿用的表节点）。ReferencedBy 只保留仍在图中且未变化的表，
+// 它们的引用边随基线沿用；变化的表需要用 LinkChangedReferences 重新关联
+func CarriedEnumTables(g *graph.SchemaGraph, changed map[string]bool) []EnumTable {
+	var tables []EnumTable
+	for id, node := range g.Nodes {
+		if node.Type != graph.NodeTypeTable || node.Properties["enum_kind"] != "code_table" {
+			continue
+		}
+		et := EnumTable{Name: id, Values: node.EnumValues}
+		et.KeyColumn, _ = node.Properties["enum_key_column"].(string)
+		et.ValueColumn, _ = node.Properties["enum_value_column"].(string)
+		et.Confidence, _ = node.Properties["enum_confidence"].(float64)
+		refs, _ := node.Properties["referenced_by"].([]string)
+		for _, ref := range refs {
+			i := strings.LastIndex(ref, ".")
+			if i < 0 {
+				continue
+			}
+			if table := ref[:i]; !changed[table] && g.GetNode(table) != nil {
+				et.ReferencedBy = append(et.ReferencedBy, ref)
+			}
+		}
+		tables = append(tables, et)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables
+}
+
+// AnnotateEnums 把检测到的码表和枚举列及其取值集合写入图节点
+func AnnotateEnums(g *graph.SchemaGraph, tables []EnumTable, columns []EnumColumn) {
+	for _, et := range tables {
+		node := g.GetNode(et.Name)
+		if node == nil {
+			continue
+		}
+		node.Properties["enum_kind"] = "code_table"
+		node.Properties["enum_key_column"] = et.KeyColumn
+		node.Properties["enum_value_column"] = et.ValueColumn
+		node.Properties["enum_confidence"] = et.Confidence
+		node.Properties["referenced_by"] = et.ReferencedBy
+		node.EnumValues = et.Values
+	}
+
+	for _, ec := range columns {
+		node := g.GetNode(fmt.Sprintf("%s.%s", ec.Table, ec.Column))
+		if node == nil {
+			continue
+		}
+		node.Properties["enum_kind"] = "inline"
+		node.Properties["enum_confidence"] = ec.Confidence
+		node.EnumValues = ec.Values
+	}
+}
+
 // findEnumColumns 查找枚举列
 func (e *EnumDetector) findEnumColumns(columns []adapter.Column) (keyCol, valueCol string) {
 	keyPatterns := []string{"code", "id", "key", "type"}
@@ -0,0 +1,235 @@
+package analyzer
+
+import (
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/graph"
+	"schema-analyzer/internal/incremental"
+	"testing"
+)
+
+func TestEvaluateEnumColumn(t *testing.T) {
+	e := NewEnumDetector(nil)
+
+	status := &adapter.ColumnStats{
+		TotalRows:     1000,
+		NullCount:     10,
+		DistinctCount: 3,
+		TopValues: []adapter.ValueCount{
+			{Value: "0", Count: 700},
+			{Value: "1", Count: 250},
+			{Value: "2", Count: 40},
+		},
+	}
+	ec, ok := e.evaluateEnumColumn("SO_SOMain", "iStatus", status)
+	if !ok {
+		t.Fatal("expected iStatus to be detected as enum column")
+	}
+	if len(ec.Values) != 3 || ec.Values[0].Key != "0" || ec.Values[0].Count != 700 {
+		t.Errorf("unexpected enum values %+v", ec.Values)
+	}
+	if ec.Confidence < 0.9 {
+		t.Errorf("expected high confidence for low-cardinality status column, got %.2f", ec.Confidence)
+	}
+
+	tests := []struct {
+		name  string
+		stats *adapter.ColumnStats
+	}{
+		{"too many distinct values", &adapter.ColumnStats{TotalRows: 1000, DistinctCount: 50}},
+		{"sample too small", &adapter.ColumnStats{TotalRows: 10, DistinctCount: 2}},
+		{"long values", &adapter.ColumnStats{TotalRows: 1000, DistinctCount: 2, TopValues: []adapter.ValueCount{
+			{Value: "这是一段很长很长很长很长很长很长很长的备注文字", Count: 500},
+			{Value: "另一段很长很长很长很长很长很长的备注文字", Count: 500},
+		}}},
+		{"unstable top values", &adapter.ColumnStats{TotalRows: 1000, DistinctCount: 8, TopValues: []adapter.ValueCount{
+			{Value: "A", Count: 400},
+			{Value: "B", Count: 300},
+		}}},
+	}
+	for _, tt := range tests {
+		if _, ok := e.evaluateEnumColumn("T", "c", tt.stats); ok {
+			t.Errorf("%s: expected column to be rejected", tt.name)
+		}
+	}
+}
+
+// stubAdapter 只提供固定列统计的测试适配器
+type stubAdapter struct {
+	adapter.DBAdapter
+	stats   map[string]*adapter.ColumnStats
+	rows    int64
+	sampled int // SampleColumnStats 的调用次数
+}
+
+func (s *stubAdapter) SampleColumnStats(table, column string, sampleSize int) (*adapter.ColumnStats, error) {
+	s.sampled++
+	return s.stats[table+"."+column], nil
+}
+
+func (s *stubAdapter) EstimateRowCount(table string) (int64, error) {
+	return s.rows, nil
+}
+
+func TestLinkEnumReferences(t *testing.T) {
+	meta := &adapter.SchemaMetadata{Tables: []adapter.Table{
+		{Name: "Department", Columns: []adapter.Column{
+			{Name: "cDepCode", DataType: "nvarchar", IsPrimaryKey: true},
+			{Name: "cDepName", DataType: "nvarchar"},
+		}},
+		{Name: "SO_SOMain", Columns: []adapter.Column{
+			{Name: "ID", DataType: "int", IsPrimaryKey: true},
+			{Name: "cDepCode", DataType: "nvarchar"},
+			{Name: "cMemo", DataType: "nvarchar"},
+		}},
+		{Name: "PO_POMain", Columns: []adapter.Column{
+			{Name: "POID", DataType: "int", IsPrimaryKey: true},
+			{Name: "cDepCode", DataType: "nvarchar"},
+		}},
+	}}
+	db := &stubAdapter{stats: map[string]*adapter.ColumnStats{
+		"SO_SOMain.cDepCode": {TopValues: []adapter.ValueCount{{Value: "01", Count: 30}, {Value: "02", Count: 10}}},
+		// 大部分取值不在码表中
+		"PO_POMain.cDepCode": {TopValues: []adapter.ValueCount{{Value: "99", Count: 30}, {Value: "01", Count: 1}}},
+	}}
+
+	enumTables := []EnumTable{{
+		Name:        "Department",
+		KeyColumn:   "cDepCode",
+		ValueColumn: "cDepName",
+		Values:      []graph.EnumValue{{Key: "01", Label: "销售部"}, {Key: "02", Label: "采购部"}},
+	}}
+
+	edges := NewEnumDetector(db).LinkEnumReferences(meta, enumTables)
+	if len(edges) != 1 {
+		t.Fatalf("expected 1 enum reference, got %d", len(edges))
+	}
+	edge := edges[0]
+	if edge.Type != graph.EdgeTypeEnum || edge.From != "SO_SOMain.cDepCode" || edge.To != "Department.cDepCode" {
+		t.Errorf("unexpected edge %+v", edge)
+	}
+	if refs := enumTables[0].ReferencedBy; len(refs) != 1 || refs[0] != "SO_SOMain.cDepCode" {
+		t.Errorf("expected ReferencedBy to be filled, got %v", refs)
+	}
+}
+
+func TestDetectEnumColumnsUsesCollectedStats(t *testing.T) {
+	meta := &adapter.SchemaMetadata{Tables: []adapter.Table{
+		{Name: "SO_SOMain", Columns: []adapter.Column{
+			{Name: "ID", DataType: "int", IsPrimaryKey: true},
+			{Name: "cStatus", DataType: "nvarchar"},
+			{Name: "cMemo", DataType: "nvarchar"},
+		}},
+	}}
+	status := &adapter.ColumnStats{TotalRows: 1000, DistinctCount: 2, TopValues: []adapter.ValueCount{
+		{Value: "open", Count: 600}, {Value: "closed", Count: 400},
+	}}
+	evidence := NewEvidenceCollector()
+	evidence.AddStats("SO_SOMain", "cStatus", status)
+
+	db := &stubAdapter{rows: 5000, stats: map[string]*adapter.ColumnStats{}}
+	detector := NewEnumDetector(db)
+	detector.SetEvidence(evidence)
+	columns, err := detector.DetectEnumColumns(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != 1 || columns[0].Column != "cStatus" {
+		t.Errorf("expected cStatus from collected stats, got %+v", columns)
+	}
+	// 只有没有统计的 cMemo 需要采样
+	if db.sampled != 1 {
+		t.Errorf("expected 1 sampling query, got %d", db.sampled)
+	}
+}
+
+func TestIncrementalEnumReferences(t *testing.T) {
+	tables := func(personRows int64, orderRows int64) *adapter.SchemaMetadata {
+		ref := []adapter.Column{{Name: "ID", DataType: "int", IsPrimaryKey: true}, {Name: "cDepCode", DataType: "nvarchar"}}
+		return &adapter.SchemaMetadata{Tables: []adapter.Table{
+			{Schema: "erp", Name: "Department", RowCount: 2, Columns: []adapter.Column{
+				{Name: "cDepCode", DataType: "nvarchar", IsPrimaryKey: true},
+				{Name: "cDepName", DataType: "nvarchar"},
+			}},
+			{Schema: "erp", Name: "Person", RowCount: personRows, Columns: ref},
+			{Schema: "erp", Name: "Vendor", RowCount: 300, Columns: ref},
+			{Schema: "erp", Name: "Orders", RowCount: orderRows, Columns: ref},
+		}}
+	}
+	matching := &adapter.ColumnStats{TopValues: []adapter.ValueCount{{Value: "01", Count: 30}, {Value: "02", Count: 10}}}
+	department := []EnumTable{{
+		Name:        "erp.Department",
+		KeyColumn:   "cDepCode",
+		ValueColumn: "cDepName",
+		Values:      []graph.EnumValue{{Key: "01", Label: "销售部"}, {Key: "02", Label: "采购部"}},
+	}}
+	build := func(meta *adapter.SchemaMetadata, skip func(string) bool) *graph.SchemaGraph {
+		g := graph.NewSchemaGraph()
+		for _, table := range meta.Tables {
+			name := table.QualifiedName()
+			if skip(name) {
+				continue
+			}
+			node := &graph.Node{ID: name, Type: graph.NodeTypeTable, Name: table.Name, Properties: map[string]interface{}{}}
+			incremental.AnnotateTable(node, table)
+			g.AddNode(node)
+			for _, col := range table.Columns {
+				g.AddNode(&graph.Node{ID: name + "." + col.Name, Type: graph.NodeTypeColumn, Name: col.Name,
+					Properties: map[string]interface{}{"table": name}})
+			}
+		}
+		return g
+	}
+
+	// 基线：三张表都引用部门码表，经过一次 JSON 往返
+	previous := tables(100, 500)
+	g := build(previous, func(string) bool { return false })
+	db := &stubAdapter{stats: map[string]*adapter.ColumnStats{
+		"erp.Person.cDepCode": matching, "erp.Vendor.cDepCode": matching, "erp.Orders.cDepCode": matching,
+	}}
+	refs := NewEnumDetector(db).LinkEnumReferences(previous, department)
+	AnnotateEnums(g, department, nil)
+	AddEnumReferences(g, refs)
+	data, _ := g.ToJSON()
+	baseline, err := graph.FromJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Person 和 Orders 的数据有变化，Orders 的取值不再落在码表内；部门码表本身未变化，不重新检测
+	current := tables(200, 1000)
+	diff := incremental.Compare(baseline, current, incremental.DefaultRowCountTolerance)
+	out := build(current, diff.Unchanged)
+	incremental.CarryOver(baseline, out, diff)
+
+	db = &stubAdapter{stats: map[string]*adapter.ColumnStats{
+		"erp.Person.cDepCode": matching,
+		"erp.Orders.cDepCode": {TopValues: []adapter.ValueCount{{Value: "99", Count: 30}}},
+	}}
+	detector := NewEnumDetector(db)
+	carried := CarriedEnumTables(out, diff.ChangedTables())
+	if len(carried) != 1 || carried[0].Name != "erp.Department" {
+		t.Fatalf("expected the department code table to be carried over, got %+v", carried)
+	}
+	refs = detector.LinkChangedReferences(current, diff.ChangedMeta(current), carried)
+	AnnotateEnums(out, carried, nil)
+	AddEnumReferences(out, refs)
+
+	if db.sampled != 2 {
+		t.Errorf("expected only the changed tables to be sampled, got %d samples", db.sampled)
+	}
+	for _, id := range []string{
+		"enum:erp.Person.cDepCode->erp.Department.cDepCode", // 变化的表重新关联
+		"enum:erp.Vendor.cDepCode->erp.Department.cDepCode", // 未变化的表沿用基线
+	} {
+		if out.GetEdge(id) == nil {
+			t.Errorf("expected edge %s", id)
+		}
+	}
+	if out.GetEdge("enum:erp.Orders.cDepCode->erp.Department.cDepCode") != nil {
+		t.Error("expected the stale Orders reference to be dropped")
+	}
+	referencedBy, _ := out.GetNode("erp.Department").Properties["referenced_by"].([]string)
+	if len(referencedBy) != 2 || referencedBy[0] != "erp.Vendor.cDepCode" || referencedBy[1] != "erp.Person.cDepCode" {
+		t.Errorf("expected referenced_by to list Vendor and Person, got %v", referencedBy)
+	}
+}
@@ -0,0 +1,179 @@
+package analyzer
+
+import (
+	"fmt"
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/ai"
+	"schema-analyzer/internal/graph"
+	"sort"
+)
+
+// maxRelationEvidence 每个字段最多列出的关联关系
+const maxRelationEvidence = 3
+
+// EvidenceCollector 汇总采样统计和关系推断结果，为 AI 解释生成字段证据。
+// 取值只以模式、长度和（允许时）高频样例的形式出现，样例由 ai.PrivateClient 统一脱敏。所有方法对 nil 安全
+type EvidenceCollector struct {
+	stats     map[string]*adapter.ColumnStats // 表.列 -> 采样统计
+	relations map[string][]relationEvidence   // 表.列 -> 关联关系
+	samples   int                             // 每个字段附带的样例数，0 不附带
+}
+
+// relationEvidence 字段参与的一条关系
+type relationEvidence struct {
+	related    ai.RelatedColumn
+	confidence float64
+}
+
+// NewEvidenceCollector 创建证据收集器
+func NewEvidenceCollector() *EvidenceCollector {
+	return &EvidenceCollector{
+		stats:     make(map[string]*adapter.ColumnStats),
+		relations: make(map[string][]relationEvidence),
+	}
+}
+
+// SetSampleValues 设置每个字段附带的样例数，0（默认）表示不发送任何样例
+func (c *EvidenceCollector) SetSampleValues(n int) {
+	if c == nil {
+		return
+	}
+	if n < 0 {
+		n = 0
+	}
+	c.samples = n
+}
+
+// AddStats 记录列的采样统计
+func (c *EvidenceCollector) AddStats(table, column string, stats *adapter.ColumnStats) {
+	if c == nil || stats == nil {
+		return
+	}
+	c.stats[table+"."+column] = stats
+}
+
+// Stats 列的采样统计，没有时返回 nil
+func (c *EvidenceCollector) Stats(table, column string) *adapter.ColumnStats {
+	if c == nil {
+		return nil
+	}
+	return c.stats[table+"."+column]
+}
+
+// AddEdges 记录字段之间的关系（声明外键、推断外键、码表引用），两端字段都会得到证据
+func (c *EvidenceCollector) AddEdges(edges []*graph.Edge) {
+	if c == nil {
+		return
+	}
+	for _, edge := range edges {
+		fromTable, _ := edge.Properties["from_table"].(string)
+		fromCol, _ := edge.Properties["from_column"].(string)
+		toTable, _ := edge.Properties["to_table"].(string)
+		toCol, _ := edge.Properties["to_column"].(string)
+		if fromCol == "" || toCol == "" {
+			continue // 表级关系
+		}
+		kind := relationKind(edge)
+		c.relations[fromTable+"."+fromCol] = append(c.relations[fromTable+"."+fromCol], relationEvidence{
+			related:    ai.RelatedColumn{Table: toTable, Column: toCol, Kind: kind},
+			confidence: edge.Confidence,
+		})
+		c.relations[toTable+"."+toCol] = append(c.relations[toTable+"."+toCol], relationEvidence{
+			related:    ai.RelatedColumn{Table: fromTable, Column: fromCol, Kind: kind, Incoming: true},
+			confidence: edge.Confidence,
+		})
+	}
+}
+
+// relationKind 关系类型的说明
+func relationKind(edge *graph.Edge) string {
+	switch edge.Type {
+	case graph.EdgeTypeFK:
+		return "外键"
+	case graph.EdgeTypeEnum:
+		return "码表"
+	}
+	// 不写置信度：它每次推断都有小幅波动，写进 prompt 会让缓存失效
+	return "推断"
+}
+
+// Field 字段的证据，没有任何证据时返回 nil
+func (c *EvidenceCollector) Field(table, column string) *ai.FieldEvidence {
+	if c == nil {
+		return nil
+	}
+	key := table + "." + column
+	stats := c.stats[key]
+	relations := c.relations[key]
+	if stats == nil && len(relations) == 0 {
+		return nil
+	}
+
+	evidence := &ai.FieldEvidence{}
+	if stats != nil && stats.TotalRows > 0 {
+		evidence.SampleRows = stats.TotalRows
+		evidence.NullRatio = float64(stats.NullCount) / float64(stats.TotalRows)
+		evidence.DistinctRate = float64(stats.DistinctCount) / float64(stats.TotalRows)
+		evidence.Patterns = topValuePatterns(stats.TopValues)
+		evidence.MinLength, evidence.MaxLength = topValueLengths(stats.TopValues)
+		for i, v := range stats.TopValues {
+			if i >= c.samples {
+				break
+			}
+			evidence.Samples = append(evidence.Samples, v.Value)
+		}
+	}
+
+	relations = append([]relationEvidence(nil), relations...)
+	sort.Slice(relations, func(i, j int) bool {
+		if relations[i].confidence != relations[j].confidence {
+			return relations[i].confidence > relations[j].confidence
+		}
+		return relations[i].related.String() < relations[j].related.String()
+	})
+	for i, rel := range relations {
+		if i >= maxRelationEvidence {
+			break
+		}
+		evidence.Relations = append(evidence.Relations, rel.related)
+	}
+	return evidence
+}
+
+// topValuePatterns 高频取值的模式（detectPattern）按出现次数的占比，从高到低
+func topValuePatterns(values []adapter.ValueCount) []string {
+	counts := make(map[string]int64)
+	var order []string
+	var total int64
+	for _, v := range values {
+		pattern := detectPattern(v.Value)
+		if _, ok := counts[pattern]; !ok {
+			order = append(order, pattern)
+		}
+		counts[pattern] += v.Count
+		total += v.Count
+	}
+	if total == 0 {
+		return nil
+	}
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+	patterns := make([]string, len(order))
+	for i, pattern := range order {
+		patterns[i] = fmt.Sprintf("%s %.0f%%", pattern, float64(counts[pattern])/float64(total)*100)
+	}
+	return patterns
+}
+
+// topValueLengths 高频取值的最短和最长长度（字符数）
+func topValueLengths(values []adapter.ValueCount) (min, max int) {
+	for i, v := range values {
+		n := len([]rune(v.Value))
+		if i == 0 || n < min {
+			min = n
+		}
+		if n > max {
+			max = n
+		}
+	}
+	return min, max
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/graph"
+	"testing"
+)
+
+func TestEvidenceCollectorField(t *testing.T) {
+	c := NewEvidenceCollector()
+	c.AddStats("dbo.Customer", "cCusPhone", &adapter.ColumnStats{
+		TotalRows:     1000,
+		NullCount:     100,
+		DistinctCount: 850,
+		TopValues: []adapter.ValueCount{
+			{Value: "13800138000", Count: 3},
+			{Value: "010-62345678", Count: 1},
+		},
+	})
+	c.AddEdges([]*graph.Edge{
+		{Type: graph.EdgeTypeInferredFK, Confidence: 0.7, Properties: map[string]interface{}{
+			"from_table": "dbo.SO_SOMain", "from_column": "cCusPhone", "to_table": "dbo.Customer", "to_column": "cCusPhone",
+		}},
+		{Type: graph.EdgeTypeFK, Confidence: 1, Properties: map[string]interface{}{
+			"from_table": "dbo.Dispatch", "from_column": "cPhone", "to_table": "dbo.Customer", "to_column": "cCusPhone",
+		}},
+	})
+
+	evidence := c.Field("dbo.Customer", "cCusPhone")
+	if evidence == nil {
+		t.Fatal("expected evidence for sampled column")
+	}
+	if evidence.NullRatio != 0.1 || evidence.DistinctRate != 0.85 {
+		t.Errorf("unexpected ratios %.2f / %.2f", evidence.NullRatio, evidence.DistinctRate)
+	}
+	if len(evidence.Patterns) != 2 || evidence.Patterns[0] != "numeric 75%" {
+		t.Errorf("unexpected patterns %v", evidence.Patterns)
+	}
+	if evidence.MinLength != 11 || evidence.MaxLength != 12 {
+		t.Errorf("unexpected lengths %d-%d", evidence.MinLength, evidence.MaxLength)
+	}
+	if len(evidence.Samples) != 0 {
+		t.Errorf("expected no sample values by default, got %v", evidence.Samples)
+	}
+	if len(evidence.Relations) != 2 || evidence.Relations[0].String() != "被 dbo.Dispatch.cPhone 引用（外键）" {
+		t.Errorf("expected declared foreign key first, got %v", evidence.Relations)
+	}
+
+	// 样例保持原值，由 ai.PrivateClient 按隐私策略处理
+	c.SetSampleValues(1)
+	evidence = c.Field("dbo.Customer", "cCusPhone")
+	if len(evidence.Samples) != 1 || evidence.Samples[0] != "13800138000" {
+		t.Errorf("expected one sample value, got %v", evidence.Samples)
+	}
+
+	if c.Field("dbo.Customer", "cUnknown") != nil {
+		t.Error("expected nil evidence for column without stats or relations")
+	}
+	var none *EvidenceCollector
+	if none.Field("dbo.Customer", "cCusPhone") != nil {
+		t.Error("expected nil collector to return nil")
+	}
+}
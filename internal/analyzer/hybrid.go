@@ -1,29 +1,78 @@
 package analyzer
 
 import (
+	"errors"
 	"fmt"
 	"schema-analyzer/internal/adapter"
 	"schema-analyzer/internal/ai"
+	"schema-analyzer/internal/checkpoint"
 	"schema-analyzer/internal/graph"
 	"strings"
+	"sync"
 )
 
 // HybridAnalyzer 混合分析器（算法 + AI）
 type HybridAnalyzer struct {
-	adapter   adapter.DBAdapter
-	aiClient  ai.Client
-	inferer   *RelationshipInferer
+	adapter     adapter.DBAdapter
+	aiClient    ai.Client
+	inferer     *RelationshipInferer
+	cp          *checkpoint.Store
+	concurrency int                // 表分析和字段批次的并发数
+	evidence    *EvidenceCollector // 字段的数据证据，nil 时只按字段名解释
 }
 
 // NewHybridAnalyzer 创建混合分析器
 func NewHybridAnalyzer(adapter adapter.DBAdapter, aiClient ai.Client) *HybridAnalyzer {
 	return &HybridAnalyzer{
-		adapter:  adapter,
-		aiClient: aiClient,
-		inferer:  NewRelationshipInferer(adapter),
+		adapter:     adapter,
+		aiClient:    aiClient,
+		inferer:     NewRelationshipInferer(adapter),
+		concurrency: 1,
 	}
 }
 
+// SetCheckpoint 设置检查点：已完成的表分析、表关系分析和字段批次在恢复时不再调用 AI
+func (h *HybridAnalyzer) SetCheckpoint(cp *checkpoint.Store) {
+	h.cp = cp
+}
+
+// SetEvidence 设置字段证据来源：批量解释时把采样统计和关联关系一并发给 AI
+func (h *HybridAnalyzer) SetEvidence(evidence *EvidenceCollector) {
+	h.evidence = evidence
+}
+
+// SetConcurrency 设置同时进行的 AI 调用数（表分析和字段批次），默认 1
+func (h *HybridAnalyzer) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	h.concurrency = n
+}
+
+// parallel 用最多 concurrency 个 goroutine 执行 fn(0) ... fn(n-1)
+func (h *HybridAnalyzer) parallel(n int, fn func(i int)) {
+	workers := h.concurrency
+	if workers > n {
+		workers = n
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
 // AnalyzeWithAI 使用 AI 增强的分析
 func (h *HybridAnalyzer) AnalyzeWithAI(meta *adapter.SchemaMetadata) (*EnhancedSchema, error) {
 	enhanced := &EnhancedSchema{
@@ -33,22 +82,55 @@ func (h *HybridAnalyzer) AnalyzeWithAI(meta *adapter.SchemaMetadata) (*EnhancedS
 	// 1. AI 分析表的意义
 	fmt.Println("🤖 AI 分析表的意义...")
 	tableExplanations := make(map[string]*ai.TableExplanation)
-	for _, table := range meta.Tables {
-		explanation, err := h.aiClient.AnalyzeTableMeaning(table.Name, table.Columns)
-		if err != nil {
-			fmt.Printf("  ⚠️  分析表 %s 失败: %v\n", table.Name, err)
+	results := make([]*ai.TableExplanation, len(meta.Tables))
+	errs := make([]error, len(meta.Tables))
+	h.parallel(len(meta.Tables), func(i int) {
+		table := meta.Tables[i]
+		tableName := table.QualifiedName()
+		if h.cp.Load(checkpoint.StageAITables, tableName, &results[i]) {
+			return
+		}
+		results[i], errs[i] = h.aiClient.AnalyzeTableMeaning(tableName, table.Columns)
+		if errs[i] == nil {
+			h.saveCheckpoint(checkpoint.StageAITables, tableName, results[i])
+		}
+	})
+	for i, table := range meta.Tables {
+		tableName := table.QualifiedName()
+		if errors.Is(errs[i], ai.ErrWithheld) {
+			continue // 隐私策略禁止发送
+		}
+		if errors.Is(errs[i], ai.ErrBudgetExceeded) {
+			enhanced.skip("table_meaning")
 			continue
 		}
-		tableExplanations[table.Name] = explanation
-		fmt.Printf("  ✓ %s: %s\n", table.Name, explanation.ChineseName)
+		if errs[i] != nil {
+			fmt.Printf("  ⚠️  分析表 %s 失败: %v\n", tableName, errs[i])
+			enhanced.addFailure("table_meaning", tableName, errs[i])
+			continue
+		}
+		tableExplanations[tableName] = results[i]
+		fmt.Printf("  ✓ %s: %s\n", tableName, results[i].ChineseName)
 	}
 
 	// 2. AI 分析表之间的关系
 	fmt.Println("\n🤖 AI 分析表之间的关系...")
-	relationships, err := h.aiClient.AnalyzeTableRelationships(meta.Tables)
-	if err != nil {
+	var relationships []ai.TableRelationship
+	var err error
+	if !h.cp.Load(checkpoint.StageAIRelations, "all", &relationships) {
+		relationships, err = h.aiClient.AnalyzeTableRelationships(meta.Tables)
+		if err == nil {
+			h.saveCheckpoint(checkpoint.StageAIRelations, "all", relationships)
+		}
+	}
+	if errors.Is(err, ai.ErrBudgetExceeded) {
+		enhanced.skip("table_relationships")
+	} else if err != nil {
 		fmt.Printf("  ⚠️  分析表关系失败: %v\n", err)
-	} else {
+		enhanced.addFailure("table_relationships", fmt.Sprintf("%d 个表", len(meta.Tables)), err)
+	}
+	// 部分分组失败时保留成功分组的结果，但不保存检查点，续跑时重新分析
+	if err == nil || len(relationships) > 0 {
 		enhanced.TableRelationships = relationships
 		fmt.Printf("  ✓ 发现 %d 个表关系\n", len(relationships))
 		for _, rel := range relationships {
@@ -61,26 +143,28 @@ func (h *HybridAnalyzer) AnalyzeWithAI(meta *adapter.SchemaMetadata) (*EnhancedS
 	customFields := make(map[string][]string) // table -> custom columns
 
 	for _, table := range meta.Tables {
+		tableName := table.QualifiedName()
 		enhancedTable := &EnhancedTable{
-			Name:    table.Name,
+			Name:    tableName,
 			Columns: make(map[string]*EnhancedColumn),
 		}
 
 		// 添加表解释
-		if exp, ok := tableExplanations[table.Name]; ok {
+		if exp, ok := tableExplanations[tableName]; ok {
 			enhancedTable.Explanation = exp
 		}
 
 		for _, col := range table.Columns {
 			if isCustomField(col.Name) {
 				// 自定义字段：记录下来，稍后基于关系推断
-				customFields[table.Name] = append(customFields[table.Name], col.Name)
+				customFields[tableName] = append(customFields[tableName], col.Name)
 			} else {
 				// 标准字段：加入批量解释队列
 				standardFields = append(standardFields, ai.FieldContext{
-					TableName:  table.Name,
+					TableName:  tableName,
 					ColumnName: col.Name,
 					DataType:   col.DataType,
+					Evidence:   h.evidence.Field(tableName, col.Name),
 				})
 			}
 
@@ -90,40 +174,70 @@ func (h *HybridAnalyzer) AnalyzeWithAI(meta *adapter.SchemaMetadata) (*EnhancedS
 			}
 		}
 
-		enhanced.Tables[table.Name] = enhancedTable
+		enhanced.Tables[tableName] = enhancedTable
 	}
 
 	// 3. 批量解释标准字段（AI）
 	if len(standardFields) > 0 {
 		fmt.Printf("🤖 AI 解释 %d 个标准字段...\n", len(standardFields))
 		
-		// 分批处理，每批最多 50 个字段
-		batchSize := 50
+		// 分批处理，每批最多 50 个字段（本地模型按上下文长度缩小）
+		batchSize := ai.BatchSize(h.aiClient, 50)
 		totalBatches := (len(standardFields) + batchSize - 1) / batchSize
 		
+		var batches [][]ai.FieldContext
 		for i := 0; i < len(standardFields); i += batchSize {
 			end := i + batchSize
 			if end > len(standardFields) {
 				end = len(standardFields)
 			}
+			batches = append(batches, standardFields[i:end])
+		}
+
+		batchResults := make([]map[string]*ai.FieldExplanation, len(batches))
+		batchErrs := make([]error, len(batches))
+		h.parallel(len(batches), func(i int) {
+			batch := batches[i]
+			batchNum := i + 1
 			
-			batch := standardFields[i:end]
-			batchNum := i/batchSize + 1
-			fmt.Printf("  处理第 %d/%d 批 (%d 个字段)...\n", batchNum, totalBatches, len(batch))
+			// 以批次内容为 key，字段顺序不变时恢复后能命中
+			keyParts := make([]string, len(batch))
+			for j, field := range batch {
+				keyParts[j] = field.TableName + "." + field.ColumnName
+			}
+			batchKey := checkpoint.Key(keyParts...)
 			
-			explanations, err := h.aiClient.BatchExplain(batch)
-			if err != nil {
-				fmt.Printf("  ⚠️  第 %d 批 AI 解释失败: %v，跳过\n", batchNum, err)
-			} else {
-				// 应用 AI 解释
-				for _, field := range batch {
-					if exp, ok := explanations[field.ColumnName]; ok {
-						col := enhanced.Tables[field.TableName].Columns[field.ColumnName]
-						col.Explanation = exp
-					}
+			if h.cp.Load(checkpoint.StageAIBatches, batchKey, &batchResults[i]) {
+				fmt.Printf("  第 %d/%d 批已在检查点中，跳过\n", batchNum, totalBatches)
+				return
+			}
+			fmt.Printf("  处理第 %d/%d 批 (%d 个字段)...\n", batchNum, totalBatches, len(batch))
+			batchResults[i], batchErrs[i] = h.aiClient.BatchExplain(batch)
+			if batchErrs[i] == nil {
+				h.saveCheckpoint(checkpoint.StageAIBatches, batchKey, batchResults[i])
+			}
+		})
+
+		for i, batch := range batches {
+			batchNum := i + 1
+			if errors.Is(batchErrs[i], ai.ErrBudgetExceeded) {
+				enhanced.skip("batch_explain")
+				continue
+			}
+			if batchErrs[i] != nil {
+				fmt.Printf("  ⚠️  第 %d 批 AI 解释失败: %v，跳过\n", batchNum, batchErrs[i])
+				target := fmt.Sprintf("第 %d 批（%s 等 %d 个字段）", batchNum, batch[0].TableName+"."+batch[0].ColumnName, len(batch))
+				enhanced.addFailure("batch_explain", target, batchErrs[i])
+				continue
+			}
+			// 应用 AI 解释
+			for _, field := range batch {
+				if exp, ok := batchResults[i][ai.FieldKey(field.TableName, field.ColumnName)]; ok {
+					col := enhanced.Tables[field.TableName].Columns[field.ColumnName]
+					col.Explanation = exp
 				}
-				fmt.Printf("  ✓ 第 %d 批完成\n", batchNum)
 			}
+			fmt.Printf("  ✓ 第 %d 批完成\n", batchNum)
 		}
 		
 		fmt.Printf("✓ AI 解释完成\n")
@@ -147,9 +261,17 @@ func (h *HybridAnalyzer) AnalyzeWithAI(meta *adapter.SchemaMetadata) (*EnhancedS
 		}
 	}
 
+	h.cp.Flush()
 	return enhanced, nil
 }
 
+// saveCheckpoint 保存 AI 结果，失败只提示不中断分析
+func (h *HybridAnalyzer) saveCheckpoint(stage, key string, v interface{}) {
+	if err := h.cp.Save(stage, key, v); err != nil {
+		fmt.Printf("  ⚠️  保存检查点失败: %v\n", err)
+	}
+}
+
 // inferCustomFieldMeaning 推断自定义字段含义
 func (h *HybridAnalyzer) inferCustomFieldMeaning(
 	tableName, columnName string,
@@ -173,11 +295,13 @@ func (h *HybridAnalyzer) inferCustomFieldMeaning(
 
 	// 2. 如果有 AI 客户端，让 AI 基于关联关系推断
 	if h.aiClient != nil {
-		explanation, err := h.aiClient.InferCustomField(columnName, relatedFields)
+		explanation, err := h.aiClient.InferCustomField(tableName, columnName, relatedFields)
 		if err == nil {
 			return explanation
 		}
-		fmt.Printf("⚠️  AI 推断失败: %v\n", err)
+		if !errors.Is(err, ai.ErrWithheld) {
+			fmt.Printf("⚠️  AI 推断失败: %v\n", err)
+		}
 	}
 
 	// 3. 降级：基于关联关系生成简单说明
@@ -279,6 +403,29 @@ type EnhancedSchema struct {
 	Tables        map[string]*EnhancedTable
 	Relationships []*graph.Edge
 	TableRelationships []ai.TableRelationship
+	Failures      []AIFailure // 重试后仍失败的 AI 调用
+	Skipped       map[string]int // 达到用量上限后跳过的调用数，按阶段统计
+}
+
+// AIFailure 一次失败的 AI 调用，对应的表或字段保留算法结果
+type AIFailure struct {
+	Stage  string // table_meaning / table_relationships / batch_explain
+	Target string // 表名或批次说明
+	Error  string
+}
+
+// skip 记录因达到用量上限跳过的调用，第一次时提示
+func (e *EnhancedSchema) skip(stage string) {
+	if e.Skipped == nil {
+		e.Skipped = make(map[string]int)
+		fmt.Println("  ⚠️  达到 AI 用量上限，停止 AI 增强，其余表和字段保留算法结果")
+	}
+	e.Skipped[stage]++
+}
+
+// addFailure 记录失败的 AI 调用
+func (e *EnhancedSchema) addFailure(stage, target string, err error) {
+	e.Failures = append(e.Failures, AIFailure{Stage: stage, Target: target, Error: err.Error()})
 }
 
 // EnhancedTable 增强的表
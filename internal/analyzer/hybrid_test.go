@@ -0,0 +1,121 @@
+package analyzer
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/ai"
+	"schema-analyzer/internal/checkpoint"
+)
+
+// stubAIClient 返回固定解释的 AI 客户端，记录最大并发数
+type stubAIClient struct {
+	failTable     string
+	relationships []ai.TableRelationship
+	relationErr   error
+	running       int32
+	peak          int32
+	mu            sync.Mutex
+}
+
+func (c *stubAIClient) enter() func() {
+	n := atomic.AddInt32(&c.running, 1)
+	c.mu.Lock()
+	if n > c.peak {
+		c.peak = n
+	}
+	c.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	return func() { atomic.AddInt32(&c.running, -1) }
+}
+
+func (c *stubAIClient) ExplainStandardField(tableName, columnName, dataType string) (*ai.FieldExplanation, error) {
+	return nil, errors.New("not used")
+}
+
+func (c *stubAIClient) InferCustomField(tableName, columnName string, relatedFields []ai.RelatedField) (*ai.FieldExplanation, error) {
+	return nil, errors.New("not used")
+}
+
+func (c *stubAIClient) BatchExplain(fields []ai.FieldContext) (map[string]*ai.FieldExplanation, error) {
+	defer c.enter()()
+	result := make(map[string]*ai.FieldExplanation)
+	for _, f := range fields {
+		result[ai.FieldKey(f.TableName, f.ColumnName)] = &ai.FieldExplanation{ColumnName: f.ColumnName, ChineseName: f.TableName + "." + f.ColumnName}
+	}
+	return result, nil
+}
+
+func (c *stubAIClient) AnalyzeTableMeaning(tableName string, columns []adapter.Column) (*ai.TableExplanation, error) {
+	defer c.enter()()
+	if tableName == c.failTable {
+		return nil, errors.New("API 调用失败: 503 Service Unavailable")
+	}
+	return &ai.TableExplanation{TableName: tableName, ChineseName: tableName}, nil
+}
+
+func (c *stubAIClient) AnalyzeTableRelationships(tables []adapter.Table) ([]ai.TableRelationship, error) {
+	return c.relationships, c.relationErr
+}
+
+func TestAnalyzeWithAIConcurrencyAndFailures(t *testing.T) {
+	meta := &adapter.SchemaMetadata{}
+	for _, name := range []string{"Department", "Person", "Inventory", "Customer", "Vendor", "Warehouse"} {
+		meta.Tables = append(meta.Tables, adapter.Table{Name: name, Columns: []adapter.Column{{Name: "cCode", DataType: "varchar"}}})
+	}
+	client := &stubAIClient{failTable: "Inventory"}
+	h := NewHybridAnalyzer(nil, client)
+	h.SetConcurrency(3)
+
+	enhanced, err := h.AnalyzeWithAI(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.peak < 2 || client.peak > 3 {
+		t.Errorf("expected between 2 and 3 concurrent calls, got %d", client.peak)
+	}
+	if len(enhanced.Failures) != 1 || enhanced.Failures[0].Stage != "table_meaning" || enhanced.Failures[0].Target != "Inventory" {
+		t.Errorf("expected the failed table to be reported, got %+v", enhanced.Failures)
+	}
+	if enhanced.Tables["Inventory"].Explanation != nil || enhanced.Tables["Person"].Explanation == nil {
+		t.Error("expected explanations for all tables except the failed one")
+	}
+	// 同名列在各表中的解释互不覆盖
+	if exp := enhanced.Tables["Vendor"].Columns["cCode"].Explanation; exp == nil || exp.ChineseName != "Vendor.cCode" {
+		t.Errorf("unexpected column explanation %+v", exp)
+	}
+}
+
+func TestAnalyzeWithAIPartialRelationships(t *testing.T) {
+	meta := &adapter.SchemaMetadata{Tables: []adapter.Table{{Name: "Orders"}, {Name: "Customer"}}}
+	cp, err := checkpoint.Open(filepath.Join(t.TempDir(), "checkpoint.json"), "test", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &stubAIClient{
+		relationships: []ai.TableRelationship{{FromTable: "Orders", ToTable: "Customer", RelationType: "one_to_many"}},
+		relationErr:   &ai.PartialError{Failed: 1, Total: 2, Err: errors.New("timeout")},
+	}
+	h := NewHybridAnalyzer(nil, client)
+	h.SetCheckpoint(cp)
+
+	enhanced, err := h.AnalyzeWithAI(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 成功分组的结果照常使用，失败记录在报告中，不完整的结果不保存检查点
+	if len(enhanced.TableRelationships) != 1 {
+		t.Errorf("expected the partial relationships to be kept, got %+v", enhanced.TableRelationships)
+	}
+	if len(enhanced.Failures) != 1 || enhanced.Failures[0].Stage != "table_relationships" {
+		t.Errorf("expected the partial failure to be reported, got %+v", enhanced.Failures)
+	}
+	if n := cp.Count(checkpoint.StageAIRelations); n != 0 {
+		t.Errorf("expected no checkpoint for partial relationships, got %d", n)
+	}
+}
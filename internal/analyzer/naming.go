@@ -0,0 +1,110 @@
+package analyzer
+
+import (
+	"schema-analyzer/internal/adapter"
+	"strings"
+	"unicode"
+)
+
+// NamingProfile 命名规范（用于表名感知的外键命名证据）
+type NamingProfile struct {
+	Name           string
+	ColumnPrefixes []string // 列名类型前缀（匈牙利命名，如 U8 的 c/i/d），仅在其后紧跟大写字母时剥离
+	KeySuffixes    []string // 键列后缀，如 id/code/no
+	TablePrefixes  []string // 表名前缀，如 tbl_/t_
+}
+
+var (
+	// GenericNamingProfile 通用命名规范（customer_id → customer.id）
+	GenericNamingProfile = NamingProfile{
+		Name:          "generic",
+		KeySuffixes:   []string{"id", "code", "no", "key", "num"},
+		TablePrefixes: []string{"tbl", "tb", "t"},
+	}
+
+	// U8NamingProfile 用友 U8 命名规范（cCusCode → Customer.cCusCode）
+	U8NamingProfile = NamingProfile{
+		Name:           "u8",
+		ColumnPrefixes: []string{"c", "i", "d", "b", "f", "n"},
+		KeySuffixes:    []string{"code", "id", "no", "num"},
+	}
+
+	namingProfiles = map[string]NamingProfile{
+		GenericNamingProfile.Name: GenericNamingProfile,
+		U8NamingProfile.Name:      U8NamingProfile,
+	}
+)
+
+// GetNamingProfile 按名称获取命名规范
+func GetNamingProfile(name string) (NamingProfile, bool) {
+	p, ok := namingProfiles[strings.ToLower(name)]
+	return p, ok
+}
+
+// columnStem 提取列名词干：去掉类型前缀和键后缀，如 cCusCode → cus，customer_id → customer
+func (p NamingProfile) columnStem(column string) string {
+	name := column
+	for _, prefix := range p.ColumnPrefixes {
+		if len(name) > len(prefix) && strings.HasPrefix(name, prefix) &&
+			unicode.IsUpper(rune(name[len(prefix)])) {
+			name = name[len(prefix):]
+			break
+		}
+	}
+
+	stem := normalizeIdentifier(name)
+	for _, suffix := range p.KeySuffixes {
+		if len(stem) > len(suffix) && strings.HasSuffix(stem, suffix) {
+			return stem[:len(stem)-len(suffix)]
+		}
+	}
+	return stem
+}
+
+// tableStem 标准化表名：去掉表前缀并转为单数
+func (p NamingProfile) tableStem(table string) string {
+	_, table = adapter.SplitQualifiedName(table)
+	lower := strings.ToLower(table)
+	for _, prefix := range p.TablePrefixes {
+		if strings.HasPrefix(lower, prefix+"_") {
+			lower = lower[len(prefix)+1:]
+			break
+		}
+	}
+	return singularize(normalizeIdentifier(lower))
+}
+
+// normalizeIdentifier 转小写并去掉分隔符
+func normalizeIdentifier(name string) string {
+	name = strings.ToLower(name)
+	name = strings.ReplaceAll(name, "_", "")
+	name = strings.ReplaceAll(name, "-", "")
+	return name
+}
+
+// singularize 简单的英文单数化
+func singularize(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && len(word) > 3:
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// isSubsequence 判断 abbr 是否为 word 的缩写（首字母相同且按顺序出现）
+func isSubsequence(abbr, word string) bool {
+	if abbr == "" || word == "" || abbr[0] != word[0] {
+		return false
+	}
+	i := 0
+	for j := 0; j < len(word) && i < len(abbr); j++ {
+		if abbr[i] == word[j] {
+			i++
+		}
+	}
+	return i == len(abbr)
+}
@@ -0,0 +1,163 @@
+package analyzer
+
+import (
+	"fmt"
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/graph"
+	"sort"
+)
+
+// EdgePruner 候选关系裁剪器
+// 同一个引用列往往会同时匹配多张表（如 cCusCode 同时匹配 Customer 和 CustomerClass），
+// 裁剪器为每个引用列只保留最佳目标，落选的候选作为备选记录在胜出的边上
+type EdgePruner struct {
+	MinMargin   float64 // 与次优候选的置信度差小于该值时标记为存疑
+	SolePKBonus float64 // 目标列是目标表唯一主键时的排序加分
+}
+
+// PruneResult 裁剪结果
+type PruneResult struct {
+	Kept      []*graph.Edge
+	Pruned    []*graph.Edge // 落选并被记录为备选的边
+	Ambiguous int           // 与次优候选差距过小的引用列数
+}
+
+// NewEdgePruner 创建候选关系裁剪器
+func NewEdgePruner() *EdgePruner {
+	return &EdgePruner{
+		MinMargin:   0.05,
+		SolePKBonus: 0.05,
+	}
+}
+
+// Prune 按引用列分组，每组只保留排序最高的候选；
+// 表级关系（AI 推断，无 from_column）、非推断关系和人工审核过的关系原样保留，
+// 引用列有人工审核过的关系时，未审核的候选全部落选
+func (p *EdgePruner) Prune(edges []*graph.Edge, meta *adapter.SchemaMetadata) *PruneResult {
+	solePK := solePrimaryKeys(meta)
+	result := &PruneResult{}
+
+	groups := make(map[string][]*graph.Edge)
+	var order []string
+	for _, edge := range edges {
+		fromCol, ok := edge.Properties["from_column"]
+		if edge.Type != graph.EdgeTypeInferredFK || !ok {
+			result.Kept = append(result.Kept, edge)
+			continue
+		}
+		key := fmt.Sprintf("%v.%v", edge.Properties["from_table"], fromCol)
+		if _, exists := groups[key]; !exists {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], edge)
+	}
+
+	for _, key := range order {
+		var reviewed, candidates []*graph.Edge
+		for _, edge := range groups[key] {
+			if isReviewed(edge) {
+				reviewed = append(reviewed, edge)
+			} else {
+				candidates = append(candidates, edge)
+			}
+		}
+		result.Kept = append(result.Kept, reviewed...)
+		if len(reviewed) > 0 {
+			// 人工决定优先，未审核的候选记录为第一条审核关系的备选
+			p.reject(reviewed[0], candidates, result)
+			continue
+		}
+		if len(candidates) == 1 {
+			result.Kept = append(result.Kept, candidates[0])
+			continue
+		}
+
+		sort.SliceStable(candidates, func(i, j int) bool {
+			ri, rj := p.rank(candidates[i], solePK), p.rank(candidates[j], solePK)
+			if ri != rj {
+				return ri > rj
+			}
+			return candidates[i].ID < candidates[j].ID
+		})
+
+		// 差距按排序分计算，唯一主键加分也算作区分依据
+		winner, runnerUp := candidates[0], candidates[1]
+		margin := p.rank(winner, solePK) - p.rank(runnerUp, solePK)
+		winner.Properties["margin"] = margin
+		if margin < p.MinMargin-1e-9 {
+			winner.Properties["ambiguous"] = true
+			result.Ambiguous++
+		}
+
+		p.reject(winner, candidates[1:], result)
+		result.Kept = append(result.Kept, winner)
+	}
+
+	return result
+}
+
+// reject 落选的候选记录为 winner 的备选
+func (p *EdgePruner) reject(winner *graph.Edge, losers []*graph.Edge, result *PruneResult) {
+	for _, loser := range losers {
+		winner.Alternatives = append(winner.Alternatives, graph.Alternative{
+			EdgeID:     loser.ID,
+			To:         loser.To,
+			Confidence: loser.Confidence,
+			Evidence:   loser.Evidence,
+		})
+		result.Pruned = append(result.Pruned, loser)
+	}
+}
+
+// PruneGraph 在图上执行裁剪，落选的边从图中移除
+func (p *EdgePruner) PruneGraph(g *graph.SchemaGraph, meta *adapter.SchemaMetadata) *PruneResult {
+	edges := make([]*graph.Edge, 0, len(g.Edges))
+	for _, edge := range g.Edges {
+		edges = append(edges, edge)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].ID < edges[j].ID
+	})
+
+	result := p.Prune(edges, meta)
+	for _, edge := range result.Pruned {
+		g.RemoveEdge(edge.ID)
+	}
+	return result
+}
+
+// rank 候选排序分：置信度，目标列为唯一主键时加分
+func (p *EdgePruner) rank(edge *graph.Edge, solePK map[string]string) float64 {
+	score := edge.Confidence
+	toTable, _ := edge.Properties["to_table"].(string)
+	toCol, _ := edge.Properties["to_column"].(string)
+	if pk, ok := solePK[toTable]; ok && pk == toCol {
+		score += p.SolePKBonus
+	}
+	return score
+}
+
+func isReviewed(edge *graph.Edge) bool {
+	_, ok := edge.Properties["review"]
+	return ok
+}
+
+// solePrimaryKeys 只有一个主键列的表 -> 主键列名
+func solePrimaryKeys(meta *adapter.SchemaMetadata) map[string]string {
+	result := make(map[string]string)
+	if meta == nil {
+		return result
+	}
+	for _, table := range meta.Tables {
+		var pks []string
+		for _, col := range table.Columns {
+			if col.IsPrimaryKey {
+				pks = append(pks, col.Name)
+			}
+		}
+		if len(pks) == 1 {
+			result[table.QualifiedName()] = pks[0]
+		}
+	}
+	return result
+}
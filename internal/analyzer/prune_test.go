@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/graph"
+	"testing"
+)
+
+func inferredEdge(fromTable, fromCol, toTable, toCol string, confidence float64) *graph.Edge {
+	return &graph.Edge{
+		ID:         fromTable + "." + fromCol + "->" + toTable + "." + toCol,
+		Type:       graph.EdgeTypeInferredFK,
+		From:       fromTable + "." + fromCol,
+		To:         toTable + "." + toCol,
+		Confidence: confidence,
+		Properties: map[string]interface{}{
+			"from_table":  fromTable,
+			"from_column": fromCol,
+			"to_table":    toTable,
+			"to_column":   toCol,
+		},
+	}
+}
+
+func TestEdgePrunerPrune(t *testing.T) {
+	meta := &adapter.SchemaMetadata{Tables: []adapter.Table{
+		{Name: "Customer", Columns: []adapter.Column{{Name: "cCusCode", IsPrimaryKey: true}}},
+		{Name: "CustomerClass", Columns: []adapter.Column{{Name: "cCusCode", IsPrimaryKey: true}, {Name: "cCCCode", IsPrimaryKey: true}}},
+		{Name: "Department", Columns: []adapter.Column{{Name: "cDepCode", IsPrimaryKey: true}}},
+		{Name: "Dept", Columns: []adapter.Column{{Name: "cDepCode", IsPrimaryKey: true}}},
+	}}
+
+	edges := []*graph.Edge{
+		// 置信度相同，目标为唯一主键的 Customer 胜出
+		inferredEdge("SO_SOMain", "cCusCode", "CustomerClass", "cCusCode", 0.8),
+		inferredEdge("SO_SOMain", "cCusCode", "Customer", "cCusCode", 0.8),
+		// 差距过小，标记为存疑
+		inferredEdge("SO_SOMain", "cDepCode", "Department", "cDepCode", 0.72),
+		inferredEdge("SO_SOMain", "cDepCode", "Dept", "cDepCode", 0.7),
+		// 唯一候选原样保留
+		inferredEdge("SO_SODetails", "ID", "SO_SOMain", "ID", 0.9),
+	}
+
+	result := NewEdgePruner().Prune(edges, meta)
+	if len(result.Kept) != 3 || len(result.Pruned) != 2 {
+		t.Fatalf("expected 3 kept and 2 pruned, got %d kept and %d pruned", len(result.Kept), len(result.Pruned))
+	}
+
+	kept := make(map[string]*graph.Edge)
+	for _, edge := range result.Kept {
+		kept[edge.ID] = edge
+	}
+
+	cus := kept["SO_SOMain.cCusCode->Customer.cCusCode"]
+	if cus == nil {
+		t.Fatalf("expected Customer to win, kept %v", kept)
+	}
+	if len(cus.Alternatives) != 1 || cus.Alternatives[0].To != "CustomerClass.cCusCode" {
+		t.Errorf("expected CustomerClass as alternative, got %+v", cus.Alternatives)
+	}
+
+	dep := kept["SO_SOMain.cDepCode->Department.cDepCode"]
+	if dep == nil {
+		t.Fatalf("expected Department to win, kept %v", kept)
+	}
+	if ambiguous, _ := dep.Properties["ambiguous"].(bool); !ambiguous {
+		t.Errorf("expected small margin to be marked ambiguous, margin %v", dep.Properties["margin"])
+	}
+	if result.Ambiguous != 1 {
+		t.Errorf("expected 1 ambiguous column, got %d", result.Ambiguous)
+	}
+}
+
+func TestEdgePrunerPrefersReviewed(t *testing.T) {
+	reviewed := inferredEdge("A", "x", "C", "id", 1.0)
+	reviewed.Properties["review"] = "manual"
+	edges := []*graph.Edge{inferredEdge("A", "x", "B", "id", 0.9), reviewed}
+
+	result := NewEdgePruner().Prune(edges, nil)
+	if len(result.Kept) != 1 || result.Kept[0] != reviewed {
+		t.Fatalf("expected reviewed edge to win, got %+v", result.Kept)
+	}
+	if _, ok := reviewed.Properties["ambiguous"]; ok {
+		t.Errorf("reviewed edge should never be marked ambiguous")
+	}
+}
+
+func TestEdgePrunerKeepsAllReviewed(t *testing.T) {
+	first := inferredEdge("A", "x", "B", "id", 1.0)
+	first.Properties["review"] = "accepted"
+	second := inferredEdge("A", "x", "C", "id", 1.0)
+	second.Properties["review"] = "accepted"
+	candidate := inferredEdge("A", "x", "D", "id", 0.9)
+
+	result := NewEdgePruner().Prune([]*graph.Edge{first, candidate, second}, nil)
+	if len(result.Kept) != 2 || len(result.Pruned) != 1 || result.Pruned[0] != candidate {
+		t.Fatalf("expected both accepted edges kept and only the candidate pruned, got %d kept and %d pruned", len(result.Kept), len(result.Pruned))
+	}
+	for _, edge := range result.Kept {
+		if edge != first && edge != second {
+			t.Errorf("unexpected kept edge %s", edge.ID)
+		}
+	}
+}
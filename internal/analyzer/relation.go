@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/checkpoint"
 	"schema-analyzer/internal/graph"
 	"strings"
 
@@ -13,11 +14,47 @@ import (
 // RelationshipInferer 关系推断器
 type RelationshipInferer struct {
 	adapter adapter.DBAdapter
+	naming  NamingProfile
+	scorer  Scorer
+	focus   map[string]bool // 非空时只比较至少一端在其中的表对
+	cp      *checkpoint.Store
 }
 
 // NewRelationshipInferer 创建推断器
 func NewRelationshipInferer(adapter adapter.DBAdapter) *RelationshipInferer {
-	return &RelationshipInferer{adapter: adapter}
+	return &RelationshipInferer{
+		adapter: adapter,
+		naming:  U8NamingProfile,
+		scorer:  DefaultScoringModel(),
+	}
+}
+
+// SetNamingProfile 设置命名规范（影响表名匹配证据）
+func (r *RelationshipInferer) SetNamingProfile(profile NamingProfile) {
+	r.naming = profile
+}
+
+// SetScorer 设置评分模型
+func (r *RelationshipInferer) SetScorer(scorer Scorer) {
+	r.scorer = scorer
+}
+
+// SetFocusTables 只推断至少一端是这些表（限定名）的关系，用于增量扫描；nil 表示全部
+func (r *RelationshipInferer) SetFocusTables(tables map[string]bool) {
+	r.focus = tables
+}
+
+// SetCheckpoint 设置检查点：每个源表比较完成后保存结果，恢复时跳过已完成的源表
+func (r *RelationshipInferer) SetCheckpoint(cp *checkpoint.Store) {
+	r.cp = cp
+}
+
+// shouldCompare 表对是否需要比较
+func (r *RelationshipInferer) shouldCompare(fromTable, toTable string) bool {
+	if fromTable == toTable {
+		return false
+	}
+	return r.focus == nil || r.focus[fromTable] || r.focus[toTable]
 }
 
 // InferRelationships 推断表间关系
@@ -31,7 +68,7 @@ func (r *RelationshipInferer) InferRelationships(meta *adapter.SchemaMetadata) (
 	for _, table := range meta.Tables {
 		for _, col := range table.Columns {
 			if col.IsPrimaryKey {
-				pkMap[table.Name] = append(pkMap[table.Name], col.Name)
+				pkMap[table.QualifiedName()] = append(pkMap[table.QualifiedName()], col.Name)
 			}
 		}
 	}
@@ -41,25 +78,21 @@ func (r *RelationshipInferer) InferRelationships(meta *adapter.SchemaMetadata) (
 	
 	// 预计算总比较次数
 	for _, fromTable := range meta.Tables {
-		for _, fromCol := range fromTable.Columns {
-			if !fromCol.IsPrimaryKey {
-				for _, toTable := range meta.Tables {
-					if fromTable.Name != toTable.Name {
-						for _, toCol := range toTable.Columns {
-							if toCol.IsPrimaryKey {
-								totalComparisons++
-							}
-						}
-					}
-				}
-			}
-		}
+		totalComparisons += r.countComparisons(meta, fromTable)
 	}
 	
 	fmt.Printf("  需要进行 %d 次列比较...\n", totalComparisons)
 	
 	// 遍历所有表的所有列，寻找可能的外键
 	for _, fromTable := range meta.Tables {
+		fromName := fromTable.QualifiedName()
+		var tableEdges []*graph.Edge
+		if r.cp.Load(checkpoint.StageInference, fromName, &tableEdges) {
+			edges = append(edges, tableEdges...)
+			completedComparisons += r.countComparisons(meta, fromTable)
+			continue
+		}
+		
 		for _, fromCol := range fromTable.Columns {
 			// 跳过主键列
 			if fromCol.IsPrimaryKey {
@@ -68,7 +101,7 @@ func (r *RelationshipInferer) InferRelationships(meta *adapter.SchemaMetadata) (
 			
 			// 与所有其他表的主键比较
 			for _, toTable := range meta.Tables {
-				if fromTable.Name == toTable.Name {
+				if !r.shouldCompare(fromTable.QualifiedName(), toTable.QualifiedName()) {
 					continue
 				}
 				
@@ -85,74 +118,107 @@ func (r *RelationshipInferer) InferRelationships(meta *adapter.SchemaMetadata) (
 					
 					// 计算关系置信度
 					edge := r.calculateRelationship(
-						fromTable.Name, fromCol,
-						toTable.Name, toCol,
+						fromTable.QualifiedName(), fromCol,
+						toTable.QualifiedName(), toCol,
 					)
 					
-					// 降低置信度阈值到 0.3
-					if edge != nil && edge.Confidence > 0.3 {
-						edges = append(edges, edge)
+					// 置信度阈值由评分模型决定
+					if edge != nil {
+						tableEdges = append(tableEdges, edge)
 					}
 				}
 			}
 		}
+		
+		edges = append(edges, tableEdges...)
+		if err := r.cp.Save(checkpoint.StageInference, fromName, tableEdges); err != nil {
+			fmt.Printf("  ⚠️  保存检查点失败: %v\n", err)
+		}
 	}
+	r.cp.Flush()
 	
 	fmt.Printf("  完成！共发现 %d 个关系\n", len(edges))
 	
 	return edges, nil
 }
 
+// countComparisons 某个源表需要进行的列比较次数
+func (r *RelationshipInferer) countComparisons(meta *adapter.SchemaMetadata, fromTable adapter.Table) int {
+	count := 0
+	for _, fromCol := range fromTable.Columns {
+		if fromCol.IsPrimaryKey {
+			continue
+		}
+		for _, toTable := range meta.Tables {
+			if !r.shouldCompare(fromTable.QualifiedName(), toTable.QualifiedName()) {
+				continue
+			}
+			for _, toCol := range toTable.Columns {
+				if toCol.IsPrimaryKey {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
 // calculateRelationship 计算两列之间的关系
 func (r *RelationshipInferer) calculateRelationship(
 	fromTable string, fromCol adapter.Column,
 	toTable string, toCol adapter.Column,
 ) *graph.Edge {
+	raw := r.Features(fromTable, fromCol, toTable, toCol)
+	features := AcceptedFeatures(r.scorer, raw)
+	
 	var evidences []graph.Evidence
-	totalScore := 0.0
 	
-	// 1. 命名相似度 (权重 0.3)
-	nameScore := r.calculateNameSimilarity(fromCol.Name, toCol.Name)
-	if nameScore > 0.3 {
+	// 1. 命名相似度
+	if score, ok := features[EvidenceNaming]; ok {
 		evidences = append(evidences, graph.Evidence{
-			Type:        "naming_similarity",
-			Score:       nameScore,
+			Type:        EvidenceNaming,
+			Score:       score,
 			Description: "列名相似度",
-			Details:     fmt.Sprintf("%s ↔ %s (%.2f)", fromCol.Name, toCol.Name, nameScore),
+			Details:     fmt.Sprintf("%s ↔ %s (%.2f)", fromCol.Name, toCol.Name, score),
+		})
+	}
+	
+	// 2. 表名匹配：列名形如 <目标表>[_]<主键>
+	if score, ok := features[EvidenceTableNaming]; ok {
+		evidences = append(evidences, graph.Evidence{
+			Type:        EvidenceTableNaming,
+			Score:       score,
+			Description: "列名指向目标表",
+			Details:     fmt.Sprintf("%s ↔ %s.%s (%.2f)", fromCol.Name, toTable, toCol.Name, score),
 		})
-		totalScore += nameScore * 0.3
 	}
 	
-	// 2. 类型匹配 (权重 0.2)
-	typeScore := r.calculateTypeMatch(fromCol, toCol)
-	if typeScore > 0 {
+	// 3. 类型匹配
+	if score, ok := features[EvidenceTypeMatch]; ok {
 		evidences = append(evidences, graph.Evidence{
-			Type:        "type_match",
-			Score:       typeScore,
+			Type:        EvidenceTypeMatch,
+			Score:       score,
 			Description: "数据类型匹配",
 			Details:     fmt.Sprintf("%s(%d) ↔ %s(%d)", fromCol.DataType, fromCol.Length, toCol.DataType, toCol.Length),
 		})
-		totalScore += typeScore * 0.2
 	}
 	
-	// 3. 值集合包含 (权重 0.5) - 最重要的证据
-	containmentScore, err := r.calculateValueContainment(fromTable, fromCol.Name, toTable, toCol.Name)
-	if err == nil && containmentScore > 0.3 {
+	// 4. 值集合包含 - 最重要的证据
+	if score, ok := features[EvidenceContainment]; ok {
 		evidences = append(evidences, graph.Evidence{
-			Type:        "value_containment",
-			Score:       containmentScore,
+			Type:        EvidenceContainment,
+			Score:       score,
 			Description: "值集合包含度",
-			Details:     fmt.Sprintf("%.1f%% 的值存在于目标表", containmentScore*100),
+			Details:     fmt.Sprintf("%.1f%% 的值存在于目标表", score*100),
 		})
-		totalScore += containmentScore * 0.5
 	}
 	
 	if len(evidences) == 0 {
 		return nil
 	}
 	
-	// 降低置信度阈值到 0.3，更容易发现关系
-	if totalScore < 0.3 {
+	totalScore := r.scorer.Score(features)
+	if totalScore <= r.scorer.Threshold() {
 		return nil
 	}
 	
@@ -171,9 +237,46 @@ func (r *RelationshipInferer) calculateRelationship(
 		},
 	}
 	
+	// 跨 schema 的关系单独标记，便于渲染和审核时关注
+	fromSchema, _ := adapter.SplitQualifiedName(fromTable)
+	toSchema, _ := adapter.SplitQualifiedName(toTable)
+	if fromSchema != toSchema {
+		edge.Properties["cross_schema"] = true
+	}
+	
 	return edge
 }
 
+// Features 计算候选列对的原始证据得分（未经阈值过滤，供评分和标定使用）
+func (r *RelationshipInferer) Features(
+	fromTable string, fromCol adapter.Column,
+	toTable string, toCol adapter.Column,
+) map[string]float64 {
+	features := map[string]float64{
+		EvidenceNaming:      r.calculateNameSimilarity(fromCol.Name, toCol.Name),
+		EvidenceTableNaming: r.calculateTableNameMatch(fromCol.Name, toTable, toCol.Name),
+		EvidenceTypeMatch:   r.calculateTypeMatch(fromCol, toCol),
+	}
+	
+	containmentScore, err := r.calculateValueContainment(fromTable, fromCol.Name, toTable, toCol.Name)
+	if err == nil {
+		features[EvidenceContainment] = containmentScore
+	}
+	
+	return features
+}
+
+// AcceptedFeatures 按评分模型的证据阈值过滤原始得分
+func AcceptedFeatures(scorer Scorer, raw map[string]float64) map[string]float64 {
+	accepted := make(map[string]float64)
+	for evidenceType, score := range raw {
+		if score > scorer.EvidenceThreshold(evidenceType) {
+			accepted[evidenceType] = score
+		}
+	}
+	return accepted
+}
+
 // calculateNameSimilarity 计算命名相似度
 func (r *RelationshipInferer) calculateNameSimilarity(name1, name2 string) float64 {
 	// 标准化命名
@@ -206,6 +309,39 @@ func (r *RelationshipInferer) calculateNameSimilarity(name1, name2 string) float
 	return 0
 }
 
+// calculateTableNameMatch 计算列名与目标表名的匹配度
+// 例如 customer_id → customer.id、CustomerID → Customers.ID、cCusCode → Customer.cCusCode
+func (r *RelationshipInferer) calculateTableNameMatch(fromCol, toTable, toCol string) float64 {
+	table := r.naming.tableStem(toTable)
+	if table == "" {
+		return 0
+	}
+	
+	// <table>[_]<pk> 完全匹配
+	pk := normalizeIdentifier(toCol)
+	if normalizeIdentifier(fromCol) == table+pk {
+		return 1.0
+	}
+	
+	stem := r.naming.columnStem(fromCol)
+	if stem == "" {
+		return 0
+	}
+	
+	switch {
+	case stem == table:
+		return 0.9
+	case len(stem) >= 3 && strings.HasPrefix(table, stem):
+		// 缩写前缀：cus → customer，dep → department
+		return 0.7
+	case len(stem) >= 2 && isSubsequence(stem, table):
+		// 缩写：wh → warehouse
+		return 0.5
+	}
+	
+	return 0
+}
+
 // calculateTypeMatch 计算类型匹配度
 func (r *RelationshipInferer) calculateTypeMatch(col1, col2 adapter.Column) float64 {
 	// 类型必须兼容
@@ -230,6 +366,11 @@ func (r *RelationshipInferer) calculateTypeMatch(col1, col2 adapter.Column) floa
 
 // isTypeCompatible 判断类型是否兼容
 func (r *RelationshipInferer) isTypeCompatible(type1, type2 string) bool {
+	return typesCompatible(type1, type2)
+}
+
+// typesCompatible 同一类型族（字符串/整数）的类型视为兼容
+func typesCompatible(type1, type2 string) bool {
 	t1 := strings.ToLower(type1)
 	t2 := strings.ToLower(type2)
 	
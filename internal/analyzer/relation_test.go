@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"schema-analyzer/internal/adapter"
 	"testing"
 )
 
@@ -14,9 +15,9 @@ func TestCalculateNameSimilarity(t *testing.T) {
 		minScore float64
 	}{
 		{"cDepCode", "cDepCode", 1.0, 1.0},
-		{"cDepCode", "DepCode", 0.8, 0.7},
+		{"cDepCode", "DepCode", 1.0, 1.0},
 		{"DepartmentID", "DepID", 0.0, 0.0},
-		{"UserID", "UserId", 0.9, 0.8},
+		{"UserID", "UserId", 1.0, 1.0},
 	}
 	
 	for _, tt := range tests {
@@ -59,3 +60,69 @@ func TestIsTypeCompatible(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateTableNameMatch(t *testing.T) {
+	r := NewRelationshipInferer(nil)
+	
+	tests := []struct {
+		fromCol  string
+		toTable  string
+		toCol    string
+		minScore float64
+		maxScore float64
+	}{
+		{"customer_id", "customer", "id", 1.0, 1.0},
+		{"CustomerID", "Customers", "ID", 1.0, 1.0},
+		{"customer_id", "customers", "customer_id", 0.9, 0.9},
+		{"cCusCode", "Customer", "cCusCode", 0.7, 0.7},
+		{"cDepCode", "Department", "cDepCode", 0.7, 0.7},
+		{"cWhCode", "Warehouse", "cWhCode", 0.5, 0.5},
+		{"cCusCode", "Vendor", "cVenCode", 0, 0},
+		{"amount", "customer", "id", 0, 0},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.fromCol+"_"+tt.toTable, func(t *testing.T) {
+			score := r.calculateTableNameMatch(tt.fromCol, tt.toTable, tt.toCol)
+			if score < tt.minScore || score > tt.maxScore {
+				t.Errorf("expected [%.2f, %.2f], got %.2f", tt.minScore, tt.maxScore, score)
+			}
+		})
+	}
+}
+
+func TestTableNameMatchGenericProfile(t *testing.T) {
+	r := NewRelationshipInferer(nil)
+	r.SetNamingProfile(GenericNamingProfile)
+	
+	// 通用规范下不剥离匈牙利前缀
+	if score := r.calculateTableNameMatch("cCusCode", "Customer", "cCusCode"); score != 0 {
+		t.Errorf("expected 0, got %.2f", score)
+	}
+	if score := r.calculateTableNameMatch("order_id", "tbl_orders", "id"); score != 1.0 {
+		t.Errorf("expected 1.0, got %.2f", score)
+	}
+}
+
+func TestCalculateRelationshipCrossSchema(t *testing.T) {
+	values := &adapter.ColumnStats{TotalRows: 100, TopValues: []adapter.ValueCount{{Value: "C001", Count: 60}, {Value: "C002", Count: 40}}}
+	db := &stubAdapter{stats: map[string]*adapter.ColumnStats{
+		"erp.Orders.cCusCode":   values,
+		"erp.Customer.cCusCode": values,
+		"crm.Customer.cCusCode": values,
+	}}
+	r := NewRelationshipInferer(db)
+	col := adapter.Column{Name: "cCusCode", DataType: "varchar", Length: 20}
+
+	cross := r.calculateRelationship("erp.Orders", col, "crm.Customer", col)
+	if cross == nil || cross.Properties["cross_schema"] != true {
+		t.Fatalf("expected a cross-schema edge, got %+v", cross)
+	}
+	same := r.calculateRelationship("erp.Orders", col, "erp.Customer", col)
+	if same == nil {
+		t.Fatal("expected an edge within the schema")
+	}
+	if _, ok := same.Properties["cross_schema"]; ok {
+		t.Errorf("edge within one schema should not be marked, got %+v", same.Properties)
+	}
+}
@@ -0,0 +1,143 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// 证据类型
+const (
+	EvidenceNaming      = "naming_similarity"
+	EvidenceTableNaming = "table_naming"
+	EvidenceTypeMatch   = "type_match"
+	EvidenceContainment = "value_containment"
+)
+
+// EvidenceTypes 参与评分的证据类型（固定顺序，用于标定）
+var EvidenceTypes = []string{EvidenceNaming, EvidenceTableNaming, EvidenceTypeMatch, EvidenceContainment}
+
+// 评分模型类型
+const (
+	ScorerLinear   = "linear"   // 加权求和（上限 1.0）
+	ScorerLogistic = "logistic" // sigmoid(bias + Σ 权重 × 得分)
+)
+
+// Scorer 关系评分模型
+type Scorer interface {
+	// Score 根据各项证据得分计算置信度 0-1
+	Score(features map[string]float64) float64
+
+	// Threshold 保留关系的最低置信度
+	Threshold() float64
+
+	// EvidenceThreshold 证据被采纳的最低得分（低于等于该值的证据不计入）
+	EvidenceThreshold(evidenceType string) float64
+}
+
+// ScoringModel 可配置的评分模型（可从 JSON 文件加载，也可由 calibrate 命令生成）
+type ScoringModel struct {
+	Kind        string             `json:"kind"`
+	Weights     map[string]float64 `json:"weights"`
+	Bias        float64            `json:"bias"`
+	MinEvidence map[string]float64 `json:"min_evidence"`
+	MinScore    float64            `json:"threshold"`
+}
+
+// DefaultScoringModel 默认的加权评分模型
+func DefaultScoringModel() *ScoringModel {
+	return &ScoringModel{
+		Kind: ScorerLinear,
+		Weights: map[string]float64{
+			EvidenceNaming:      0.3,
+			EvidenceTableNaming: 0.2,
+			EvidenceTypeMatch:   0.2,
+			EvidenceContainment: 0.5,
+		},
+		MinEvidence: map[string]float64{
+			EvidenceNaming:      0.3,
+			EvidenceTableNaming: 0,
+			EvidenceTypeMatch:   0,
+			EvidenceContainment: 0.3,
+		},
+		MinScore: 0.3,
+	}
+}
+
+// LoadScoringModel 从 JSON 文件加载评分模型，未配置的字段使用默认值
+func LoadScoringModel(path string) (*ScoringModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	model := DefaultScoringModel()
+	var loaded ScoringModel
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("解析评分模型失败: %v", err)
+	}
+
+	if loaded.Kind != "" {
+		if loaded.Kind != ScorerLinear && loaded.Kind != ScorerLogistic {
+			return nil, fmt.Errorf("不支持的评分模型类型: %s", loaded.Kind)
+		}
+		model.Kind = loaded.Kind
+	}
+	for k, v := range loaded.Weights {
+		model.Weights[k] = v
+	}
+	for k, v := range loaded.MinEvidence {
+		model.MinEvidence[k] = v
+	}
+	model.Bias = loaded.Bias
+	if loaded.MinScore > 0 {
+		model.MinScore = loaded.MinScore
+	}
+
+	return model, nil
+}
+
+// Save 保存评分模型到 JSON 文件
+func (m *ScoringModel) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Score 计算置信度
+func (m *ScoringModel) Score(features map[string]float64) float64 {
+	sum := 0.0
+	for evidenceType, score := range features {
+		sum += m.Weights[evidenceType] * score
+	}
+
+	if m.Kind == ScorerLogistic {
+		return sigmoid(m.Bias + sum)
+	}
+
+	sum += m.Bias
+	if sum > 1.0 {
+		return 1.0
+	}
+	if sum < 0 {
+		return 0
+	}
+	return sum
+}
+
+// Threshold 保留关系的最低置信度
+func (m *ScoringModel) Threshold() float64 {
+	return m.MinScore
+}
+
+// EvidenceThreshold 证据被采纳的最低得分
+func (m *ScoringModel) EvidenceThreshold(evidenceType string) float64 {
+	return m.MinEvidence[evidenceType]
+}
+
+func sigmoid(x float64) float64 {
+	return 1.0 / (1.0 + math.Exp(-x))
+}
@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"testing"
+)
+
+func TestDefaultScoringModel(t *testing.T) {
+	model := DefaultScoringModel()
+
+	score := model.Score(map[string]float64{
+		EvidenceNaming:      1.0,
+		EvidenceTypeMatch:   1.0,
+		EvidenceContainment: 0.98,
+	})
+	if score < 0.98 || score > 1.0 {
+		t.Errorf("expected ~0.99, got %f", score)
+	}
+
+	// 证据采纳阈值：命名相似度 0.3 以下不计入
+	accepted := AcceptedFeatures(model, map[string]float64{
+		EvidenceNaming:    0.2,
+		EvidenceTypeMatch: 0.6,
+	})
+	if _, ok := accepted[EvidenceNaming]; ok {
+		t.Errorf("naming evidence below threshold should be dropped")
+	}
+	if accepted[EvidenceTypeMatch] != 0.6 {
+		t.Errorf("type evidence should be kept")
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	var samples []CalibrationSample
+	for i := 0; i < 20; i++ {
+		// 正样本：值包含度高
+		samples = append(samples, CalibrationSample{
+			Pair: LabeledPair{Related: true},
+			Features: map[string]float64{
+				EvidenceNaming:      0.8,
+				EvidenceTypeMatch:   1.0,
+				EvidenceContainment: 0.9 + float64(i%5)*0.02,
+			},
+		})
+		// 负样本：命名相似但值不包含
+		samples = append(samples, CalibrationSample{
+			Pair: LabeledPair{Related: false},
+			Features: map[string]float64{
+				EvidenceNaming:      0.8,
+				EvidenceTypeMatch:   1.0,
+				EvidenceContainment: float64(i%3) * 0.1,
+			},
+		})
+	}
+
+	model, report, err := Calibrate(samples, DefaultScoringModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Kind != ScorerLogistic {
+		t.Errorf("expected logistic model, got %s", model.Kind)
+	}
+	if model.Weights[EvidenceContainment] <= model.Weights[EvidenceNaming] {
+		t.Errorf("containment should outweigh naming: %v", model.Weights)
+	}
+	if report.F1 < 0.99 {
+		t.Errorf("expected separable samples to reach F1 1.0, got %f", report.F1)
+	}
+}
+
+func TestCalibrateRequiresBothClasses(t *testing.T) {
+	samples := []CalibrationSample{
+		{Pair: LabeledPair{Related: true}, Features: map[string]float64{EvidenceNaming: 1.0}},
+	}
+	if _, _, err := Calibrate(samples, DefaultScoringModel()); err == nil {
+		t.Errorf("expected error for single-class samples")
+	}
+}
@@ -0,0 +1,180 @@
+package checkpoint
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 扫描流水线中可断点续跑的阶段
+const (
+	StageColumnStats = "column_stats"     // 列采样统计，key 为 表.列
+	StageProfile     = "profile"          // 列数据画像，key 为 表.列
+	StageInference   = "inference"        // 关系推断，key 为源表
+	StageAITables    = "ai_tables"        // AI 表含义分析，key 为表
+	StageAIRelations = "ai_relations"     // AI 表关系分析
+	StageAIBatches   = "ai_field_batches" // AI 字段批量解释，key 为批次内容的摘要，结果按 表.列 索引
+)
+
+// flushInterval 两次写盘的最短间隔，避免每完成一列就重写整个文件
+const flushInterval = 2 * time.Second
+
+// Store 检查点：按阶段保存已完成工作单元的结果，中断后用 --resume 从中恢复。
+// 所有方法对 nil 安全，未启用检查点时传 nil 即可
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	flushed time.Time
+	dirty   bool
+
+	Signature string                                `json:"signature"`
+	UpdatedAt time.Time                             `json:"updated_at"`
+	Stages    map[string]map[string]json.RawMessage `json:"stages"`
+}
+
+// Open 打开检查点。resume 为 true 且文件存在时加载已有进度，
+// 签名（扫描参数的摘要）不一致时报错，避免把不同参数的结果混在一起；否则从头开始
+func Open(path, signature string, resume bool) (*Store, error) {
+	s := &Store{path: path, Signature: signature, Stages: make(map[string]map[string]json.RawMessage)}
+	if !resume {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved Store
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("解析检查点 %s 失败: %v", path, err)
+	}
+	if saved.Signature != signature {
+		return nil, fmt.Errorf("检查点 %s 与当前扫描参数不一致，请去掉 --resume 重新扫描", path)
+	}
+	if saved.Stages != nil {
+		s.Stages = saved.Stages
+	}
+	s.UpdatedAt = saved.UpdatedAt
+	return s, nil
+}
+
+// Signature 根据扫描参数计算签名
+func Signature(parts ...string) string {
+	h := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(h[:])
+}
+
+// Key 由多个部分生成定长的 key（如一批字段的列表）
+func Key(parts ...string) string {
+	return Signature(parts...)[:16]
+}
+
+// Load 读取已完成的结果到 v，不存在时返回 false
+func (s *Store) Load(stage, key string, v interface{}) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	raw, ok := s.Stages[stage][key]
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(raw, v) == nil
+}
+
+// Save 记录一个已完成的工作单元，距上次写盘超过 flushInterval 时写盘
+func (s *Store) Save(stage, key string, v interface{}) error {
+	if s == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Stages[stage] == nil {
+		s.Stages[stage] = make(map[string]json.RawMessage)
+	}
+	s.Stages[stage][key] = raw
+	s.dirty = true
+	if time.Since(s.flushed) < flushInterval {
+		return nil
+	}
+	return s.flushLocked()
+}
+
+// Count 某个阶段已完成的工作单元数
+func (s *Store) Count(stage string) int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.Stages[stage])
+}
+
+// Flush 把未写盘的进度写入文件（阶段结束时调用）
+func (s *Store) Flush() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	return s.flushLocked()
+}
+
+// flushLocked 先写临时文件再改名，避免中断时留下损坏的检查点
+func (s *Store) flushLocked() error {
+	s.UpdatedAt = time.Now()
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.flushed = s.UpdatedAt
+	s.dirty = false
+	return nil
+}
+
+// Remove 扫描完成后删除检查点
+func (s *Store) Remove() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dirty = false
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Path 检查点文件路径
+func (s *Store) Path() string {
+	return s.path
+}
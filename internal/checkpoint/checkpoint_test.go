@@ -0,0 +1,55 @@
+package checkpoint
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	signature := Signature("mysql", "erp", "1000")
+
+	cp, err := Open(path, signature, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Save(StageColumnStats, "dbo.Customer.cCusCode", map[string]int{"total_rows": 1000}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Save(StageInference, "dbo.SO_SOMain", []string{"edge"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := Open(path, signature, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats map[string]int
+	if !resumed.Load(StageColumnStats, "dbo.Customer.cCusCode", &stats) || stats["total_rows"] != 1000 {
+		t.Errorf("expected saved column stats to be restored, got %v", stats)
+	}
+	if resumed.Count(StageInference) != 1 || resumed.Load(StageInference, "dbo.Customer", &[]string{}) {
+		t.Error("unexpected inference progress")
+	}
+
+	if _, err := Open(path, Signature("mysql", "erp", "5000"), true); err == nil {
+		t.Error("expected a signature mismatch to be rejected")
+	}
+
+	if err := resumed.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	fresh, err := Open(path, signature, true)
+	if err != nil || fresh.Count(StageColumnStats) != 0 {
+		t.Errorf("expected an empty checkpoint after removal, got %v", err)
+	}
+
+	// 未启用检查点时 nil 也能安全调用
+	var disabled *Store
+	if disabled.Load(StageProfile, "x", &stats) || disabled.Save(StageProfile, "x", 1) != nil {
+		t.Error("nil store should be a no-op")
+	}
+}
@@ -0,0 +1,262 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"fmt"
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/analyzer"
+	"schema-analyzer/internal/graph"
+	"sort"
+	"strings"
+)
+
+// DefaultThresholds 默认评估的置信度阈值
+var DefaultThresholds = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9}
+
+// GroundTruth 标准答案：关系键 -> 是否为真实关系
+// 不在集合中的预测按"闭世界"假设视为错误
+type GroundTruth map[string]bool
+
+// FromForeignKeys 以声明的外键作为标准答案
+func FromForeignKeys(fks []adapter.ForeignKey) GroundTruth {
+	truth := make(GroundTruth)
+	for _, fk := range fks {
+		pair := analyzer.LabeledPair{
+			FromTable:  fk.FromTable,
+			FromColumn: fk.FromColumn,
+			ToTable:    fk.ToTable,
+			ToColumn:   fk.ToColumn,
+		}
+		truth[pair.Key()] = true
+	}
+	return truth
+}
+
+// FromLabels 以标注文件作为标准答案
+func FromLabels(pairs []analyzer.LabeledPair) GroundTruth {
+	truth := make(GroundTruth)
+	for _, pair := range pairs {
+		truth[pair.Key()] = pair.Related
+	}
+	return truth
+}
+
+// Positives 真实关系数量
+func (t GroundTruth) Positives() int {
+	count := 0
+	for _, related := range t {
+		if related {
+			count++
+		}
+	}
+	return count
+}
+
+// ThresholdResult 某个置信度阈值下的评估结果
+type ThresholdResult struct {
+	Threshold      float64  `json:"threshold"`
+	TruePositives  int      `json:"true_positives"`
+	FalsePositives int      `json:"false_positives"`
+	FalseNegatives int      `json:"false_negatives"`
+	Precision      float64  `json:"precision"`
+	Recall         float64  `json:"recall"`
+	F1             float64  `json:"f1"`
+	FalsePositive  []string `json:"false_positive_edges"`
+	FalseNegative  []string `json:"false_negative_edges"`
+}
+
+// Report 评估报告
+type Report struct {
+	TruthCount     int               `json:"truth_count"`
+	PredictedCount int               `json:"predicted_count"`
+	Results        []ThresholdResult `json:"results"`
+}
+
+// Options 评估选项
+type Options struct {
+	NamingProfile analyzer.NamingProfile
+	Scorer        analyzer.Scorer
+	Thresholds    []float64
+
+	KeepAllCandidates bool // 不裁剪候选关系（默认每个引用列只保留最佳目标）
+}
+
+// Run 隐藏声明外键后运行关系推断，并与标准答案对比
+func Run(a adapter.DBAdapter, truth GroundTruth, opts Options) (*Report, error) {
+	if len(opts.Thresholds) == 0 {
+		opts.Thresholds = DefaultThresholds
+	}
+	if opts.Scorer == nil {
+		opts.Scorer = analyzer.DefaultScoringModel()
+	}
+	if opts.NamingProfile.Name == "" {
+		opts.NamingProfile = analyzer.U8NamingProfile
+	}
+
+	hidden := HideForeignKeys(a)
+	meta, err := hidden.IntrospectSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	// 推断时放宽到最低评估阈值，由 Evaluate 按阈值逐档统计
+	lowest := opts.Thresholds[0]
+	for _, t := range opts.Thresholds {
+		if t < lowest {
+			lowest = t
+		}
+	}
+
+	inferer := analyzer.NewRelationshipInferer(hidden)
+	inferer.SetNamingProfile(opts.NamingProfile)
+	inferer.SetScorer(&thresholdScorer{Scorer: opts.Scorer, threshold: lowest - 1e-9})
+
+	edges, err := inferer.InferRelationships(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.KeepAllCandidates {
+		edges = analyzer.NewEdgePruner().Prune(edges, meta).Kept
+	}
+
+	return Evaluate(edges, truth, opts.Thresholds), nil
+}
+
+// thresholdScorer 覆盖评分模型的保留阈值
+type thresholdScorer struct {
+	analyzer.Scorer
+	threshold float64
+}
+
+// Threshold 保留关系的最低置信度
+func (s *thresholdScorer) Threshold() float64 {
+	return s.threshold
+}
+
+// Evaluate 对比推断出的边与标准答案，计算各阈值下的精确率、召回率和 F1
+func Evaluate(edges []*graph.Edge, truth GroundTruth, thresholds []float64) *Report {
+	// 同一列对只保留最高置信度（防御重复边）
+	predicted := make(map[string]float64)
+	for _, edge := range edges {
+		if edge.Type != graph.EdgeTypeInferredFK {
+			continue
+		}
+		if _, hasCol := edge.Properties["from_column"]; !hasCol {
+			continue
+		}
+		if edge.Confidence > predicted[edge.ID] {
+			predicted[edge.ID] = edge.Confidence
+		}
+	}
+
+	report := &Report{
+		TruthCount:     truth.Positives(),
+		PredictedCount: len(predicted),
+	}
+
+	for _, threshold := range thresholds {
+		result := ThresholdResult{Threshold: threshold}
+		found := make(map[string]bool)
+
+		for key, confidence := range predicted {
+			if confidence < threshold {
+				continue
+			}
+			if truth[key] {
+				result.TruePositives++
+				found[key] = true
+			} else {
+				result.FalsePositives++
+				result.FalsePositive = append(result.FalsePositive, fmt.Sprintf("%s (%.2f)", key, confidence))
+			}
+		}
+
+		for key, related := range truth {
+			if related && !found[key] {
+				result.FalseNegatives++
+				result.FalseNegative = append(result.FalseNegative, key)
+			}
+		}
+
+		sort.Strings(result.FalsePositive)
+		sort.Strings(result.FalseNegative)
+		result.Precision, result.Recall, result.F1 = analyzer.PrecisionRecallF1(
+			result.TruePositives, result.FalsePositives, result.FalseNegatives)
+		report.Results = append(report.Results, result)
+	}
+
+	return report
+}
+
+// Best F1 最高的阈值结果
+func (r *Report) Best() *ThresholdResult {
+	var best *ThresholdResult
+	for i := range r.Results {
+		if best == nil || r.Results[i].F1 > best.F1 {
+			best = &r.Results[i]
+		}
+	}
+	return best
+}
+
+// ToJSON 导出为 JSON
+func (r *Report) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Markdown 渲染为 Markdown 报告
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+
+	sb.WriteString("# 关系推断评估报告\n\n")
+	sb.WriteString(fmt.Sprintf("- 标准答案关系数: %d\n", r.TruthCount))
+	sb.WriteString(fmt.Sprintf("- 推断候选关系数: %d\n\n", r.PredictedCount))
+
+	sb.WriteString("| 阈值 | TP | FP | FN | 精确率 | 召回率 | F1 |\n")
+	sb.WriteString("|------|----|----|----|--------|--------|----|\n")
+	for _, res := range r.Results {
+		sb.WriteString(fmt.Sprintf("| %.2f | %d | %d | %d | %.1f%% | %.1f%% | %.3f |\n",
+			res.Threshold, res.TruePositives, res.FalsePositives, res.FalseNegatives,
+			res.Precision*100, res.Recall*100, res.F1))
+	}
+	sb.WriteString("\n")
+
+	for _, res := range r.Results {
+		if len(res.FalsePositive) == 0 && len(res.FalseNegative) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("## 阈值 %.2f 的错误列表\n\n", res.Threshold))
+		if len(res.FalsePositive) > 0 {
+			sb.WriteString("**误报 (FP)**\n\n")
+			for _, key := range res.FalsePositive {
+				sb.WriteString(fmt.Sprintf("- `%s`\n", key))
+			}
+			sb.WriteString("\n")
+		}
+		if len(res.FalseNegative) > 0 {
+			sb.WriteString("**漏报 (FN)**\n\n")
+			for _, key := range res.FalseNegative {
+				sb.WriteString(fmt.Sprintf("- `%s`\n", key))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// hiddenFKAdapter 隐藏声明外键的适配器包装，确保推断过程看不到标准答案
+type hiddenFKAdapter struct {
+	adapter.DBAdapter
+}
+
+// HideForeignKeys 包装适配器，使 GetForeignKeys 返回空
+func HideForeignKeys(a adapter.DBAdapter) adapter.DBAdapter {
+	return &hiddenFKAdapter{DBAdapter: a}
+}
+
+// GetForeignKeys 隐藏外键
+func (h *hiddenFKAdapter) GetForeignKeys() ([]adapter.ForeignKey, error) {
+	return nil, nil
+}
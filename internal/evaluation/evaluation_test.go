@@ -0,0 +1,243 @@
+package evaluation
+
+import (
+	"database/sql"
+	"fmt"
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/graph"
+	"sort"
+	"testing"
+)
+
+// memoryAdapter 内存中的测试数据库
+type memoryAdapter struct {
+	meta *adapter.SchemaMetadata
+	data map[string]map[string][]string // table -> column -> values
+	fks  []adapter.ForeignKey
+}
+
+func (m *memoryAdapter) IntrospectSchema() (*adapter.SchemaMetadata, error) {
+	return m.meta, nil
+}
+
+func (m *memoryAdapter) EstimateRowCount(table string) (int64, error) {
+	for _, values := range m.data[table] {
+		return int64(len(values)), nil
+	}
+	return 0, nil
+}
+
+func (m *memoryAdapter) SampleColumnStats(table, column string, sampleSize int) (*adapter.ColumnStats, error) {
+	values, ok := m.data[table][column]
+	if !ok {
+		return nil, fmt.Errorf("no data for %s.%s", table, column)
+	}
+
+	counts := make(map[string]int64)
+	stats := &adapter.ColumnStats{TotalRows: int64(len(values))}
+	for _, v := range values {
+		if v == "" {
+			stats.NullCount++
+			continue
+		}
+		counts[v]++
+	}
+	stats.DistinctCount = int64(len(counts))
+	for v, c := range counts {
+		stats.TopValues = append(stats.TopValues, adapter.ValueCount{Value: v, Count: c})
+	}
+	sort.Slice(stats.TopValues, func(i, j int) bool {
+		if stats.TopValues[i].Count != stats.TopValues[j].Count {
+			return stats.TopValues[i].Count > stats.TopValues[j].Count
+		}
+		return stats.TopValues[i].Value < stats.TopValues[j].Value
+	})
+	if len(stats.TopValues) > 10 {
+		stats.TopValues = stats.TopValues[:10]
+	}
+	return stats, nil
+}
+
+func (m *memoryAdapter) GetPrimaryKeys(table string) ([]string, error) {
+	var keys []string
+	for _, t := range m.meta.Tables {
+		if t.Name != table {
+			continue
+		}
+		for _, c := range t.Columns {
+			if c.IsPrimaryKey {
+				keys = append(keys, c.Name)
+			}
+		}
+	}
+	return keys, nil
+}
+
+func (m *memoryAdapter) GetForeignKeys() ([]adapter.ForeignKey, error) {
+	return m.fks, nil
+}
+
+func (m *memoryAdapter) GetValuePairs(table, keyColumn, valueColumn string, limit int) ([]adapter.ValuePair, error) {
+	keys := m.data[table][keyColumn]
+	labels := m.data[table][valueColumn]
+	var pairs []adapter.ValuePair
+	for i := 0; i < len(keys) && i < limit; i++ {
+		pairs = append(pairs, adapter.ValuePair{Key: keys[i], Label: labels[i]})
+	}
+	return pairs, nil
+}
+
+func (m *memoryAdapter) SampleValues(table, column string, sampleSize int) ([]sql.NullString, error) {
+	var values []sql.NullString
+	for i, v := range m.data[table][column] {
+		if i == sampleSize {
+			break
+		}
+		values = append(values, sql.NullString{String: v, Valid: v != ""})
+	}
+	return values, nil
+}
+
+func (m *memoryAdapter) GetColumnRange(table, column string) (min, max sql.NullString, err error) {
+	for _, v := range m.data[table][column] {
+		if v == "" {
+			continue
+		}
+		if !min.Valid || v < min.String {
+			min = sql.NullString{String: v, Valid: true}
+		}
+		if !max.Valid || v > max.String {
+			max = sql.NullString{String: v, Valid: true}
+		}
+	}
+	return min, max, nil
+}
+
+func (m *memoryAdapter) Close() error {
+	return nil
+}
+
+// newU8Fixture 一个简化的 U8 销售订单结构
+func newU8Fixture() *memoryAdapter {
+	varchar := func(name string, length int, pk bool) adapter.Column {
+		return adapter.Column{Name: name, DataType: "nvarchar", Length: length, IsPrimaryKey: pk}
+	}
+	integer := func(name string, pk bool) adapter.Column {
+		return adapter.Column{Name: name, DataType: "int", IsPrimaryKey: pk}
+	}
+
+	return &memoryAdapter{
+		meta: &adapter.SchemaMetadata{Tables: []adapter.Table{
+			{Name: "Customer", Columns: []adapter.Column{varchar("cCusCode", 20, true), varchar("cCusName", 98, false)}},
+			{Name: "Department", Columns: []adapter.Column{varchar("cDepCode", 12, true), varchar("cDepName", 255, false)}},
+			{Name: "Inventory", Columns: []adapter.Column{varchar("cInvCode", 60, true), varchar("cInvName", 255, false)}},
+			{Name: "SO_SOMain", Columns: []adapter.Column{
+				integer("ID", true), varchar("cCusCode", 20, false), varchar("cDepCode", 12, false), varchar("cMemo", 255, false),
+			}},
+			{Name: "SO_SODetails", Columns: []adapter.Column{
+				integer("AutoID", true), integer("ID", false), varchar("cInvCode", 60, false), integer("iQuantity", false),
+			}},
+		}},
+		data: map[string]map[string][]string{
+			"Customer": {
+				"cCusCode": {"C001", "C002", "C003", "C004"},
+				"cCusName": {"华东商贸", "华南电子", "北方机械", "西部物流"},
+			},
+			"Department": {
+				"cDepCode": {"01", "02", "03"},
+				"cDepName": {"销售部", "采购部", "财务部"},
+			},
+			"Inventory": {
+				"cInvCode": {"I001", "I002", "I003", "I004", "I005"},
+				"cInvName": {"螺栓", "螺母", "垫片", "轴承", "齿轮"},
+			},
+			"SO_SOMain": {
+				"ID":       {"1", "2", "3", "4", "5"},
+				"cCusCode": {"C001", "C002", "C001", "C003", "C004"},
+				"cDepCode": {"01", "01", "02", "01", "03"},
+				"cMemo":    {"", "加急", "", "", "月结"},
+			},
+			"SO_SODetails": {
+				"AutoID":    {"101", "102", "103", "104", "105", "106"},
+				"ID":        {"1", "1", "2", "3", "4", "5"},
+				"cInvCode":  {"I001", "I002", "I003", "I001", "I005", "I004"},
+				"iQuantity": {"10", "20", "50", "10", "100", "30"},
+			},
+		},
+		fks: []adapter.ForeignKey{
+			{FromTable: "SO_SOMain", FromColumn: "cCusCode", ToTable: "Customer", ToColumn: "cCusCode"},
+			{FromTable: "SO_SOMain", FromColumn: "cDepCode", ToTable: "Department", ToColumn: "cDepCode"},
+			{FromTable: "SO_SODetails", FromColumn: "ID", ToTable: "SO_SOMain", ToColumn: "ID"},
+			{FromTable: "SO_SODetails", FromColumn: "cInvCode", ToTable: "Inventory", ToColumn: "cInvCode"},
+		},
+	}
+}
+
+func TestRunOnU8Fixture(t *testing.T) {
+	db := newU8Fixture()
+	truth := FromForeignKeys(db.fks)
+
+	report, err := Run(db, truth, Options{Thresholds: []float64{0.3, 0.5, 0.8}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.TruthCount != 4 {
+		t.Fatalf("expected 4 true relationships, got %d", report.TruthCount)
+	}
+
+	for _, res := range report.Results {
+		t.Logf("threshold %.2f: P=%.2f R=%.2f F1=%.2f FP=%v FN=%v",
+			res.Threshold, res.Precision, res.Recall, res.F1, res.FalsePositive, res.FalseNegative)
+	}
+
+	// 这是回归基线：调整启发式规则后，该 F1 不应下降
+	best := report.Best()
+	if best.Recall < 1.0 {
+		t.Errorf("expected all declared FKs to be recovered, missed %v", best.FalseNegative)
+	}
+	if best.F1 < 0.8 {
+		t.Errorf("expected best F1 >= 0.8, got %.3f", best.F1)
+	}
+}
+
+func TestRunHidesForeignKeys(t *testing.T) {
+	hidden := HideForeignKeys(newU8Fixture())
+	fks, err := hidden.GetForeignKeys()
+	if err != nil || len(fks) != 0 {
+		t.Errorf("expected foreign keys to be hidden, got %v", fks)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	truth := GroundTruth{
+		"A.b_id->B.id": true,
+		"A.c_id->C.id": true,
+		"A.x->D.id":    false,
+	}
+	edges := []*graph.Edge{
+		{ID: "A.b_id->B.id", Type: graph.EdgeTypeInferredFK, Confidence: 0.9, Properties: map[string]interface{}{"from_column": "b_id"}},
+		{ID: "A.x->D.id", Type: graph.EdgeTypeInferredFK, Confidence: 0.6, Properties: map[string]interface{}{"from_column": "x"}},
+		{ID: "A.c_id->C.id", Type: graph.EdgeTypeInferredFK, Confidence: 0.4, Properties: map[string]interface{}{"from_column": "c_id"}},
+	}
+
+	report := Evaluate(edges, truth, []float64{0.3, 0.5, 0.8})
+
+	tests := []struct {
+		tp, fp, fn int
+	}{
+		{2, 1, 0},
+		{1, 1, 1},
+		{1, 0, 1},
+	}
+	for i, tt := range tests {
+		res := report.Results[i]
+		if res.TruePositives != tt.tp || res.FalsePositives != tt.fp || res.FalseNegatives != tt.fn {
+			t.Errorf("threshold %.2f: expected tp=%d fp=%d fn=%d, got tp=%d fp=%d fn=%d",
+				res.Threshold, tt.tp, tt.fp, tt.fn, res.TruePositives, res.FalsePositives, res.FalseNegatives)
+		}
+	}
+
+	if best := report.Best(); best.Threshold != 0.3 {
+		t.Errorf("expected best threshold 0.3, got %.2f", best.Threshold)
+	}
+}
@@ -19,6 +19,7 @@ type Edge struct {
 	Confidence float64                `json:"confidence"` // 置信度 0-1
 	Evidence   []Evidence             `json:"evidence"`
 	Properties map[string]interface{} `json:"properties"`
+	Alternatives []Alternative        `json:"alternatives,omitempty"` // 被裁剪掉的备选目标
 }
 
 // Evidence 证据
@@ -28,3 +29,11 @@ type Evidence struct {
 	Description string  `json:"description"`
 	Details     string  `json:"details"`
 }
+
+// Alternative 同一引用列的备选目标（裁剪时落选的候选关系）
+type Alternative struct {
+	EdgeID     string     `json:"edge_id"`
+	To         string     `json:"to"` // 节点ID
+	Confidence float64    `json:"confidence"`
+	Evidence   []Evidence `json:"evidence"`
+}
@@ -41,9 +41,38 @@ func (g *SchemaGraph) GetNode(id string) *Node {
 	return g.Nodes[id]
 }
 
+// FromJSON 从 JSON 加载图
+func FromJSON(data []byte) (*SchemaGraph, error) {
+	g := NewSchemaGraph()
+	if err := json.Unmarshal(data, g); err != nil {
+		return nil, err
+	}
+	if g.Nodes == nil {
+		g.Nodes = make(map[string]*Node)
+	}
+	if g.Edges == nil {
+		g.Edges = make(map[string]*Edge)
+	}
+	return g, nil
+}
+
 // ToJSON 导出为JSON
 func (g *SchemaGraph) ToJSON() ([]byte, error) {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 	return json.MarshalIndent(g, "", "  ")
 }
+
+// RemoveEdge 删除边
+func (g *SchemaGraph) RemoveEdge(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.Edges, id)
+}
+
+// GetEdge 获取边
+func (g *SchemaGraph) GetEdge(id string) *Edge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.Edges[id]
+}
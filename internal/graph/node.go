@@ -16,6 +16,7 @@ type Node struct {
 	Type       NodeType               `json:"type"`
 	Name       string                 `json:"name"`
 	Properties map[string]interface{} `json:"properties"`
+	EnumValues []EnumValue            `json:"enum_values,omitempty"` // 码表/枚举列的取值集合
 }
 
 // TableNode 表节点属性
@@ -42,3 +43,10 @@ type Value struct {
 	Count int64   `json:"count"`
 	Ratio float64 `json:"ratio"`
 }
+
+// EnumValue 枚举取值（码表为 键→含义，枚举列为 取值→出现次数）
+type EnumValue struct {
+	Key   string `json:"key"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count,omitempty"`
+}
@@ -0,0 +1,227 @@
+package incremental
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/graph"
+	"sort"
+	"time"
+)
+
+// 表节点上记录目录元数据指纹的属性，下次扫描据此判断表是否变化
+const (
+	PropColumnSignature = "column_signature"
+	PropRowCount        = "row_count"
+	PropLastModified    = "last_modified"
+)
+
+// DefaultRowCountTolerance 行数相对变化超过该比例视为数据有变化
+// （MySQL InnoDB 的 TABLE_ROWS 本身是估计值，容差不宜过小）
+const DefaultRowCountTolerance = 0.1
+
+// ColumnSignature 列结构签名：列名、类型、长度、可空、主键任一变化都会改变签名
+func ColumnSignature(t adapter.Table) string {
+	h := sha1.New()
+	for _, col := range t.Columns {
+		fmt.Fprintf(h, "%s|%s|%d|%t|%t\n", col.Name, col.DataType, col.Length, col.Nullable, col.IsPrimaryKey)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AnnotateTable 把表的指纹写入表节点
+func AnnotateTable(node *graph.Node, t adapter.Table) {
+	if node == nil {
+		return
+	}
+	node.Properties[PropColumnSignature] = ColumnSignature(t)
+	node.Properties[PropRowCount] = t.RowCount
+	if t.LastModified.IsZero() {
+		delete(node.Properties, PropLastModified)
+	} else {
+		node.Properties[PropLastModified] = t.LastModified.UTC().Format(time.RFC3339)
+	}
+}
+
+// TableChange 需要重新分析的表及原因
+type TableChange struct {
+	Table  string
+	Reason string
+}
+
+// Diff 当前元数据与基线的对比结果
+type Diff struct {
+	Changed   []TableChange
+	Removed   []string
+	unchanged map[string]bool
+}
+
+// Compare 对比当前元数据和基线图中的表指纹。
+// 最后修改时间两边都有时以它为准，否则比较行数（相对变化超过 tolerance 视为变化）
+func Compare(baseline *graph.SchemaGraph, meta *adapter.SchemaMetadata, tolerance float64) *Diff {
+	d := &Diff{unchanged: make(map[string]bool)}
+	current := make(map[string]bool, len(meta.Tables))
+
+	for _, t := range meta.Tables {
+		name := t.QualifiedName()
+		current[name] = true
+		if reason := changeReason(baseline.GetNode(name), t, tolerance); reason != "" {
+			d.Changed = append(d.Changed, TableChange{Table: name, Reason: reason})
+		} else {
+			d.unchanged[name] = true
+		}
+	}
+
+	for id, node := range baseline.Nodes {
+		if node.Type == graph.NodeTypeTable && !current[id] {
+			d.Removed = append(d.Removed, id)
+		}
+	}
+	sort.Strings(d.Removed)
+	return d
+}
+
+func changeReason(node *graph.Node, t adapter.Table, tolerance float64) string {
+	if node == nil || node.Type != graph.NodeTypeTable {
+		return "新增表"
+	}
+	signature, _ := node.Properties[PropColumnSignature].(string)
+	if signature == "" {
+		return "基线中没有表指纹"
+	}
+	if signature != ColumnSignature(t) {
+		return "列结构变化"
+	}
+
+	if previous, ok := node.Properties[PropLastModified].(string); ok && !t.LastModified.IsZero() {
+		if previous != t.LastModified.UTC().Format(time.RFC3339) {
+			return fmt.Sprintf("数据有更新（%s → %s）", previous, t.LastModified.UTC().Format(time.RFC3339))
+		}
+		return ""
+	}
+
+	previous := toInt64(node.Properties[PropRowCount])
+	if rowCountChanged(previous, t.RowCount, tolerance) {
+		return fmt.Sprintf("行数变化（%d → %d）", previous, t.RowCount)
+	}
+	return ""
+}
+
+func rowCountChanged(previous, current int64, tolerance float64) bool {
+	if previous == current {
+		return false
+	}
+	base := math.Max(float64(previous), 1)
+	return math.Abs(float64(current-previous))/base > tolerance
+}
+
+// toInt64 兼容直接赋值（int64）和 JSON 反序列化后（float64）的数值
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
+
+// Unchanged 表是否未变化（可沿用基线）。d 为 nil（全量扫描）时总是返回 false
+func (d *Diff) Unchanged(table string) bool {
+	return d != nil && d.unchanged[table]
+}
+
+// ChangedTables 需要重新分析的表集合；d 为 nil 时返回 nil（表示全部）
+func (d *Diff) ChangedTables() map[string]bool {
+	if d == nil {
+		return nil
+	}
+	changed := make(map[string]bool, len(d.Changed))
+	for _, c := range d.Changed {
+		changed[c.Table] = true
+	}
+	return changed
+}
+
+// ChangedMeta 只包含变化表的元数据，用于重新采样、画像和 AI 分析；d 为 nil 时返回 meta 本身
+func (d *Diff) ChangedMeta(meta *adapter.SchemaMetadata) *adapter.SchemaMetadata {
+	if d == nil {
+		return meta
+	}
+	subset := &adapter.SchemaMetadata{}
+	for _, t := range meta.Tables {
+		if !d.unchanged[t.QualifiedName()] {
+			subset.Tables = append(subset.Tables, t)
+		}
+	}
+	for _, idx := range meta.Indexes {
+		if !d.unchanged[idx.Table] {
+			subset.Indexes = append(subset.Indexes, idx)
+		}
+	}
+	return subset
+}
+
+// CarryResult 从基线沿用的内容统计
+type CarryResult struct {
+	Tables  int
+	Columns int
+	Edges   int
+}
+
+// CarryOver 把未变化表的表节点、列节点（含采样统计、AI 解释、画像摘要）和两端都未变化的关系复制到 g。
+// 人工审核过的关系由审核记录重新应用，这里不单独处理
+func CarryOver(baseline, g *graph.SchemaGraph, d *Diff) CarryResult {
+	var result CarryResult
+	if d == nil {
+		return result
+	}
+
+	for id, node := range baseline.Nodes {
+		switch node.Type {
+		case graph.NodeTypeTable:
+			if d.unchanged[id] {
+				restoreTypes(node)
+				g.AddNode(node)
+				result.Tables++
+			}
+		case graph.NodeTypeColumn:
+			if table, _ := node.Properties["table"].(string); d.unchanged[table] {
+				restoreTypes(node)
+				g.AddNode(node)
+				result.Columns++
+			}
+		}
+	}
+
+	for _, edge := range baseline.Edges {
+		fromTable, _ := edge.Properties["from_table"].(string)
+		toTable, _ := edge.Properties["to_table"].(string)
+		if d.unchanged[fromTable] && d.unchanged[toTable] {
+			g.AddEdge(edge)
+			result.Edges++
+		}
+	}
+	return result
+}
+
+// restoreTypes 还原 JSON 反序列化后丢失的属性类型（数值变为 float64、字符串切片变为 []interface{}），
+// 与全量扫描时写入的类型保持一致
+func restoreTypes(node *graph.Node) {
+	if v, ok := node.Properties["length"].(float64); ok {
+		node.Properties["length"] = int(v)
+	}
+	if v, ok := node.Properties["referenced_by"].([]interface{}); ok {
+		refs := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				refs = append(refs, s)
+			}
+		}
+		node.Properties["referenced_by"] = refs
+	}
+}
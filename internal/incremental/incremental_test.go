@@ -0,0 +1,69 @@
+package incremental
+
+import (
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/graph"
+	"testing"
+	"time"
+)
+
+func TestCompareAndCarryOver(t *testing.T) {
+	updated := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	previous := &adapter.SchemaMetadata{Tables: []adapter.Table{
+		{Schema: "dbo", Name: "Customer", RowCount: 1000, Columns: []adapter.Column{{Name: "cCusCode", DataType: "nvarchar", Length: 20, IsPrimaryKey: true}}},
+		{Schema: "dbo", Name: "Inventory", RowCount: 5000, Columns: []adapter.Column{{Name: "cInvCode", DataType: "nvarchar", Length: 60, IsPrimaryKey: true}}},
+		{Schema: "dbo", Name: "SO_SOMain", RowCount: 200, LastModified: updated, Columns: []adapter.Column{{Name: "cSOCode", DataType: "nvarchar", Length: 30}}},
+		{Schema: "dbo", Name: "Obsolete"},
+	}}
+
+	// 用上次的元数据构造基线，并经过一次 JSON 往返
+	g := graph.NewSchemaGraph()
+	for _, table := range previous.Tables {
+		node := &graph.Node{ID: table.QualifiedName(), Type: graph.NodeTypeTable, Name: table.Name, Properties: map[string]interface{}{}}
+		AnnotateTable(node, table)
+		g.AddNode(node)
+		for _, col := range table.Columns {
+			g.AddNode(&graph.Node{
+				ID:         table.QualifiedName() + "." + col.Name,
+				Type:       graph.NodeTypeColumn,
+				Name:       col.Name,
+				Properties: map[string]interface{}{"table": table.QualifiedName(), "length": col.Length},
+			})
+		}
+	}
+	g.AddEdge(&graph.Edge{ID: "customer->inventory", Properties: map[string]interface{}{"from_table": "dbo.Customer", "to_table": "dbo.Inventory"}})
+	g.AddEdge(&graph.Edge{ID: "so->customer", Properties: map[string]interface{}{"from_table": "dbo.SO_SOMain", "to_table": "dbo.Customer"}})
+	data, _ := g.ToJSON()
+	baseline, err := graph.FromJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current := &adapter.SchemaMetadata{Tables: []adapter.Table{
+		previous.Tables[0], // 未变化
+		{Schema: "dbo", Name: "Inventory", RowCount: 5200, Columns: previous.Tables[1].Columns}, // 行数变化在容差内
+		{Schema: "dbo", Name: "SO_SOMain", RowCount: 200, LastModified: updated.Add(time.Hour), Columns: previous.Tables[2].Columns},
+		{Schema: "dbo", Name: "Warehouse"},
+	}}
+
+	diff := Compare(baseline, current, DefaultRowCountTolerance)
+	if !diff.Unchanged("dbo.Customer") || !diff.Unchanged("dbo.Inventory") {
+		t.Errorf("expected Customer and Inventory to be unchanged, got %+v", diff.Changed)
+	}
+	changed := diff.ChangedTables()
+	if len(changed) != 2 || !changed["dbo.SO_SOMain"] || !changed["dbo.Warehouse"] {
+		t.Errorf("expected SO_SOMain and Warehouse to change, got %+v", diff.Changed)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != "dbo.Obsolete" {
+		t.Errorf("expected Obsolete to be removed, got %v", diff.Removed)
+	}
+
+	out := graph.NewSchemaGraph()
+	carried := CarryOver(baseline, out, diff)
+	if carried.Tables != 2 || carried.Columns != 2 || carried.Edges != 1 {
+		t.Errorf("unexpected carry-over %+v", carried)
+	}
+	if _, ok := out.GetNode("dbo.Customer.cCusCode").Properties["length"].(int); !ok {
+		t.Error("expected length to be restored as int after JSON round trip")
+	}
+}
@@ -0,0 +1,545 @@
+package profile
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"schema-analyzer/internal/adapter"
+	"schema-analyzer/internal/checkpoint"
+	"schema-analyzer/internal/graph"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 列的取值类别
+const (
+	KindNumeric  = "numeric"
+	KindDateTime = "datetime"
+	KindString   = "string"
+	KindOther    = "other"
+)
+
+// maxPatterns 每列保留的最常见格式数
+const maxPatterns = 10
+
+// Bucket 分布区间
+type Bucket struct {
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+// LengthStats 字符串长度分布
+type LengthStats struct {
+	Min       int      `json:"min"`
+	Max       int      `json:"max"`
+	Mean      float64  `json:"mean"`
+	Histogram []Bucket `json:"histogram"`
+}
+
+// NumericStats 数值分布
+type NumericStats struct {
+	Min       float64            `json:"min"`
+	Max       float64            `json:"max"`
+	Mean      float64            `json:"mean"`
+	StdDev    float64            `json:"stddev"`
+	Quantiles map[string]float64 `json:"quantiles"` // p5/p25/p50/p75/p95
+	Histogram []Bucket           `json:"histogram"`
+}
+
+// DateStats 日期范围
+type DateStats struct {
+	Earliest string   `json:"earliest"`
+	Latest   string   `json:"latest"`
+	ByYear   []Bucket `json:"by_year"`
+	Invalid  int64    `json:"invalid"` // 无法解析为日期的取值数
+}
+
+// PatternCount 取值格式（字母→A/a，数字→9，汉字→中）及出现次数
+type PatternCount struct {
+	Pattern string `json:"pattern"`
+	Count   int64  `json:"count"`
+	Example string `json:"example"`
+}
+
+// ColumnProfile 列画像
+type ColumnProfile struct {
+	Table    string `json:"table"`
+	Column   string `json:"column"`
+	DataType string `json:"data_type"`
+	Kind     string `json:"kind"`
+
+	SampledRows     int64  `json:"sampled_rows"`
+	NullCount       int64  `json:"null_count"`
+	DistinctCount   int64  `json:"distinct_count"`
+	ZeroCount       int64  `json:"zero_count"`
+	BlankCount      int64  `json:"blank_count"`      // 空字符串
+	WhitespaceCount int64  `json:"whitespace_count"` // 只含空白字符
+	MinValue        string `json:"min_value,omitempty"`
+	MaxValue        string `json:"max_value,omitempty"`
+
+	Length   *LengthStats   `json:"length,omitempty"`
+	Numeric  *NumericStats  `json:"numeric,omitempty"`
+	Dates    *DateStats     `json:"dates,omitempty"`
+	Patterns []PatternCount `json:"patterns,omitempty"`
+}
+
+// TableProfile 表画像
+type TableProfile struct {
+	Name     string           `json:"name"`
+	RowCount int64            `json:"row_count"`
+	Columns  []*ColumnProfile `json:"columns"`
+}
+
+// Report 画像报告
+type Report struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	SampleSize  int             `json:"sample_size"`
+	Tables      []*TableProfile `json:"tables"`
+}
+
+// Profiler 列画像生成器
+type Profiler struct {
+	adapter    adapter.DBAdapter
+	sampleSize int
+	cp         *checkpoint.Store
+}
+
+// NewProfiler 创建列画像生成器
+func NewProfiler(a adapter.DBAdapter) *Profiler {
+	return &Profiler{adapter: a, sampleSize: 1000}
+}
+
+// SetSampleSize 设置采样大小
+func (p *Profiler) SetSampleSize(n int) {
+	if n > 0 {
+		p.sampleSize = n
+	}
+}
+
+// SetCheckpoint 设置检查点：每列画像完成后保存，恢复时跳过已完成的列
+func (p *Profiler) SetCheckpoint(cp *checkpoint.Store) {
+	p.cp = cp
+}
+
+// Profile 为所有表的所有列生成画像，单列失败不影响其他列
+func (p *Profiler) Profile(meta *adapter.SchemaMetadata) (*Report, error) {
+	report := &Report{GeneratedAt: time.Now(), SampleSize: p.sampleSize}
+
+	for _, table := range meta.Tables {
+		name := table.QualifiedName()
+		rowCount, _ := p.adapter.EstimateRowCount(name)
+		tp := &TableProfile{Name: name, RowCount: rowCount}
+		for _, col := range table.Columns {
+			key := name + "." + col.Name
+			var cp *ColumnProfile
+			if !p.cp.Load(checkpoint.StageProfile, key, &cp) {
+				var err error
+				if cp, err = p.ProfileColumn(name, col); err != nil {
+					continue
+				}
+				p.cp.Save(checkpoint.StageProfile, key, cp)
+			}
+			tp.Columns = append(tp.Columns, cp)
+		}
+		report.Tables = append(report.Tables, tp)
+	}
+	p.cp.Flush()
+
+	return report, nil
+}
+
+// ProfileColumn 为单列生成画像：采样取值在本地统计，最小/最大值优先取全表精确值
+func (p *Profiler) ProfileColumn(table string, col adapter.Column) (*ColumnProfile, error) {
+	values, err := p.adapter.SampleValues(table, col.Name, p.sampleSize)
+	if err != nil {
+		return nil, err
+	}
+
+	cp := Compute(table, col, values)
+	if min, max, err := p.adapter.GetColumnRange(table, col.Name); err == nil {
+		if min.Valid {
+			cp.MinValue = min.String
+		}
+		if max.Valid {
+			cp.MaxValue = max.String
+		}
+	}
+	return cp, nil
+}
+
+// Compute 根据采样取值计算列画像
+func Compute(table string, col adapter.Column, values []sql.NullString) *ColumnProfile {
+	cp := &ColumnProfile{
+		Table:       table,
+		Column:      col.Name,
+		DataType:    col.DataType,
+		Kind:        kindOf(col.DataType),
+		SampledRows: int64(len(values)),
+	}
+
+	distinct := make(map[string]bool)
+	patterns := make(map[string]*PatternCount)
+	var present []string
+	for _, v := range values {
+		if !v.Valid {
+			cp.NullCount++
+			continue
+		}
+		distinct[v.String] = true
+		present = append(present, v.String)
+
+		switch {
+		case v.String == "":
+			cp.BlankCount++
+		case strings.TrimSpace(v.String) == "":
+			cp.WhitespaceCount++
+		}
+
+		shape := valuePattern(v.String)
+		if pc, ok := patterns[shape]; ok {
+			pc.Count++
+		} else {
+			patterns[shape] = &PatternCount{Pattern: shape, Count: 1, Example: v.String}
+		}
+	}
+	cp.DistinctCount = int64(len(distinct))
+	cp.Patterns = topPatterns(patterns)
+
+	switch cp.Kind {
+	case KindNumeric:
+		cp.Numeric, cp.ZeroCount = numericStats(present)
+		if cp.Numeric != nil {
+			cp.MinValue = formatNumber(cp.Numeric.Min)
+			cp.MaxValue = formatNumber(cp.Numeric.Max)
+		}
+	case KindDateTime:
+		cp.Dates = dateStats(present)
+		if cp.Dates != nil {
+			cp.MinValue, cp.MaxValue = cp.Dates.Earliest, cp.Dates.Latest
+		}
+	case KindString:
+		cp.Length = lengthStats(present)
+		cp.MinValue, cp.MaxValue = stringRange(present)
+	}
+
+	return cp
+}
+
+// kindOf 按数据类型划分取值类别
+func kindOf(dataType string) string {
+	switch strings.ToLower(dataType) {
+	case "int", "bigint", "smallint", "tinyint", "mediumint", "decimal", "numeric",
+		"float", "real", "double", "money", "smallmoney":
+		return KindNumeric
+	case "date", "datetime", "datetime2", "smalldatetime", "datetimeoffset", "timestamp", "time", "year":
+		return KindDateTime
+	case "char", "nchar", "varchar", "nvarchar", "text", "ntext", "tinytext", "mediumtext", "longtext", "enum", "set":
+		return KindString
+	}
+	return KindOther
+}
+
+// valuePattern 取值格式：大写字母→A，小写字母→a，数字→9，汉字→中，其他字符原样保留
+func valuePattern(s string) string {
+	var sb strings.Builder
+	n := 0
+	for _, r := range s {
+		if n == 32 {
+			sb.WriteString("…")
+			break
+		}
+		switch {
+		case unicode.IsUpper(r):
+			sb.WriteRune('A')
+		case unicode.IsLower(r):
+			sb.WriteRune('a')
+		case unicode.IsDigit(r):
+			sb.WriteRune('9')
+		case unicode.Is(unicode.Han, r):
+			sb.WriteRune('中')
+		default:
+			sb.WriteRune(r)
+		}
+		n++
+	}
+	return sb.String()
+}
+
+func topPatterns(patterns map[string]*PatternCount) []PatternCount {
+	list := make([]PatternCount, 0, len(patterns))
+	for _, pc := range patterns {
+		list = append(list, *pc)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Pattern < list[j].Pattern
+	})
+	if len(list) > maxPatterns {
+		list = list[:maxPatterns]
+	}
+	return list
+}
+
+// numericStats 数值的分位数和等宽直方图
+func numericStats(values []string) (*NumericStats, int64) {
+	var nums []float64
+	var zeros int64
+	for _, v := range values {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			continue
+		}
+		if f == 0 {
+			zeros++
+		}
+		nums = append(nums, f)
+	}
+	if len(nums) == 0 {
+		return nil, zeros
+	}
+	sort.Float64s(nums)
+
+	var sum float64
+	for _, f := range nums {
+		sum += f
+	}
+	mean := sum / float64(len(nums))
+	var variance float64
+	for _, f := range nums {
+		variance += (f - mean) * (f - mean)
+	}
+	variance /= float64(len(nums))
+
+	stats := &NumericStats{
+		Min:       nums[0],
+		Max:       nums[len(nums)-1],
+		Mean:      mean,
+		StdDev:    math.Sqrt(variance),
+		Quantiles: make(map[string]float64),
+	}
+	for _, q := range []int{5, 25, 50, 75, 95} {
+		stats.Quantiles[fmt.Sprintf("p%d", q)] = quantile(nums, float64(q)/100)
+	}
+	stats.Histogram = histogram(nums, 10)
+	return stats, zeros
+}
+
+// quantile 排序后取最近秩分位数
+func quantile(sorted []float64, q float64) float64 {
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// histogram 在 [min, max] 上划分等宽区间
+func histogram(sorted []float64, buckets int) []Bucket {
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	if lo == hi {
+		return []Bucket{{Label: formatNumber(lo), Count: int64(len(sorted))}}
+	}
+
+	width := (hi - lo) / float64(buckets)
+	counts := make([]int64, buckets)
+	for _, f := range sorted {
+		i := int((f - lo) / width)
+		if i >= buckets {
+			i = buckets - 1
+		}
+		counts[i]++
+	}
+
+	result := make([]Bucket, buckets)
+	for i := range counts {
+		result[i] = Bucket{
+			Label: fmt.Sprintf("[%s, %s)", formatNumber(lo+width*float64(i)), formatNumber(lo+width*float64(i+1))),
+			Count: counts[i],
+		}
+	}
+	result[buckets-1].Label = strings.TrimSuffix(result[buckets-1].Label, ")") + "]"
+	return result
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// dateLayouts 采样取值可能出现的日期格式
+var dateLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	time.RFC3339Nano,
+	"2006-01-02",
+	"2006/01/02",
+	"15:04:05",
+	"2006",
+}
+
+// dateStats 日期范围和按年分布
+func dateStats(values []string) *DateStats {
+	stats := &DateStats{}
+	years := make(map[int]int64)
+	var earliest, latest time.Time
+
+	for _, v := range values {
+		t, ok := parseDate(strings.TrimSpace(v))
+		if !ok {
+			stats.Invalid++
+			continue
+		}
+		if earliest.IsZero() || t.Before(earliest) {
+			earliest = t
+			stats.Earliest = v
+		}
+		if latest.IsZero() || t.After(latest) {
+			latest = t
+			stats.Latest = v
+		}
+		years[t.Year()]++
+	}
+	if len(years) == 0 {
+		return stats
+	}
+
+	var keys []int
+	for y := range years {
+		keys = append(keys, y)
+	}
+	sort.Ints(keys)
+	for _, y := range keys {
+		stats.ByYear = append(stats.ByYear, Bucket{Label: strconv.Itoa(y), Count: years[y]})
+	}
+	return stats
+}
+
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// lengthBounds 长度分布区间上界
+var lengthBounds = []int{0, 5, 10, 20, 50, 100}
+
+// lengthStats 字符串长度（按字符计）分布
+func lengthStats(values []string) *LengthStats {
+	if len(values) == 0 {
+		return nil
+	}
+
+	counts := make([]int64, len(lengthBounds)+1)
+	stats := &LengthStats{Min: -1}
+	var total int
+	for _, v := range values {
+		n := len([]rune(v))
+		total += n
+		if stats.Min < 0 || n < stats.Min {
+			stats.Min = n
+		}
+		if n > stats.Max {
+			stats.Max = n
+		}
+		i := sort.SearchInts(lengthBounds, n)
+		counts[i]++
+	}
+	stats.Mean = float64(total) / float64(len(values))
+
+	for i, c := range counts {
+		var label string
+		switch {
+		case i == 0:
+			label = "0"
+		case i == len(lengthBounds):
+			label = fmt.Sprintf(">%d", lengthBounds[i-1])
+		default:
+			label = fmt.Sprintf("%d-%d", lengthBounds[i-1]+1, lengthBounds[i])
+		}
+		stats.Histogram = append(stats.Histogram, Bucket{Label: label, Count: c})
+	}
+	return stats
+}
+
+func stringRange(values []string) (string, string) {
+	if len(values) == 0 {
+		return "", ""
+	}
+	min, max := values[0], values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return min, max
+}
+
+// AnnotateGraph 把画像摘要写入列节点
+func AnnotateGraph(g *graph.SchemaGraph, report *Report) {
+	for _, tp := range report.Tables {
+		for _, cp := range tp.Columns {
+			node := g.GetNode(fmt.Sprintf("%s.%s", cp.Table, cp.Column))
+			if node == nil {
+				continue
+			}
+			node.Properties["min_value"] = cp.MinValue
+			node.Properties["max_value"] = cp.MaxValue
+			node.Properties["zero_count"] = cp.ZeroCount
+			node.Properties["blank_count"] = cp.BlankCount
+			node.Properties["whitespace_count"] = cp.WhitespaceCount
+			if len(cp.Patterns) > 0 {
+				node.Properties["top_pattern"] = cp.Patterns[0].Pattern
+			}
+		}
+	}
+}
+
+// ToJSON 导出为 JSON
+func (r *Report) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// LoadReport 读取 profile.json
+func LoadReport(filename string) (*Report, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Merge 并入另一份报告中 keep 返回 true 的表（增量扫描时沿用未变化表的画像），已有的表不覆盖
+func (r *Report) Merge(other *Report, keep func(table string) bool) int {
+	existing := make(map[string]bool, len(r.Tables))
+	for _, tp := range r.Tables {
+		existing[tp.Name] = true
+	}
+	merged := 0
+	for _, tp := range other.Tables {
+		if existing[tp.Name] || !keep(tp.Name) {
+			continue
+		}
+		r.Tables = append(r.Tables, tp)
+		merged++
+	}
+	sort.Slice(r.Tables, func(i, j int) bool {
+		return r.Tables[i].Name < r.Tables[j].Name
+	})
+	return merged
+}
@@ -0,0 +1,71 @@
+package profile
+
+import (
+	"database/sql"
+	"schema-analyzer/internal/adapter"
+	"testing"
+)
+
+func nullStrings(values ...string) []sql.NullString {
+	var result []sql.NullString
+	for _, v := range values {
+		if v == "<null>" {
+			result = append(result, sql.NullString{})
+			continue
+		}
+		result = append(result, sql.NullString{String: v, Valid: true})
+	}
+	return result
+}
+
+func TestComputeNumeric(t *testing.T) {
+	col := adapter.Column{Name: "iQuantity", DataType: "int"}
+	values := nullStrings("0", "10", "20", "30", "40", "50", "60", "70", "80", "90", "100", "<null>")
+
+	cp := Compute("SO_SODetails", col, values)
+	if cp.Kind != KindNumeric || cp.NullCount != 1 || cp.ZeroCount != 1 || cp.DistinctCount != 11 {
+		t.Fatalf("unexpected profile %+v", cp)
+	}
+	if cp.MinValue != "0" || cp.MaxValue != "100" {
+		t.Errorf("expected range 0~100, got %s~%s", cp.MinValue, cp.MaxValue)
+	}
+	if p50 := cp.Numeric.Quantiles["p50"]; p50 != 50 {
+		t.Errorf("expected median 50, got %v", p50)
+	}
+	var total int64
+	for _, b := range cp.Numeric.Histogram {
+		total += b.Count
+	}
+	if len(cp.Numeric.Histogram) != 10 || total != 11 {
+		t.Errorf("expected 10 buckets covering 11 values, got %d buckets, %d values", len(cp.Numeric.Histogram), total)
+	}
+}
+
+func TestComputeString(t *testing.T) {
+	col := adapter.Column{Name: "cCusCode", DataType: "nvarchar"}
+	values := nullStrings("C001", "C002", "C003", "", "  ", "客户A")
+
+	cp := Compute("Customer", col, values)
+	if cp.BlankCount != 1 || cp.WhitespaceCount != 1 {
+		t.Errorf("expected 1 blank and 1 whitespace value, got %d and %d", cp.BlankCount, cp.WhitespaceCount)
+	}
+	if len(cp.Patterns) == 0 || cp.Patterns[0].Pattern != "A999" || cp.Patterns[0].Count != 3 {
+		t.Errorf("expected A999 to be the top pattern, got %+v", cp.Patterns)
+	}
+	if cp.Length == nil || cp.Length.Min != 0 || cp.Length.Max != 4 {
+		t.Errorf("unexpected length stats %+v", cp.Length)
+	}
+}
+
+func TestComputeDates(t *testing.T) {
+	col := adapter.Column{Name: "dDate", DataType: "datetime"}
+	values := nullStrings("2023-05-01 00:00:00", "2021-01-15", "2024-12-31T08:00:00Z", "not a date")
+
+	cp := Compute("SO_SOMain", col, values)
+	if cp.Dates == nil || cp.Dates.Invalid != 1 || len(cp.Dates.ByYear) != 3 {
+		t.Fatalf("unexpected date stats %+v", cp.Dates)
+	}
+	if cp.MinValue != "2021-01-15" || cp.MaxValue != "2024-12-31T08:00:00Z" {
+		t.Errorf("unexpected date range %s ~ %s", cp.MinValue, cp.MaxValue)
+	}
+}
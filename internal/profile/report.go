@@ -0,0 +1,99 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown 渲染画像报告
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+
+	sb.WriteString("# 数据画像报告\n\n")
+	sb.WriteString(fmt.Sprintf("- 生成时间: %s\n", r.GeneratedAt.Format("2006-01-02 15:04:05")))
+	sb.WriteString(fmt.Sprintf("- 每列采样: %d 行\n\n", r.SampleSize))
+
+	for _, tp := range r.Tables {
+		sb.WriteString(fmt.Sprintf("## %s\n\n", tp.Name))
+		sb.WriteString(fmt.Sprintf("估算行数: %d\n\n", tp.RowCount))
+
+		sb.WriteString("| 列名 | 类型 | 采样 | Null | 唯一值 | 零值 | 空串 | 空白 | 最小值 | 最大值 | 主要格式 |\n")
+		sb.WriteString("|------|------|------|------|--------|------|------|------|--------|--------|----------|\n")
+		for _, cp := range tp.Columns {
+			pattern := ""
+			if len(cp.Patterns) > 0 {
+				pattern = "`" + cp.Patterns[0].Pattern + "`"
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %d | %d | %d | %d | %d | %d | %s | %s | %s |\n",
+				cp.Column, cp.DataType, cp.SampledRows, cp.NullCount, cp.DistinctCount,
+				cp.ZeroCount, cp.BlankCount, cp.WhitespaceCount,
+				cell(cp.MinValue), cell(cp.MaxValue), pattern))
+		}
+		sb.WriteString("\n")
+
+		for _, cp := range tp.Columns {
+			writeColumnDetails(&sb, cp)
+		}
+	}
+
+	return sb.String()
+}
+
+// writeColumnDetails 输出单列的分布明细
+func writeColumnDetails(sb *strings.Builder, cp *ColumnProfile) {
+	if cp.Numeric == nil && cp.Dates == nil && cp.Length == nil {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("### %s.%s\n\n", cp.Table, cp.Column))
+
+	if n := cp.Numeric; n != nil {
+		sb.WriteString(fmt.Sprintf("- 均值 %.4g，标准差 %.4g\n", n.Mean, n.StdDev))
+		sb.WriteString(fmt.Sprintf("- 分位数: P5=%s P25=%s P50=%s P75=%s P95=%s\n",
+			formatNumber(n.Quantiles["p5"]), formatNumber(n.Quantiles["p25"]), formatNumber(n.Quantiles["p50"]),
+			formatNumber(n.Quantiles["p75"]), formatNumber(n.Quantiles["p95"])))
+		writeBuckets(sb, "区间", n.Histogram)
+	}
+
+	if d := cp.Dates; d != nil {
+		sb.WriteString(fmt.Sprintf("- 日期范围: %s ~ %s\n", d.Earliest, d.Latest))
+		if d.Invalid > 0 {
+			sb.WriteString(fmt.Sprintf("- 无法解析的取值: %d\n", d.Invalid))
+		}
+		writeBuckets(sb, "年份", d.ByYear)
+	}
+
+	if l := cp.Length; l != nil {
+		sb.WriteString(fmt.Sprintf("- 长度: 最短 %d，最长 %d，平均 %.1f\n", l.Min, l.Max, l.Mean))
+		writeBuckets(sb, "长度", l.Histogram)
+	}
+
+	if len(cp.Patterns) > 1 {
+		sb.WriteString("| 格式 | 次数 | 示例 |\n")
+		sb.WriteString("|------|------|------|\n")
+		for _, pc := range cp.Patterns {
+			sb.WriteString(fmt.Sprintf("| `%s` | %d | %s |\n", pc.Pattern, pc.Count, cell(pc.Example)))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+func writeBuckets(sb *strings.Builder, label string, buckets []Bucket) {
+	if len(buckets) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n| %s | 次数 |\n", label))
+	sb.WriteString("|------|------|\n")
+	for _, b := range buckets {
+		sb.WriteString(fmt.Sprintf("| %s | %d |\n", b.Label, b.Count))
+	}
+	sb.WriteString("\n")
+}
+
+// cell 转义表格单元格并截断过长的取值
+func cell(s string) string {
+	if r := []rune(s); len(r) > 40 {
+		s = string(r[:40]) + "…"
+	}
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -19,8 +19,6 @@ func (m *MarkdownRenderer) Render(g *graph.SchemaGraph) string {
 	var sb strings.Builder
 	
 	sb.WriteString("# 数据库结构文档\n\n")
-	sb.WriteString("## 表结构\n\n")
-	
 	// 按表组织列信息
 	tables := make(map[string][]*graph.Node)
 	for _, node := range g.Nodes {
@@ -30,8 +28,11 @@ func (m *MarkdownRenderer) Render(g *graph.SchemaGraph) string {
 		}
 	}
 	
-	// 输出每个表
-	for tableName, columns := range tables {
+	// 按 schema 分组输出每个表
+	ordered := orderTables(g, tableNames(tables))
+	for i, ref := range ordered {
+		tableName, columns := ref.Name, tables[ref.Name]
+		writeSchemaHeading(&sb, ordered, i)
 		sb.WriteString(fmt.Sprintf("### %s\n\n", tableName))
 		
 		// 表头
@@ -66,6 +67,9 @@ func (m *MarkdownRenderer) Render(g *graph.SchemaGraph) string {
 		
 		sb.WriteString("\n")
 		
+		// 码表取值和枚举列取值
+		writeEnumValues(&sb, g, tableName, columns)
+		
 		// 输出该表的关系
 		m.renderTableRelations(&sb, g, tableName)
 	}
@@ -73,6 +77,60 @@ func (m *MarkdownRenderer) Render(g *graph.SchemaGraph) string {
 	return sb.String()
 }
 
+// writeEnumValues 输出码表的 键→含义 对照表和内联枚举列的取值分布
+func writeEnumValues(sb *strings.Builder, g *graph.SchemaGraph, tableName string, columns []*graph.Node) {
+	if table := g.GetNode(tableName); table != nil && len(table.EnumValues) > 0 {
+		keyCol, _ := table.Properties["enum_key_column"].(string)
+		valueCol, _ := table.Properties["enum_value_column"].(string)
+		sb.WriteString(fmt.Sprintf("#### 码表取值（%d 项）\n\n", len(table.EnumValues)))
+		if refs, ok := table.Properties["referenced_by"].([]string); ok && len(refs) > 0 {
+			sb.WriteString(fmt.Sprintf("被引用: `%s`\n\n", strings.Join(refs, "`, `")))
+		}
+		if valueCol == "" {
+			sb.WriteString(fmt.Sprintf("| %s |\n", keyCol))
+			sb.WriteString("|------|\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("| %s | %s |\n", keyCol, valueCol))
+			sb.WriteString("|------|------|\n")
+		}
+		for _, v := range table.EnumValues {
+			if valueCol == "" {
+				sb.WriteString(fmt.Sprintf("| %s |\n", escapeCell(v.Key)))
+			} else {
+				sb.WriteString(fmt.Sprintf("| %s | %s |\n", escapeCell(v.Key), escapeCell(v.Label)))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	for _, col := range columns {
+		if len(col.EnumValues) == 0 {
+			continue
+		}
+		var total int64
+		for _, v := range col.EnumValues {
+			total += v.Count
+		}
+		sb.WriteString(fmt.Sprintf("#### 枚举字段 %s\n\n", col.Name))
+		sb.WriteString("| 取值 | 采样次数 | 占比 |\n")
+		sb.WriteString("|------|----------|------|\n")
+		for _, v := range col.EnumValues {
+			ratio := 0.0
+			if total > 0 {
+				ratio = float64(v.Count) / float64(total)
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %d | %.1f%% |\n", escapeCell(v.Key), v.Count, ratio*100))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// escapeCell 转义 Markdown 表格单元格中的竖线和换行
+func escapeCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
 // renderTableRelations 渲染表关系
 func (m *MarkdownRenderer) renderTableRelations(sb *strings.Builder, g *graph.SchemaGraph, tableName string) {
 	var relations []*graph.Edge
@@ -97,10 +155,7 @@ func (m *MarkdownRenderer) renderTableRelations(sb *strings.Builder, g *graph.Sc
 		toTable := props["to_table"].(string)
 		toCol := props["to_column"].(string)
 		
-		relType := "外键"
-		if rel.Type == graph.EdgeTypeInferredFK {
-			relType = "推断外键"
-		}
+		relType := relationTypeLabel(rel)
 		
 		sb.WriteString(fmt.Sprintf("- **%s** `%s.%s` → `%s.%s` (置信度: %.2f)\n",
 			relType, fromTable, fromCol, toTable, toCol, rel.Confidence))
@@ -113,7 +168,66 @@ func (m *MarkdownRenderer) renderTableRelations(sb *strings.Builder, g *graph.Sc
 					ev.Description, ev.Score, ev.Details))
 			}
 		}
+		writeCrossSchema(sb, rel)
+		writeEnumSample(sb, g, rel)
+		writeAlternatives(sb, rel)
 	}
 	
 	sb.WriteString("\n")
 }
+
+// writeEnumSample 码表引用关系附带几个码表取值示例
+func writeEnumSample(sb *strings.Builder, g *graph.SchemaGraph, edge *graph.Edge) {
+	if edge.Type != graph.EdgeTypeEnum {
+		return
+	}
+	toTable, _ := edge.Properties["to_table"].(string)
+	table := g.GetNode(toTable)
+	if table == nil || len(table.EnumValues) == 0 {
+		return
+	}
+	var samples []string
+	for i, v := range table.EnumValues {
+		if i == 5 {
+			samples = append(samples, "...")
+			break
+		}
+		if v.Label != "" {
+			samples = append(samples, fmt.Sprintf("%s=%s", v.Key, v.Label))
+		} else {
+			samples = append(samples, v.Key)
+		}
+	}
+	sb.WriteString(fmt.Sprintf("  - 取值: %s\n", strings.Join(samples, ", ")))
+}
+
+// writeAlternatives 输出同一字段落选的备选目标
+func writeAlternatives(sb *strings.Builder, edge *graph.Edge) {
+	if ambiguous, _ := edge.Properties["ambiguous"].(bool); ambiguous {
+		sb.WriteString("  - ⚠️ 与备选目标差距较小，建议人工审核\n")
+	}
+	if len(edge.Alternatives) == 0 {
+		return
+	}
+	sb.WriteString("  - 备选:\n")
+	for _, alt := range edge.Alternatives {
+		sb.WriteString(fmt.Sprintf("    - `%s` (置信度: %.2f)\n", alt.To, alt.Confidence))
+	}
+}
+
+// relationTypeLabel 关系类型标签（区分人工审核过的关系）
+func relationTypeLabel(edge *graph.Edge) string {
+	switch edge.Properties["review"] {
+	case "accepted":
+		return "已确认外键"
+	case "manual":
+		return "人工外键"
+	}
+	if edge.Type == graph.EdgeTypeInferredFK {
+		return "推断外键"
+	}
+	if edge.Type == graph.EdgeTypeEnum {
+		return "码表引用"
+	}
+	return "外键"
+}
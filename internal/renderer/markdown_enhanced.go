@@ -19,8 +19,6 @@ func (m *EnhancedMarkdownRenderer) Render(g *graph.SchemaGraph) string {
 	var sb strings.Builder
 	
 	sb.WriteString("# 数据库结构文档（AI 增强版）\n\n")
-	sb.WriteString("## 表结构\n\n")
-	
 	// 按表组织列信息
 	tables := make(map[string][]*graph.Node)
 	for _, node := range g.Nodes {
@@ -30,8 +28,11 @@ func (m *EnhancedMarkdownRenderer) Render(g *graph.SchemaGraph) string {
 		}
 	}
 	
-	// 输出每个表
-	for tableName, columns := range tables {
+	// 按 schema 分组输出每个表
+	ordered := orderTables(g, tableNames(tables))
+	for i, ref := range ordered {
+		tableName, columns := ref.Name, tables[ref.Name]
+		writeSchemaHeading(&sb, ordered, i)
 		sb.WriteString(fmt.Sprintf("### %s\n\n", tableName))
 		
 		// 检查是否有 AI 解释
@@ -121,6 +122,9 @@ func (m *EnhancedMarkdownRenderer) Render(g *graph.SchemaGraph) string {
 		
 		sb.WriteString("\n")
 		
+		// 码表取值和枚举列取值
+		writeEnumValues(&sb, g, tableName, columns)
+		
 		// 输出该表的关系
 		m.renderTableRelations(&sb, g, tableName)
 	}
@@ -175,10 +179,7 @@ func (m *EnhancedMarkdownRenderer) renderTableRelations(sb *strings.Builder, g *
 			toTable := props["to_table"].(string)
 			toCol := props["to_column"].(string)
 			
-			relType := "外键"
-			if rel.Type == graph.EdgeTypeInferredFK {
-				relType = "推断外键"
-			}
+			relType := relationTypeLabel(rel)
 			
 			sb.WriteString(fmt.Sprintf("- **%s** `%s.%s` → `%s.%s` (置信度: %.2f)\n",
 				relType, fromTable, fromCol, toTable, toCol, rel.Confidence))
@@ -191,6 +192,9 @@ func (m *EnhancedMarkdownRenderer) renderTableRelations(sb *strings.Builder, g *
 						ev.Description, ev.Score, ev.Details))
 				}
 			}
+			writeCrossSchema(sb, rel)
+			writeEnumSample(sb, g, rel)
+			writeAlternatives(sb, rel)
 		}
 	}
 	
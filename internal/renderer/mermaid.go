@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"schema-analyzer/internal/graph"
 	"strings"
+	"unicode"
 )
 
 // MermaidRenderer Mermaid ER 图渲染器
@@ -42,9 +43,40 @@ func (m *MermaidRenderer) Render(g *graph.SchemaGraph) string {
 		}
 	}
 	
-	// 输出表定义
-	for tableName, columns := range tables {
-		sb.WriteString(fmt.Sprintf("    %s {\n", tableName))
+	// 输出表定义。erDiagram 不支持子图/命名空间，多 schema 时用注释分组，
+	// 实体名带 schema 前缀以区分不同 schema 的同名表
+	names := make([]string, 0, len(tables))
+	for tableName := range tables {
+		names = append(names, tableName)
+	}
+	ordered := orderTables(g, names)
+	multiSchema := schemaCount(ordered) > 1
+	// 替换字符后可能重名（如 a.b_c 和 a_b.c），重名时按顺序加 _2、_3 后缀
+	entities := make(map[string]string, len(ordered))
+	used := make(map[string]bool, len(ordered))
+	entity := func(tableName string) string {
+		if name, ok := entities[tableName]; ok {
+			return name
+		}
+		base := m.entityName(g, tableName, multiSchema)
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[name] = true
+		entities[tableName] = name
+		return name
+	}
+	for _, ref := range ordered {
+		entity(ref.Name)
+	}
+	
+	for i, ref := range ordered {
+		if multiSchema && (i == 0 || ref.Schema != ordered[i-1].Schema) {
+			sb.WriteString(fmt.Sprintf("    %%%% schema: %s\n", ref.Schema))
+		}
+		columns := tables[ref.Name]
+		sb.WriteString(fmt.Sprintf("    %s {\n", entities[ref.Name]))
 		for _, col := range columns {
 			sb.WriteString(col + "\n")
 		}
@@ -62,15 +94,37 @@ func (m *MermaidRenderer) Render(g *graph.SchemaGraph) string {
 			
 			// 关系类型
 			relType := "||--o{"
-			if edge.Type == graph.EdgeTypeInferredFK {
-				relType = "||..o{" // 虚线表示推断关系
+			if edge.Type == graph.EdgeTypeInferredFK && edge.Properties["review"] == nil {
+				relType = "||..o{" // 虚线表示推断关系（人工审核过的用实线）
 			}
 			
 			label := fmt.Sprintf("\"%.2f\"", edge.Confidence)
 			sb.WriteString(fmt.Sprintf("    %s %s %s : %s\n", 
-				toTable, relType, fromTable, label))
+				entity(toTable), relType, entity(fromTable), label))
+		}
+		
+		// 码表引用用虚线，标注引用列
+		if edge.Type == graph.EdgeTypeEnum {
+			props := edge.Properties
+			sb.WriteString(fmt.Sprintf("    %s ||..o{ %s : \"%s\"\n",
+				entity(props["to_table"].(string)), entity(props["from_table"].(string)), props["from_column"].(string)))
 		}
 	}
 	
 	return sb.String()
 }
+
+// entityName Mermaid 实体名：单 schema 时使用表名本身，多 schema 时为 schema_表名；
+// 实体名只能包含字母、数字、下划线和连字符，其余字符替换为下划线
+func (m *MermaidRenderer) entityName(g *graph.SchemaGraph, tableName string, multiSchema bool) string {
+	name := tableName
+	if node := g.GetNode(tableName); node != nil && !multiSchema {
+		name = node.Name
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+}
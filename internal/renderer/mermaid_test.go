@@ -0,0 +1,36 @@
+package renderer
+
+import (
+	"schema-analyzer/internal/graph"
+	"strings"
+	"testing"
+)
+
+func TestMermaidEntityNames(t *testing.T) {
+	single := graph.NewSchemaGraph()
+	orders := addTable(single, "erp", "Orders", "ID", "cCusCode")
+	customer := addTable(single, "erp", "Customer", "cCusCode")
+	addRelation(single, orders, "cCusCode", customer, "cCusCode", nil)
+	out := NewMermaidRenderer().Render(single)
+	if !strings.Contains(out, "    Orders {") || !strings.Contains(out, "Customer ||..o{ Orders") || strings.Contains(out, "%% schema") {
+		t.Errorf("expected bare table names for a single schema:\n%s", out)
+	}
+
+	multi := graph.NewSchemaGraph()
+	addTable(multi, "erp", "Orders", "ID")
+	addTable(multi, "crm", "Orders", "ID")
+	out = NewMermaidRenderer().Render(multi)
+	if !strings.Contains(out, "    erp_Orders {") || !strings.Contains(out, "    crm_Orders {") || !strings.Contains(out, "%% schema: crm") {
+		t.Errorf("expected schema-prefixed entities:\n%s", out)
+	}
+
+	// a.b_c 和 a_b.c 替换字符后同名
+	collide := graph.NewSchemaGraph()
+	first := addTable(collide, "a", "b_c", "ID")
+	second := addTable(collide, "a_b", "c", "ID", "ref")
+	addRelation(collide, second, "ref", first, "ID", nil)
+	out = NewMermaidRenderer().Render(collide)
+	if !strings.Contains(out, "    a_b_c {") || !strings.Contains(out, "    a_b_c_2 {") || !strings.Contains(out, "a_b_c ||..o{ a_b_c_2") {
+		t.Errorf("expected colliding entity names to be disambiguated:\n%s", out)
+	}
+}
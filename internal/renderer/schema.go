@@ -0,0 +1,86 @@
+package renderer
+
+import (
+	"fmt"
+	"schema-analyzer/internal/graph"
+	"sort"
+	"strings"
+)
+
+// tableRef 渲染顺序中的一张表
+type tableRef struct {
+	Schema string
+	Name   string // 带 schema 前缀的表名（即表节点 ID）
+}
+
+// orderTables 按 schema、表名排序，保证输出稳定且同一 schema 的表相邻
+func orderTables(g *graph.SchemaGraph, names []string) []tableRef {
+	refs := make([]tableRef, 0, len(names))
+	for _, name := range names {
+		refs = append(refs, tableRef{Schema: tableSchema(g, name), Name: name})
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Schema != refs[j].Schema {
+			return refs[i].Schema < refs[j].Schema
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	return refs
+}
+
+// tableNames 按表组织的列节点中的表名
+func tableNames(tables map[string][]*graph.Node) []string {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	return names
+}
+
+// tableSchema 表所属的 schema：优先取表节点的 schema 属性，否则从限定名中拆出
+func tableSchema(g *graph.SchemaGraph, tableName string) string {
+	if node := g.GetNode(tableName); node != nil {
+		if schema, ok := node.Properties["schema"].(string); ok {
+			return schema
+		}
+	}
+	if i := strings.Index(tableName, "."); i > 0 {
+		return tableName[:i]
+	}
+	return ""
+}
+
+// schemaCount 不同 schema 的数量
+func schemaCount(refs []tableRef) int {
+	count := 0
+	for i, ref := range refs {
+		if i == 0 || ref.Schema != refs[i-1].Schema {
+			count++
+		}
+	}
+	return count
+}
+
+// writeSchemaHeading 多 schema 时在每个 schema 的第一张表前输出分组标题，单 schema 时输出统一的表结构标题
+func writeSchemaHeading(sb *strings.Builder, refs []tableRef, i int) {
+	if schemaCount(refs) <= 1 {
+		if i == 0 {
+			sb.WriteString("## 表结构\n\n")
+		}
+		return
+	}
+	if i == 0 || refs[i].Schema != refs[i-1].Schema {
+		schema := refs[i].Schema
+		if schema == "" {
+			schema = "（默认）"
+		}
+		sb.WriteString(fmt.Sprintf("## Schema: %s\n\n", schema))
+	}
+}
+
+// writeCrossSchema 标注跨 schema 的关系
+func writeCrossSchema(sb *strings.Builder, edge *graph.Edge) {
+	if cross, _ := edge.Properties["cross_schema"].(bool); cross {
+		sb.WriteString("  - 🔀 跨 schema 关系\n")
+	}
+}
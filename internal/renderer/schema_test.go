@@ -0,0 +1,75 @@
+package renderer
+
+import (
+	"schema-analyzer/internal/graph"
+	"strings"
+	"testing"
+)
+
+// addTable 向图中加入表节点和列节点，第一列为主键
+func addTable(g *graph.SchemaGraph, schema, name string, columns ...string) string {
+	id := name
+	if schema != "" {
+		id = schema + "." + name
+	}
+	g.AddNode(&graph.Node{ID: id, Type: graph.NodeTypeTable, Name: name, Properties: map[string]interface{}{"schema": schema}})
+	for i, col := range columns {
+		g.AddNode(&graph.Node{
+			ID:   id + "." + col,
+			Type: graph.NodeTypeColumn,
+			Name: col,
+			Properties: map[string]interface{}{
+				"table":          id,
+				"data_type":      "varchar",
+				"length":         20,
+				"nullable":       i > 0,
+				"is_primary_key": i == 0,
+				"null_ratio":     0.0,
+				"distinct_rate":  1.0,
+			},
+		})
+	}
+	return id
+}
+
+// addRelation 加入一条列级推断关系
+func addRelation(g *graph.SchemaGraph, fromTable, fromCol, toTable, toCol string, properties map[string]interface{}) {
+	props := map[string]interface{}{"from_table": fromTable, "from_column": fromCol, "to_table": toTable, "to_column": toCol}
+	for k, v := range properties {
+		props[k] = v
+	}
+	g.AddEdge(&graph.Edge{
+		ID:         fromTable + "." + fromCol + "->" + toTable + "." + toCol,
+		Type:       graph.EdgeTypeInferredFK,
+		From:       fromTable + "." + fromCol,
+		To:         toTable + "." + toCol,
+		Confidence: 0.9,
+		Properties: props,
+	})
+}
+
+func TestSchemaHeadings(t *testing.T) {
+	single := graph.NewSchemaGraph()
+	addTable(single, "erp", "Orders", "ID", "cCusCode")
+	addTable(single, "erp", "Customer", "cCusCode")
+	out := NewMarkdownRenderer().Render(single)
+	if strings.Count(out, "## 表结构") != 1 || strings.Contains(out, "## Schema:") {
+		t.Errorf("expected one plain heading for a single schema:\n%s", out)
+	}
+
+	multi := graph.NewSchemaGraph()
+	orders := addTable(multi, "erp", "Orders", "ID", "cCusCode")
+	customer := addTable(multi, "crm", "Customer", "cCusCode")
+	addTable(multi, "crm", "Contact", "ID")
+	addRelation(multi, orders, "cCusCode", customer, "cCusCode", map[string]interface{}{"cross_schema": true})
+	out = NewMarkdownRenderer().Render(multi)
+	if strings.Contains(out, "## 表结构") || strings.Count(out, "## Schema: crm") != 1 || strings.Count(out, "## Schema: erp") != 1 {
+		t.Errorf("expected one heading per schema:\n%s", out)
+	}
+	if strings.Index(out, "## Schema: crm") > strings.Index(out, "## Schema: erp") {
+		t.Errorf("expected schemas in name order:\n%s", out)
+	}
+	if !strings.Contains(out, "🔀 跨 schema 关系") {
+		t.Errorf("expected cross-schema relation to be marked:\n%s", out)
+	}
+}
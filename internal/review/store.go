@@ -0,0 +1,315 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"schema-analyzer/internal/graph"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Verdict 人工审核结论
+type Verdict string
+
+const (
+	VerdictAccepted Verdict = "accepted" // 确认推断关系
+	VerdictRejected Verdict = "rejected" // 否决推断关系
+	VerdictManual   Verdict = "manual"   // 人工补充的关系
+)
+
+// DefaultFileName 审核记录默认文件名（位于输出目录）
+const DefaultFileName = "review.json"
+
+// Decision 审核记录
+type Decision struct {
+	EdgeID     string    `json:"edge_id"`
+	Verdict    Verdict   `json:"verdict"`
+	FromTable  string    `json:"from_table"`
+	FromColumn string    `json:"from_column"`
+	ToTable    string    `json:"to_table"`
+	ToColumn   string    `json:"to_column"`
+	Note       string    `json:"note,omitempty"`
+	DecidedAt  time.Time `json:"decided_at"`
+}
+
+// Store 审核记录存储
+type Store struct {
+	mu        sync.Mutex
+	path      string
+	Decisions map[string]*Decision `json:"decisions"`
+	Seen      map[string]time.Time `json:"seen"` // 历次扫描中出现过的候选关系及首次出现时间
+}
+
+// ApplyResult 应用审核结论的结果
+type ApplyResult struct {
+	Accepted      int
+	Rejected      int
+	Manual        int
+	NewCandidates []*graph.Edge // 本次扫描首次出现且未审核的候选关系
+	Pending       int           // 未审核的候选关系总数
+}
+
+// Load 加载审核记录，文件不存在时返回空存储
+func Load(path string) (*Store, error) {
+	s := &Store{
+		path:      path,
+		Decisions: make(map[string]*Decision),
+		Seen:      make(map[string]time.Time),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("解析审核记录失败: %v", err)
+	}
+	if s.Decisions == nil {
+		s.Decisions = make(map[string]*Decision)
+	}
+	if s.Seen == nil {
+		s.Seen = make(map[string]time.Time)
+	}
+	return s, nil
+}
+
+// Save 保存审核记录
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// Path 审核记录文件路径
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Accept 确认推断关系
+func (s *Store) Accept(edgeID, note string) error {
+	_, err := s.decide(edgeID, VerdictAccepted, note)
+	return err
+}
+
+// Reject 否决推断关系，后续扫描不再输出
+func (s *Store) Reject(edgeID, note string) error {
+	_, err := s.decide(edgeID, VerdictRejected, note)
+	return err
+}
+
+// Add 人工补充关系，from/to 形如 表.列
+func (s *Store) Add(from, to, note string) (string, error) {
+	return s.decide(from+"->"+to, VerdictManual, note)
+}
+
+// Remove 撤销审核结论
+func (s *Store) Remove(edgeID string) bool {
+	edgeID = normalizeEdgeID(edgeID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.Decisions[edgeID]; !ok {
+		return false
+	}
+	delete(s.Decisions, edgeID)
+	return true
+}
+
+// Get 获取某条关系的审核结论
+func (s *Store) Get(edgeID string) *Decision {
+	edgeID = normalizeEdgeID(edgeID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Decisions[edgeID]
+}
+
+// List 按关系 ID 排序列出所有审核记录
+func (s *Store) List() []*Decision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]*Decision, 0, len(s.Decisions))
+	for _, d := range s.Decisions {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].EdgeID < list[j].EdgeID
+	})
+	return list
+}
+
+// decide 记录审核结论，返回规范化后的关系 ID（去掉首尾和箭头两侧的空白），与图中边的 ID 一致
+func (s *Store) decide(edgeID string, verdict Verdict, note string) (string, error) {
+	fromTable, fromCol, toTable, toCol, err := ParseEdgeID(edgeID)
+	if err != nil {
+		return "", err
+	}
+	edgeID = fromTable + "." + fromCol + "->" + toTable + "." + toCol
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Decisions[edgeID] = &Decision{
+		EdgeID:     edgeID,
+		Verdict:    verdict,
+		FromTable:  fromTable,
+		FromColumn: fromCol,
+		ToTable:    toTable,
+		ToColumn:   toCol,
+		Note:       note,
+		DecidedAt:  time.Now(),
+	}
+	return edgeID, nil
+}
+
+// normalizeEdgeID 规范化关系 ID，无法解析时只去掉首尾空白
+func normalizeEdgeID(edgeID string) string {
+	fromTable, fromCol, toTable, toCol, err := ParseEdgeID(edgeID)
+	if err != nil {
+		return strings.TrimSpace(edgeID)
+	}
+	return fromTable + "." + fromCol + "->" + toTable + "." + toCol
+}
+
+// Apply 把审核结论应用到图上：
+// 否决的关系被移除，确认的关系标记为人工证据，人工补充的关系被加入，
+// 并返回本次扫描首次出现的未审核候选关系
+func (s *Store) Apply(g *graph.SchemaGraph) *ApplyResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &ApplyResult{}
+	now := time.Now()
+
+	// 候选关系（只处理列级关系）
+	var candidates []*graph.Edge
+	for _, edge := range g.Edges {
+		if edge.Type != graph.EdgeTypeInferredFK {
+			continue
+		}
+		if _, ok := edge.Properties["from_column"]; !ok {
+			continue
+		}
+		candidates = append(candidates, edge)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ID < candidates[j].ID
+	})
+
+	for _, edge := range candidates {
+		decision, reviewed := s.Decisions[edge.ID]
+		if !reviewed {
+			result.Pending++
+			if _, seen := s.Seen[edge.ID]; !seen {
+				s.Seen[edge.ID] = now
+				result.NewCandidates = append(result.NewCandidates, edge)
+			}
+			continue
+		}
+
+		if decision.Verdict == VerdictRejected {
+			g.RemoveEdge(edge.ID)
+			result.Rejected++
+		}
+	}
+
+	// 确认和人工补充的关系：即使本次没有推断出来也要保留
+	for _, decision := range s.Decisions {
+		if decision.Verdict == VerdictRejected {
+			continue
+		}
+
+		edge := g.GetEdge(decision.EdgeID)
+		if edge == nil {
+			edge = newManualEdge(decision)
+			g.AddEdge(edge)
+		}
+		markReviewed(edge, decision)
+
+		if decision.Verdict == VerdictAccepted {
+			result.Accepted++
+		} else {
+			result.Manual++
+		}
+	}
+
+	return result
+}
+
+// newManualEdge 根据审核记录创建边
+func newManualEdge(d *Decision) *graph.Edge {
+	return &graph.Edge{
+		ID:   d.EdgeID,
+		Type: graph.EdgeTypeInferredFK,
+		From: fmt.Sprintf("%s.%s", d.FromTable, d.FromColumn),
+		To:   fmt.Sprintf("%s.%s", d.ToTable, d.ToColumn),
+		Properties: map[string]interface{}{
+			"from_table":  d.FromTable,
+			"from_column": d.FromColumn,
+			"to_table":    d.ToTable,
+			"to_column":   d.ToColumn,
+		},
+	}
+}
+
+// markReviewed 标记人工证据。已有的人工证据（如增量扫描从基线沿用的关系）被替换，重复应用不会累积
+func markReviewed(edge *graph.Edge, d *Decision) {
+	description := "人工确认"
+	if d.Verdict == VerdictManual {
+		description = "人工添加"
+	}
+	details := d.DecidedAt.Format("2006-01-02 15:04")
+	if d.Note != "" {
+		details += " " + d.Note
+	}
+
+	var evidence []graph.Evidence
+	for _, e := range edge.Evidence {
+		if e.Type != "manual" {
+			evidence = append(evidence, e)
+		}
+	}
+	edge.Confidence = 1.0
+	edge.Evidence = append(evidence, graph.Evidence{
+		Type:        "manual",
+		Score:       1.0,
+		Description: description,
+		Details:     details,
+	})
+	edge.Properties["review"] = string(d.Verdict)
+}
+
+// ParseEdgeID 解析关系 ID（表.列->表.列），表名可以带 schema 前缀
+func ParseEdgeID(edgeID string) (fromTable, fromCol, toTable, toCol string, err error) {
+	parts := strings.Split(edgeID, "->")
+	if len(parts) != 2 {
+		return "", "", "", "", fmt.Errorf("无效的关系 ID: %s（格式：表.列->表.列）", edgeID)
+	}
+
+	split := func(s string) (string, string, bool) {
+		i := strings.LastIndex(s, ".")
+		if i <= 0 || i == len(s)-1 {
+			return "", "", false
+		}
+		return s[:i], s[i+1:], true
+	}
+
+	var ok bool
+	if fromTable, fromCol, ok = split(strings.TrimSpace(parts[0])); !ok {
+		return "", "", "", "", fmt.Errorf("无效的关系起点: %s", parts[0])
+	}
+	if toTable, toCol, ok = split(strings.TrimSpace(parts[1])); !ok {
+		return "", "", "", "", fmt.Errorf("无效的关系终点: %s", parts[1])
+	}
+	return fromTable, fromCol, toTable, toCol, nil
+}
@@ -0,0 +1,119 @@
+package review
+
+import (
+	"path/filepath"
+	"schema-analyzer/internal/graph"
+	"testing"
+)
+
+func newEdge(id string, confidence float64) *graph.Edge {
+	fromTable, fromCol, toTable, toCol, _ := ParseEdgeID(id)
+	return &graph.Edge{
+		ID:         id,
+		Type:       graph.EdgeTypeInferredFK,
+		Confidence: confidence,
+		Properties: map[string]interface{}{
+			"from_table":  fromTable,
+			"from_column": fromCol,
+			"to_table":    toTable,
+			"to_column":   toCol,
+		},
+	}
+}
+
+func TestApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFileName)
+	store, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.Accept("SO_SOMain.cCusCode->Customer.cCusCode", "")
+	store.Reject("SO_SOMain.cDepCode->Customer.cCusCode", "编码格式碰巧相同")
+	store.Add("SO_SODetails.iSOsID", "SO_SOMain.ID", "")
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载，模拟下一次扫描
+	store, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := graph.NewSchemaGraph()
+	g.AddEdge(newEdge("SO_SOMain.cCusCode->Customer.cCusCode", 0.8))
+	g.AddEdge(newEdge("SO_SOMain.cDepCode->Customer.cCusCode", 0.4))
+	g.AddEdge(newEdge("SO_SOMain.cDepCode->Department.cDepCode", 0.9))
+
+	result := store.Apply(g)
+	if result.Accepted != 1 || result.Rejected != 1 || result.Manual != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if g.GetEdge("SO_SOMain.cDepCode->Customer.cCusCode") != nil {
+		t.Errorf("rejected edge should be removed")
+	}
+	accepted := g.GetEdge("SO_SOMain.cCusCode->Customer.cCusCode")
+	if accepted.Confidence != 1.0 || accepted.Properties["review"] != "accepted" {
+		t.Errorf("accepted edge should carry manual evidence: %+v", accepted)
+	}
+	if g.GetEdge("SO_SODetails.iSOsID->SO_SOMain.ID") == nil {
+		t.Errorf("manual edge should be added")
+	}
+
+	// 再次应用（如增量扫描沿用了基线中的关系）不会累积人工证据
+	evidence := len(accepted.Evidence)
+	store.Apply(g)
+	if len(accepted.Evidence) != evidence {
+		t.Errorf("expected manual evidence to be replaced, got %d entries after %d", len(accepted.Evidence), evidence)
+	}
+	if len(result.NewCandidates) != 1 {
+		t.Errorf("expected 1 new candidate, got %d", len(result.NewCandidates))
+	}
+
+	// 同一候选关系第二次出现时不再报告
+	g2 := graph.NewSchemaGraph()
+	g2.AddEdge(newEdge("SO_SOMain.cDepCode->Department.cDepCode", 0.9))
+	if result := store.Apply(g2); len(result.NewCandidates) != 0 || result.Pending != 1 {
+		t.Errorf("expected no new candidates and 1 pending, got %+v", result)
+	}
+}
+
+func TestParseEdgeID(t *testing.T) {
+	fromTable, fromCol, toTable, toCol, err := ParseEdgeID("dbo.Orders.CustomerID->dbo.Customers.ID")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromTable != "dbo.Orders" || fromCol != "CustomerID" || toTable != "dbo.Customers" || toCol != "ID" {
+		t.Errorf("unexpected parse result: %s %s %s %s", fromTable, fromCol, toTable, toCol)
+	}
+
+	if _, _, _, _, err := ParseEdgeID("Orders.CustomerID"); err == nil {
+		t.Errorf("expected error for missing target")
+	}
+}
+
+func TestDecideNormalizesEdgeID(t *testing.T) {
+	store, err := Load(filepath.Join(t.TempDir(), DefaultFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const id = "SO_SOMain.cCusCode->Customer.cCusCode"
+	store.Accept("  SO_SOMain.cCusCode -> Customer.cCusCode \n", "")
+	store.Accept(id, "")
+	if len(store.List()) != 1 || store.Get(id) == nil || store.Get(id).EdgeID != id {
+		t.Fatalf("expected one decision keyed by the normalized ID, got %+v", store.List())
+	}
+	if added, _ := store.Add(" Person.cDepCode", "Department.cDepCode ", ""); added != "Person.cDepCode->Department.cDepCode" {
+		t.Errorf("expected normalized ID from Add, got %q", added)
+	}
+
+	g := graph.NewSchemaGraph()
+	g.AddEdge(newEdge(id, 0.8))
+	if result := store.Apply(g); result.Accepted != 1 || g.GetEdge(id).Properties["review"] != "accepted" {
+		t.Errorf("expected the decision to match the graph edge, got %+v", result)
+	}
+	if !store.Remove(" " + id) {
+		t.Error("expected Remove to accept a padded ID")
+	}
+}
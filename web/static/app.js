@@ -29,6 +29,23 @@ document.getElementById('enableAI').addEventListener('change', function() {
     apiKeyGroup.style.display = this.checked ? 'block' : 'none';
 });
 
+// AI 服务商切换
+const aiProviderDefaults = {
+    dashscope: { url: '', model: 'qwen-plus' },
+    openai: { url: 'https://api.openai.com/v1', model: 'gpt-4o-mini' },
+    ollama: { url: 'http://localhost:11434', model: 'qwen2.5:7b' },
+    llamacpp: { url: 'http://localhost:8080', model: '服务启动时加载的模型' }
+};
+document.getElementById('aiProvider').addEventListener('change', function() {
+    const defaults = aiProviderDefaults[this.value];
+    const isLocal = this.value === 'ollama' || this.value === 'llamacpp';
+    document.getElementById('aiBaseURLGroup').style.display = this.value === 'dashscope' ? 'none' : 'block';
+    document.getElementById('aiBaseURL').placeholder = defaults.url;
+    document.getElementById('aiModel').placeholder = '留空使用默认模型（' + defaults.model + '）';
+    document.getElementById('aiContextGroup').style.display = isLocal ? 'block' : 'none';
+    document.getElementById('aiKeyGroup').style.display = isLocal ? 'none' : 'block';
+});
+
 // 表单提交
 document.getElementById('analysisForm').addEventListener('submit', async function(e) {
     e.preventDefault();
@@ -43,7 +60,12 @@ document.getElementById('analysisForm').addEventListener('submit', async functio
         schema: document.getElementById('schema').value,
         sample_size: parseInt(document.getElementById('sampleSize').value),
         enable_ai: document.getElementById('enableAI').checked,
-        api_key: document.getElementById('apiKey').value
+        api_key: document.getElementById('apiKey').value,
+        ai_provider: document.getElementById('aiProvider').value,
+        ai_base_url: document.getElementById('aiBaseURL').value,
+        ai_model: document.getElementById('aiModel').value,
+        ai_context: parseInt(document.getElementById('aiContext').value) || 0,
+        ai_domain: document.getElementById('aiDomain').value
     };
     
     // 显示进度条
@@ -159,6 +181,19 @@ function displayResult(result) {
             <h3>${result.stats.enum_tables}</h3>
             <p>枚举表</p>
         </div>
+        <div class="stat-card">
+            <h3>${result.stats.enum_refs || 0}</h3>
+            <p>码表引用字段</p>
+        </div>
+        <div class="stat-card">
+            <h3>${result.stats.new_candidates || 0}</h3>
+            <p>新候选关系</p>
+        </div>
+        ${result.stats.ai_failures ? `
+        <div class="stat-card">
+            <h3>${result.stats.ai_failures}</h3>
+            <p>AI 调用失败</p>
+        </div>` : ''}
     `;
     document.getElementById('stats').innerHTML = statsHTML;
     
@@ -171,6 +206,9 @@ function displayResult(result) {
     // JSON
     document.getElementById('jsonResult').textContent = JSON.stringify(JSON.parse(result.schema_json), null, 2);
     
+    // 关系审核
+    renderReview(JSON.parse(result.schema_json));
+    
     // 滚动到结果
     document.getElementById('resultContainer').scrollIntoView({ behavior: 'smooth' });
 }
@@ -295,3 +333,91 @@ async function listDatabases() {
         alert('❌ 获取失败: ' + error.message);
     }
 }
+
+
+// 审核请求（按数据库区分审核记录）
+async function reviewRequest(payload) {
+    const data = Object.assign({
+        db_type: document.getElementById('dbType').value,
+        host: document.getElementById('host').value,
+        database: document.getElementById('database').value
+    }, payload);
+    
+    const response = await fetch('/api/review', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(data)
+    });
+    const result = await response.json();
+    if (!result.success) {
+        throw new Error(result.message);
+    }
+    return result;
+}
+
+// 渲染关系审核列表
+function renderReview(schema) {
+    const edges = Object.values(schema.edges || {})
+        .filter(edge => edge.type === 'inferred_fk' && edge.properties && edge.properties.from_column)
+        .sort((a, b) => b.confidence - a.confidence);
+    
+    const statusLabel = {
+        accepted: '✅ 已确认',
+        manual: '✍️ 人工添加'
+    };
+    
+    const rows = edges.map(edge => `
+        <tr data-edge-id="${edge.id}">
+            <td><code>${edge.id}</code></td>
+            <td>${edge.confidence.toFixed(2)}</td>
+            <td class="review-status">${statusLabel[edge.properties.review] || '待审核'}</td>
+            <td>
+                <button style="background: #28a745; color: white;" onclick="reviewEdge('${edge.id}', 'accept')">确认</button>
+                <button style="background: #dc3545; color: white;" onclick="reviewEdge('${edge.id}', 'reject')">否决</button>
+            </td>
+        </tr>
+    `);
+    
+    document.getElementById('reviewRows').innerHTML = rows.join('') ||
+        '<tr><td colspan="4">没有推断关系</td></tr>';
+}
+
+// 确认/否决关系
+async function reviewEdge(edgeId, action) {
+    try {
+        await reviewRequest({ action: action, edge_id: edgeId });
+        const row = document.querySelector(`tr[data-edge-id="${edgeId}"]`);
+        if (row) {
+            row.querySelector('.review-status').textContent = action === 'accept' ? '✅ 已确认' : '❌ 已否决';
+        }
+    } catch (error) {
+        alert('❌ 保存失败: ' + error.message);
+    }
+}
+
+// 人工添加关系
+async function addManualRelation() {
+    const from = document.getElementById('manualFrom').value.trim();
+    const to = document.getElementById('manualTo').value.trim();
+    if (!from || !to) {
+        alert('请填写来源和目标（表.列）');
+        return;
+    }
+    
+    try {
+        const result = await reviewRequest({ action: 'add', from: from, to: to });
+        const rows = document.getElementById('reviewRows');
+        rows.insertAdjacentHTML('beforeend', `
+            <tr data-edge-id="${result.edge_id}">
+                <td><code>${result.edge_id}</code></td>
+                <td>1.00</td>
+                <td class="review-status">✍️ 人工添加</td>
+                <td></td>
+            </tr>
+        `);
+        document.getElementById('manualFrom').value = '';
+        document.getElementById('manualTo').value = '';
+    } catch (error) {
+        alert('❌ 添加失败: ' + error.message);
+    }
+}